@@ -0,0 +1,65 @@
+/*
+Copyright 2021. Alexis de Talhouët
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+
+	"github.com/openshift-telco/go-netconf-client/netconf"
+	"github.com/openshift-telco/go-netconf-client/netconf/message"
+	"github.com/openshift-telco/go-netconf-client/netconf/mock"
+)
+
+// toaster is the configuration of the toaster module, encoded by NewEditConfigFromStruct.
+type toaster struct {
+	XMLName        xml.Name `xml:"http://netconfcentral.org/ns/toaster toaster"`
+	DarknessFactor int      `xml:"darknessFactor" nc:"operation=replace"`
+}
+
+var editCommand = command{
+	usage: "edits the candidate configuration under lock, then commits it",
+	server: func() *mock.Transport {
+		return mock.NewTransport()
+	},
+	run: runEdit,
+}
+
+func runEdit(session *netconf.Session, out io.Writer) error {
+	if _, err := checkReply(session.SyncRPC(message.NewLock(message.DatastoreCandidate), 5)); err != nil {
+		return err
+	}
+	// release the lock even when the edit fails
+	defer func() {
+		_, _ = session.SyncRPC(message.NewUnlock(message.DatastoreCandidate), 5)
+		fmt.Fprintln(out, "unlocked candidate")
+	}()
+	fmt.Fprintln(out, "locked candidate")
+
+	edit := message.NewEditConfigFromStruct(message.DatastoreCandidate, message.DefaultOperationTypeMerge, toaster{DarknessFactor: 750})
+	if _, err := checkReply(session.SyncRPC(edit, 5)); err != nil {
+		return err
+	}
+	fmt.Fprintln(out, "edited candidate")
+
+	if _, err := checkReply(session.SyncRPC(message.NewCommit(), 5)); err != nil {
+		return err
+	}
+	fmt.Fprintln(out, "committed")
+	return nil
+}
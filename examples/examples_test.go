@@ -0,0 +1,43 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestCommands(t *testing.T) {
+	expected := map[string][]string{
+		"get":       {"running: <data><toaster", "toaster: <data><toaster"},
+		"edit":      {"locked candidate", "edited candidate", "committed", "unlocked candidate"},
+		"rpc":       {"toast in the making"},
+		"subscribe": {"2021-11-01T10:00:00Z: toast done", "2021-11-01T10:00:01Z: toast done", "2021-11-01T10:00:02Z: toast done"},
+	}
+	if len(expected) != len(commands) {
+		t.Errorf("got %d commands, wanted %d tested ones", len(commands), len(expected))
+	}
+
+	for name, cmd := range commands {
+		t.Run(name, func(t *testing.T) {
+			session, err := mockSession(cmd)
+			if err != nil {
+				t.Fatalf("failed to open session: %v", err)
+			}
+			defer session.Close()
+
+			var out bytes.Buffer
+			if err := cmd.run(session, &out); err != nil {
+				t.Fatalf("failed to run: %v", err)
+			}
+			lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+			if len(lines) != len(expected[name]) {
+				t.Fatalf("got output %q, wanted %d lines", out.String(), len(expected[name]))
+			}
+			for i, prefix := range expected[name] {
+				if !strings.HasPrefix(lines[i], prefix) {
+					t.Errorf("got line %q, wanted it to start with %q", lines[i], prefix)
+				}
+			}
+		})
+	}
+}
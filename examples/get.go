@@ -0,0 +1,59 @@
+/*
+Copyright 2021. Alexis de Talhouët
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/openshift-telco/go-netconf-client/netconf"
+	"github.com/openshift-telco/go-netconf-client/netconf/message"
+	"github.com/openshift-telco/go-netconf-client/netconf/mock"
+)
+
+const toasterConfig = `<toaster xmlns="http://netconfcentral.org/ns/toaster"><darknessFactor>500</darknessFactor></toaster>`
+
+var getCommand = command{
+	usage: "retrieves the running configuration, synchronously then asynchronously",
+	server: func() *mock.Transport {
+		return mock.NewTransport(mock.WithHandler(mock.ReplyData(toasterConfig)))
+	},
+	run: runGet,
+}
+
+func runGet(session *netconf.Session, out io.Writer) error {
+	// SyncRPC waits for the reply, up to the timeout in seconds
+	reply, err := checkReply(session.SyncRPC(message.NewGetConfig(message.DatastoreRunning, "", ""), 5))
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "running: %s\n", reply.Data)
+
+	// AsyncRPC invokes the callback with the reply, or with an error upon timeout
+	replies := make(chan netconf.Event, 1)
+	subtree := `<toaster xmlns="http://netconfcentral.org/ns/toaster"/>`
+	rpc := message.NewGet(message.FilterTypeSubtree, subtree)
+	if err := session.AsyncRPC(rpc, func(event netconf.Event) { replies <- event }); err != nil {
+		return err
+	}
+	event := <-replies
+	if netconf.EventErr(event) != nil {
+		return netconf.EventErr(event)
+	}
+	fmt.Fprintf(out, "toaster: %s\n", event.RPCReply().Data)
+	return nil
+}
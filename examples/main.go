@@ -0,0 +1,122 @@
+/*
+Copyright 2021. Alexis de Talhouët
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Command examples runs small programs showing how to use the library, each as a subcommand:
+//
+//	go run ./examples [-address host:port -user admin -password admin] <command>
+//
+// Without an address, the command runs against an in-memory mock server, see the netconf/mock package.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+
+	"github.com/openshift-telco/go-netconf-client/netconf"
+	"github.com/openshift-telco/go-netconf-client/netconf/message"
+	"github.com/openshift-telco/go-netconf-client/netconf/mock"
+	"golang.org/x/crypto/ssh"
+)
+
+// command is an example runnable as a subcommand.
+type command struct {
+	usage string
+	// server creates the mock server the example runs against when no address is provided.
+	server func() *mock.Transport
+	run    func(session *netconf.Session, out io.Writer) error
+}
+
+var commands = map[string]command{
+	"get":       getCommand,
+	"edit":      editCommand,
+	"rpc":       rpcCommand,
+	"subscribe": subscribeCommand,
+}
+
+func main() {
+	address := flag.String("address", "", "address of the NETCONF server, the mock server is used when empty")
+	user := flag.String("user", "admin", "SSH user")
+	password := flag.String("password", "admin", "SSH password")
+	flag.Usage = usage
+	flag.Parse()
+
+	cmd, ok := commands[flag.Arg(0)]
+	if flag.NArg() != 1 || !ok {
+		usage()
+		os.Exit(2)
+	}
+
+	var session *netconf.Session
+	var err error
+	if *address == "" {
+		session, err = mockSession(cmd)
+	} else {
+		session, err = netconf.Connect(*address, &ssh.ClientConfig{
+			User:            *user,
+			Auth:            []ssh.AuthMethod{ssh.Password(*password)},
+			HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+		})
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer session.Close()
+
+	if err := cmd.run(session, os.Stdout); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func usage() {
+	fmt.Fprintf(flag.CommandLine.Output(), "usage: examples [flags] <command>\n\ncommands:\n")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(flag.CommandLine.Output(), "  %-10s %s\n", name, commands[name].usage)
+	}
+	fmt.Fprintf(flag.CommandLine.Output(), "\nflags:\n")
+	flag.PrintDefaults()
+}
+
+// mockSession opens a session with the mock server of the command.
+func mockSession(cmd command) (*netconf.Session, error) {
+	session, err := netconf.NewSession(cmd.server())
+	if err != nil {
+		return nil, err
+	}
+	if err := session.SendHello(&message.Hello{}); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// checkReply returns the errors of the reply, if any.
+func checkReply(reply *message.RPCReply, err error) (*message.RPCReply, error) {
+	if err != nil {
+		return nil, err
+	}
+	if len(reply.Errors) != 0 {
+		return nil, fmt.Errorf("rpc failed with errors: %w", reply.Err())
+	}
+	return reply, nil
+}
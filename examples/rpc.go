@@ -0,0 +1,44 @@
+/*
+Copyright 2021. Alexis de Talhouët
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/openshift-telco/go-netconf-client/netconf"
+	"github.com/openshift-telco/go-netconf-client/netconf/message"
+	"github.com/openshift-telco/go-netconf-client/netconf/mock"
+)
+
+var rpcCommand = command{
+	usage: "invokes a YANG-defined operation, making toast",
+	server: func() *mock.Transport {
+		return mock.NewTransport()
+	},
+	run: runRPC,
+}
+
+func runRPC(session *netconf.Session, out io.Writer) error {
+	makeToast := `<make-toast xmlns="http://netconfcentral.org/ns/toaster">` +
+		`<toasterDoneness>9</toasterDoneness><toasterToastType>frozen-waffle</toasterToastType></make-toast>`
+	if _, err := checkReply(session.SyncRPC(message.NewRPC(makeToast), 5)); err != nil {
+		return err
+	}
+	fmt.Fprintln(out, "toast in the making")
+	return nil
+}
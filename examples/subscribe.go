@@ -0,0 +1,74 @@
+/*
+Copyright 2021. Alexis de Talhouët
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/openshift-telco/go-netconf-client/netconf"
+	"github.com/openshift-telco/go-netconf-client/netconf/mock"
+)
+
+// toastDone is the notification sent by the toaster module once a toast is made.
+type toastDone struct {
+	EventTime   string `xml:"eventTime"`
+	ToastStatus string `xml:"toastDone>toastStatus"`
+}
+
+// notifications is the number of notifications received by the example before it returns.
+const notifications = 3
+
+var subscribeCommand = command{
+	usage: "subscribes to the NETCONF stream and prints the received notifications",
+	server: func() *mock.Transport {
+		var server *mock.Transport
+		server = mock.NewTransport(mock.WithHandler(func(messageID string, request []byte) []byte {
+			if strings.Contains(string(request), "create-subscription") {
+				// the notifications follow the subscription reply
+				go func() {
+					for i := 0; i < notifications; i++ {
+						server.Notify(fmt.Sprintf(`<notification xmlns="urn:ietf:params:xml:ns:netconf:notification:1.0">`+
+							`<eventTime>2021-11-01T10:00:0%dZ</eventTime>`+
+							`<toastDone xmlns="http://netconfcentral.org/ns/toaster"><toastStatus>done</toastStatus></toastDone>`+
+							`</notification>`, i))
+					}
+				}()
+			}
+			return mock.ReplyOK(messageID, request)
+		}))
+		return server
+	},
+	run: runSubscribe,
+}
+
+func runSubscribe(session *netconf.Session, out io.Writer) error {
+	// the channel is closed when the subscription ends
+	toasts, err := netconf.SubscribeChan(session, "NETCONF", netconf.XMLNotificationDecoder[toastDone]())
+	if err != nil {
+		return err
+	}
+	for i := 0; i < notifications; i++ {
+		toast, ok := <-toasts
+		if !ok {
+			break
+		}
+		fmt.Fprintf(out, "%s: toast %s\n", toast.EventTime, toast.ToastStatus)
+	}
+	return nil
+}
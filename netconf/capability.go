@@ -0,0 +1,179 @@
+/*
+Copyright 2021. Alexis de Talhouët
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package netconf
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// Capability is a capability advertised in a hello, decomposed in its base URI and query parameters.
+// Module capabilities, see RFC 6020, carry the module, revision, features and deviations parameters, e.g.
+// urn:ietf:params:xml:ns:yang:ietf-interfaces?module=ietf-interfaces&revision=2018-02-20&features=if-mib.
+type Capability struct {
+	// URI is the capability without its query, e.g. urn:ietf:params:netconf:capability:candidate:1.0.
+	URI      string
+	Module   string
+	Revision string
+	// Features and Deviations are sorted, so equal capabilities have equal lists whatever the advertised order.
+	Features   []string
+	Deviations []string
+	// Parameters holds the other query parameters, e.g. scheme for the url capability.
+	Parameters map[string]string
+}
+
+// ParseCapability decomposes the capability, failing when it is empty or its query is malformed.
+func ParseCapability(capability string) (Capability, error) {
+	uri, query, _ := strings.Cut(strings.TrimSpace(capability), "?")
+	if uri == "" {
+		return Capability{}, fmt.Errorf("invalid capability %q, missing the URI", capability)
+	}
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return Capability{}, fmt.Errorf("invalid capability %q: %w", capability, err)
+	}
+
+	parsed := Capability{URI: uri}
+	for key := range values {
+		value := values.Get(key)
+		switch key {
+		case "module":
+			parsed.Module = value
+		case "revision":
+			parsed.Revision = value
+		case "features":
+			parsed.Features = splitList(value)
+		case "deviations":
+			parsed.Deviations = splitList(value)
+		default:
+			if parsed.Parameters == nil {
+				parsed.Parameters = make(map[string]string)
+			}
+			parsed.Parameters[key] = value
+		}
+	}
+	return parsed, nil
+}
+
+// ParseCapabilities decomposes the capabilities, skipping the malformed ones.
+func ParseCapabilities(capabilities []string) []Capability {
+	parsed := make([]Capability, 0, len(capabilities))
+	for _, capability := range capabilities {
+		if c, err := ParseCapability(capability); err == nil {
+			parsed = append(parsed, c)
+		}
+	}
+	return parsed
+}
+
+// splitList returns the sorted elements of a comma-separated list.
+func splitList(value string) []string {
+	var list []string
+	for _, element := range strings.Split(value, ",") {
+		if element = strings.TrimSpace(element); element != "" {
+			list = append(list, element)
+		}
+	}
+	sort.Strings(list)
+	return list
+}
+
+// Name returns the URI without its version, e.g. urn:ietf:params:netconf:capability:candidate for candidate:1.0.
+// Module capabilities have no version, their name is their URI.
+func (c Capability) Name() string {
+	if c.Module != "" {
+		return c.URI
+	}
+	return capabilityName(c.URI)
+}
+
+// HasFeature tells whether the module capability advertises the feature.
+func (c Capability) HasFeature(feature string) bool {
+	i := sort.SearchStrings(c.Features, feature)
+	return i < len(c.Features) && c.Features[i] == feature
+}
+
+// Equal tells whether both capabilities have the same URI and parameters.
+func (c Capability) Equal(other Capability) bool {
+	if c.URI != other.URI || c.Module != other.Module || c.Revision != other.Revision ||
+		!equalStrings(c.Features, other.Features) || !equalStrings(c.Deviations, other.Deviations) ||
+		len(c.Parameters) != len(other.Parameters) {
+		return false
+	}
+	for key, value := range c.Parameters {
+		if otherValue, ok := other.Parameters[key]; !ok || otherValue != value {
+			return false
+		}
+	}
+	return true
+}
+
+// equalStrings tells whether both lists have the same elements in the same order.
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// String returns the capability URI, its parameters sorted by name.
+func (c Capability) String() string {
+	values := url.Values{}
+	if c.Module != "" {
+		values.Set("module", c.Module)
+	}
+	if c.Revision != "" {
+		values.Set("revision", c.Revision)
+	}
+	if len(c.Features) != 0 {
+		values.Set("features", strings.Join(c.Features, ","))
+	}
+	if len(c.Deviations) != 0 {
+		values.Set("deviations", strings.Join(c.Deviations, ","))
+	}
+	for key, value := range c.Parameters {
+		values.Set(key, value)
+	}
+	if len(values) == 0 {
+		return c.URI
+	}
+	// Capabilities are not percent-encoded, e.g. commas separate the features.
+	query, _ := url.QueryUnescape(values.Encode())
+	return c.URI + "?" + query
+}
+
+// ServerCapabilities returns the parsed capabilities advertised by the server, skipping the malformed ones.
+func (session *Session) ServerCapabilities() []Capability {
+	return ParseCapabilities(session.Capabilities)
+}
+
+// ModuleCapability returns the capability advertising the module, if any.
+func (session *Session) ModuleCapability(name string) (Capability, bool) {
+	for _, capability := range session.ServerCapabilities() {
+		if capability.Module == name {
+			return capability, true
+		}
+	}
+	return Capability{}, false
+}
@@ -0,0 +1,213 @@
+/*
+Copyright 2021. Alexis de Talhouët
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package netconf
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+/**
+This file provides wire-level capture of the NETCONF traffic, and its replay.
+
+A capture is written in the JSON Lines format: one JSON object per line, per message, in the order they
+were exchanged. Each object has the following fields:
+  - "time": the RFC 3339 timestamp, with nanoseconds, at which the message was sent or received
+  - "direction": either "sent" or "received"
+  - "data": the NETCONF message, with the transport framing (end-of-message or chunks) removed
+*/
+
+// CaptureDirection tells whether a captured message was sent or received by the client.
+type CaptureDirection string
+
+const (
+	// CaptureSent identifies a message sent by the client.
+	CaptureSent CaptureDirection = "sent"
+	// CaptureReceived identifies a message received by the client.
+	CaptureReceived CaptureDirection = "received"
+)
+
+// CaptureRecord is a single message of a capture.
+type CaptureRecord struct {
+	Time      time.Time        `json:"time"`
+	Direction CaptureDirection `json:"direction"`
+	Data      string           `json:"data"`
+}
+
+// WithCapture writes every message sent and received by the session to the provided writer.
+// See NewCaptureTransport for the format.
+func WithCapture(w io.Writer) SessionOption {
+	return func(s *Session) {
+		s.capture = w
+	}
+}
+
+// captureTransport is a Transport recording the exchanged messages before handing them over.
+type captureTransport struct {
+	Transport
+	lock    sync.Mutex
+	encoder *json.Encoder
+}
+
+// NewCaptureTransport wraps the provided transport so every message it sends and receives is written
+// to w, using the JSON Lines format documented in this file.
+func NewCaptureTransport(t Transport, w io.Writer) Transport {
+	return &captureTransport{Transport: t, encoder: json.NewEncoder(w)}
+}
+
+// Send sends the message through the wrapped transport, and records it when successful.
+func (t *captureTransport) Send(data []byte) error {
+	err := t.Transport.Send(data)
+	if err == nil {
+		t.record(CaptureSent, data)
+	}
+	return err
+}
+
+// Receive receives a message from the wrapped transport, and records it when successful.
+func (t *captureTransport) Receive() ([]byte, error) {
+	data, err := t.Transport.Receive()
+	if err == nil {
+		t.record(CaptureReceived, data)
+	}
+	return data, err
+}
+
+// ReceiveFrame receives a message from the wrapped transport as a stream, and records it once fully read.
+func (t *captureTransport) ReceiveFrame() (io.Reader, error) {
+	receiver, ok := t.Transport.(FrameReceiver)
+	if !ok {
+		data, err := t.Receive()
+		if err != nil {
+			return nil, err
+		}
+		return bytes.NewReader(data), nil
+	}
+	frame, err := receiver.ReceiveFrame()
+	if err != nil {
+		return nil, err
+	}
+	return &captureFrame{frame: frame, transport: t}, nil
+}
+
+// captureFrame records the message it streams once its end is reached.
+type captureFrame struct {
+	frame     io.Reader
+	transport *captureTransport
+	data      []byte
+	recorded  bool
+}
+
+func (f *captureFrame) Read(p []byte) (int, error) {
+	n, err := f.frame.Read(p)
+	f.data = append(f.data, p[:n]...)
+	if err == io.EOF && !f.recorded {
+		f.recorded = true
+		f.transport.record(CaptureReceived, f.data)
+	}
+	return n, err
+}
+
+// Stats returns the counters of the wrapped transport, if it reports any.
+func (t *captureTransport) Stats() TransportStats {
+	if reporter, ok := t.Transport.(StatsReporter); ok {
+		return reporter.Stats()
+	}
+	return TransportStats{}
+}
+
+func (t *captureTransport) record(direction CaptureDirection, data []byte) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	// A failing capture must not fail the NETCONF exchange.
+	_ = t.encoder.Encode(CaptureRecord{Time: time.Now(), Direction: direction, Data: string(data)})
+}
+
+// ReadCapture reads all the records of a capture written by a capture transport.
+func ReadCapture(r io.Reader) ([]CaptureRecord, error) {
+	var records []CaptureRecord
+	decoder := json.NewDecoder(r)
+	for {
+		var record CaptureRecord
+		err := decoder.Decode(&record)
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+}
+
+// replayTransport is a Transport replaying the received messages of a capture.
+type replayTransport struct {
+	lock     sync.Mutex
+	received []CaptureRecord
+	closed   chan struct{}
+	once     sync.Once
+}
+
+// NewReplayTransport creates a transport that returns, in order, the received messages of the capture.
+// Sent messages are accepted and discarded. Once all the messages are replayed, Receive blocks until the
+// transport is closed, and then returns io.EOF.
+func NewReplayTransport(records []CaptureRecord) Transport {
+	t := &replayTransport{closed: make(chan struct{})}
+	for _, record := range records {
+		if record.Direction == CaptureReceived {
+			t.received = append(t.received, record)
+		}
+	}
+	return t
+}
+
+// Send discards the provided message.
+func (t *replayTransport) Send(data []byte) error {
+	select {
+	case <-t.closed:
+		return io.ErrClosedPipe
+	default:
+		return nil
+	}
+}
+
+// Receive returns the next received message of the capture.
+func (t *replayTransport) Receive() ([]byte, error) {
+	t.lock.Lock()
+	if len(t.received) > 0 {
+		record := t.received[0]
+		t.received = t.received[1:]
+		t.lock.Unlock()
+		return []byte(record.Data), nil
+	}
+	t.lock.Unlock()
+
+	<-t.closed
+	return nil, io.EOF
+}
+
+// Close closes the transport, unblocking any pending Receive.
+func (t *replayTransport) Close() error {
+	t.once.Do(func() { close(t.closed) })
+	return nil
+}
+
+// SetVersion is a no-op, as captured messages have their framing already removed.
+func (t *replayTransport) SetVersion(version string) {}
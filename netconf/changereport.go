@@ -0,0 +1,329 @@
+/*
+Copyright 2021. Alexis de Talhouët
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package netconf
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/openshift-telco/go-netconf-client/netconf/message"
+)
+
+// ChangeType is the kind of difference found between two snapshots.
+type ChangeType string
+
+const (
+	// ChangeAdded identifies a leaf only present after the change.
+	ChangeAdded ChangeType = "added"
+	// ChangeRemoved identifies a leaf only present before the change.
+	ChangeRemoved ChangeType = "removed"
+	// ChangeModified identifies a leaf whose value differs.
+	ChangeModified ChangeType = "modified"
+)
+
+// Change is a single difference between the before and after snapshots.
+// Path identifies the leaf using element local names; entries of repeated elements, or of the lists declared
+// with ChangeReport.Keys, are identified by the value of their first leaf, which is the list key for YANG-modeled
+// data, e.g. /interfaces/interface[name='eth0']/mtu, or of as many leading leaves as needed to tell them apart.
+// Entries which cannot be told apart, like duplicate leaf-list values, are further identified by their position,
+// e.g. /servers/server[.='ntp1'][2]
+type Change struct {
+	Path   string     `json:"path"`
+	Type   ChangeType `json:"type"`
+	Before string     `json:"before,omitempty"`
+	After  string     `json:"after,omitempty"`
+}
+
+// ChangeReport holds the snapshots of selected subtrees of a datastore taken before and after a change,
+// and the structured differences between them.
+type ChangeReport struct {
+	Datastore  string    `json:"datastore"`
+	Filter     string    `json:"filter,omitempty"`
+	Before     string    `json:"before"`
+	BeforeTime time.Time `json:"beforeTime"`
+	After      string    `json:"after,omitempty"`
+	AfterTime  time.Time `json:"afterTime,omitempty"`
+	Changes    []Change  `json:"changes,omitempty"`
+	// Keys declares lists, indexed by their schema path, e.g. /interfaces/interface, with the number of leading
+	// leaves forming the key of their entries. An element repeated under a same parent is detected as a list, but
+	// a list having a single entry in both snapshots is only known from Keys, so a change of the key of that entry
+	// is reported as an entry replaced by another rather than as a modified leaf.
+	Keys map[string]int `json:"keys,omitempty"`
+
+	session *Session
+}
+
+// NewChangeReport takes the "before" snapshot of the datastore subtrees selected by the subtree filter.
+// An empty filter selects the whole datastore. Call Complete once the change is applied.
+// The timeout, in seconds, bounds the get-config, see SyncRPC.
+func (session *Session) NewChangeReport(datastore string, filter string, timeout int32) (*ChangeReport, error) {
+	ctx, cancel := session.timeoutContext(timeout)
+	defer cancel()
+	return session.NewChangeReportContext(ctx, datastore, filter)
+}
+
+// NewChangeReportContext is NewChangeReport bounded by the context rather than a timeout.
+func (session *Session) NewChangeReportContext(ctx context.Context, datastore string, filter string) (*ChangeReport, error) {
+	report := &ChangeReport{Datastore: datastore, Filter: filter, session: session}
+
+	before, err := report.snapshot(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("fail to snapshot datastore before change: %w", err)
+	}
+	report.Before = before
+	report.BeforeTime = time.Now()
+	return report, nil
+}
+
+// Complete takes the "after" snapshot and computes the differences with the "before" snapshot, which are
+// logged by the session as the audit entry of the change. The timeout, in seconds, bounds the get-config, see
+// SyncRPC.
+func (report *ChangeReport) Complete(timeout int32) error {
+	ctx, cancel := report.session.timeoutContext(timeout)
+	defer cancel()
+	return report.CompleteContext(ctx)
+}
+
+// CompleteContext is Complete bounded by the context rather than a timeout.
+func (report *ChangeReport) CompleteContext(ctx context.Context) error {
+	after, err := report.snapshot(ctx)
+	if err != nil {
+		return fmt.Errorf("fail to snapshot datastore after change: %w", err)
+	}
+	report.After = after
+	report.AfterTime = time.Now()
+
+	changes, err := diffData(report.Before, report.After, report.Keys)
+	if err != nil {
+		return err
+	}
+	report.Changes = changes
+
+	report.session.logger.Info("configuration change", report.session.logArgs(
+		"datastore", report.Datastore,
+		"filter", report.Filter,
+		"before-time", report.BeforeTime,
+		"after-time", report.AfterTime,
+		"changes", report.Changes,
+	)...)
+	return nil
+}
+
+// snapshot retrieves the selected subtrees of the datastore.
+func (report *ChangeReport) snapshot(ctx context.Context) (string, error) {
+	rpc := message.NewGetConfig(report.Datastore, message.FilterTypeSubtree, report.Filter)
+	reply, err := report.session.SyncRPCContext(ctx, rpc)
+	if err != nil {
+		return "", err
+	}
+	if err := report.session.replyErr(reply); err != nil {
+		return "", fmt.Errorf("get-config failed with errors: %w", err)
+	}
+	return reply.RawReply, nil
+}
+
+// xmlNode is a minimal XML tree used to compare snapshots.
+type xmlNode struct {
+	name     string
+	text     string
+	children []*xmlNode
+}
+
+// parseData parses the children of the first `data` element of the provided XML.
+func parseData(raw string) (*xmlNode, error) {
+	decoder := xml.NewDecoder(strings.NewReader(raw))
+	var stack []*xmlNode
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil, message.ErrNoData
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if len(stack) == 0 && t.Name.Local != "data" {
+				continue
+			}
+			node := &xmlNode{name: t.Name.Local}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, node)
+			}
+			stack = append(stack, node)
+		case xml.EndElement:
+			if len(stack) == 0 {
+				continue
+			}
+			node := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if len(stack) == 0 {
+				return node, nil
+			}
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text += string(bytes.TrimSpace(t))
+			}
+		}
+	}
+}
+
+// diffData computes the leaf level differences between two replies, the declared lists being indexed by their
+// schema path with the length of their key.
+func diffData(before string, after string, declared map[string]int) ([]Change, error) {
+	beforeRoot, err := parseData(before)
+	if err != nil {
+		return nil, err
+	}
+	afterRoot, err := parseData(after)
+	if err != nil {
+		return nil, err
+	}
+
+	// An element is keyed when declared as a list or repeated under the same parent in either snapshot, so an
+	// entry keeps the same path when a sibling entry gets added or removed.
+	keys := map[string]int{}
+	findLists(beforeRoot, "", keys)
+	findLists(afterRoot, "", keys)
+	for schemaPath, length := range declared {
+		keys[schemaPath] = length
+	}
+
+	beforeLeaves := map[string]string{}
+	flatten(beforeRoot, "", "", keys, beforeLeaves)
+	afterLeaves := map[string]string{}
+	flatten(afterRoot, "", "", keys, afterLeaves)
+
+	var changes []Change
+	for path, value := range beforeLeaves {
+		afterValue, ok := afterLeaves[path]
+		switch {
+		case !ok:
+			changes = append(changes, Change{Path: path, Type: ChangeRemoved, Before: value})
+		case afterValue != value:
+			changes = append(changes, Change{Path: path, Type: ChangeModified, Before: value, After: afterValue})
+		}
+	}
+	for path, value := range afterLeaves {
+		if _, ok := beforeLeaves[path]; !ok {
+			changes = append(changes, Change{Path: path, Type: ChangeAdded, After: value})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes, nil
+}
+
+// findLists records, indexed by their schema path, the elements repeated under a same parent along with the
+// number of leading leaves needed to tell their entries apart.
+func findLists(node *xmlNode, schemaPath string, keys map[string]int) {
+	entries := map[string][]*xmlNode{}
+	for _, child := range node.children {
+		entries[child.name] = append(entries[child.name], child)
+	}
+	for name, siblings := range entries {
+		if len(siblings) < 2 {
+			continue
+		}
+		childPath := schemaPath + "/" + name
+		if length := keyLength(siblings); length > keys[childPath] {
+			keys[childPath] = length
+		}
+	}
+	for _, child := range node.children {
+		findLists(child, schemaPath+"/"+child.name, keys)
+	}
+}
+
+// keyLength returns the smallest number of leading leaves identifying each entry, or all the leading leaves
+// when some entries cannot be told apart.
+func keyLength(entries []*xmlNode) int {
+	longest := 1
+	for _, entry := range entries {
+		if leaves := leadingLeaves(entry); leaves > longest {
+			longest = leaves
+		}
+	}
+	for length := 1; length < longest; length++ {
+		seen := map[string]bool{}
+		unique := true
+		for _, entry := range entries {
+			key := entryKey(entry, length)
+			if seen[key] {
+				unique = false
+				break
+			}
+			seen[key] = true
+		}
+		if unique {
+			return length
+		}
+	}
+	return longest
+}
+
+// leadingLeaves returns the number of leaves preceding the first non-leaf child of the node.
+func leadingLeaves(node *xmlNode) int {
+	for i, child := range node.children {
+		if len(child.children) != 0 {
+			return i
+		}
+	}
+	return len(node.children)
+}
+
+// flatten collects the leaves of the tree, indexed by their path.
+func flatten(node *xmlNode, path string, schemaPath string, keys map[string]int, leaves map[string]string) {
+	// entries having the same key are told apart by their position
+	occurrences := map[string]int{}
+	for _, child := range node.children {
+		childSchemaPath := schemaPath + "/" + child.name
+		childPath := path + "/" + child.name
+		if length, ok := keys[childSchemaPath]; ok {
+			childPath += entryKey(child, length)
+			occurrences[childPath]++
+			if occurrence := occurrences[childPath]; occurrence > 1 {
+				childPath += fmt.Sprintf("[%d]", occurrence)
+			}
+		}
+		if len(child.children) == 0 {
+			leaves[childPath] = child.text
+			continue
+		}
+		flatten(child, childPath, childSchemaPath, keys, leaves)
+	}
+}
+
+// entryKey returns the predicate identifying an entry of a repeated element from up to length of its leading
+// leaves, or from its value for a leaf-list entry.
+func entryKey(node *xmlNode, length int) string {
+	if len(node.children) == 0 {
+		return fmt.Sprintf("[.='%s']", node.text)
+	}
+	var key strings.Builder
+	for _, child := range node.children[:min(length, leadingLeaves(node))] {
+		fmt.Fprintf(&key, "[%s='%s']", child.name, child.text)
+	}
+	return key.String()
+}
@@ -0,0 +1,146 @@
+/*
+Copyright 2021. Alexis de Talhouët
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package netconf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/openshift-telco/go-netconf-client/netconf/message"
+)
+
+// defaultConfirmTimeout is the time a confirmed commit waits for its confirmation when none is given, see RFC 6241.
+const defaultConfirmTimeout = 600 * time.Second
+
+// maxConfirmMargin bounds the time CommitConfirmed keeps to confirm, or cancel, the commit before its timeout.
+const maxConfirmMargin = 30 * time.Second
+
+// ErrConfirmedCommitNotSupported is returned by ConfirmedCommit when the server does not advertise the
+// :confirmed-commit capability.
+var ErrConfirmedCommitNotSupported = errors.New("server does not support confirmed commits")
+
+// Commit commits the candidate datastore to the running one.
+// It fails when the server replies with rpc-errors.
+func (session *Session) Commit(ctx context.Context) error {
+	return session.rpcOK(ctx, "commit", message.NewCommit())
+}
+
+// PendingCommit is a confirmed commit waiting for its confirmation, see Session.ConfirmedCommit.
+type PendingCommit struct {
+	session *Session
+	// PersistID identifies a persistent confirmed commit, empty when the commit is bound to the session.
+	PersistID string
+	// Deadline is when the server reverts the commit unless it is confirmed.
+	Deadline time.Time
+}
+
+// ConfirmedCommit commits the candidate datastore to the running one, the server reverting the commit unless it is
+// confirmed within the timeout, so a change cutting the management access rolls back on its own. A zero timeout
+// stands for the server default, 600 seconds.
+// The commit is persistent when persist is not empty, surviving the session to be confirmed, or cancelled, from
+// another one; otherwise closing the session cancels it.
+//
+// The caller then checks the change and calls Confirm, or Cancel, on the returned PendingCommit.
+func (session *Session) ConfirmedCommit(ctx context.Context, timeout time.Duration, persist string) (*PendingCommit, error) {
+	if !session.features.ConfirmedCommit {
+		return nil, ErrConfirmedCommitNotSupported
+	}
+	if timeout == 0 {
+		timeout = defaultConfirmTimeout
+	}
+	start := time.Now()
+	if err := session.rpcOK(ctx, "commit", message.NewConfirmedCommit(timeout, persist)); err != nil {
+		return nil, err
+	}
+	return &PendingCommit{session: session, PersistID: persist, Deadline: start.Add(timeout)}, nil
+}
+
+// Confirm makes the confirmed commit permanent.
+// It fails when the server replies with rpc-errors, e.g. when the timeout already elapsed.
+func (c *PendingCommit) Confirm(ctx context.Context) error {
+	return c.session.rpcOK(ctx, "commit", message.NewConfirmingCommit(c.PersistID))
+}
+
+// Cancel reverts the confirmed commit right away, instead of waiting for the timeout to elapse.
+// It fails when the server replies with rpc-errors.
+func (c *PendingCommit) Cancel(ctx context.Context) error {
+	return c.session.CancelCommit(ctx, c.PersistID)
+}
+
+// CommitConfirmed commits the candidate datastore with a persistent confirmed commit, then checks the change with
+// the confirm function: the commit is confirmed when it returns nil, and cancelled when it fails, the error being
+// returned. A zero timeout stands for the server default, 600 seconds.
+//
+// The confirm function is given a context expiring a bit before the timeout, a fifth of it and at most 30 seconds,
+// the commit being cancelled when the function did not return by then, so it is never left to the server timer.
+// As the commit is persistent, when the session drops while the change is checked, e.g. as it briefly cut the
+// management access, the session is reconnected, see WithRedial, to confirm or cancel the commit with its
+// persist-id.
+func (session *Session) CommitConfirmed(
+	ctx context.Context, timeout time.Duration, confirm func(ctx context.Context) error,
+) error {
+	if timeout == 0 {
+		timeout = defaultConfirmTimeout
+	}
+	pending, err := session.ConfirmedCommit(ctx, timeout, message.UUIDMessageID())
+	if err != nil {
+		return err
+	}
+
+	margin := timeout / 5
+	if margin > maxConfirmMargin {
+		margin = maxConfirmMargin
+	}
+	confirmCtx, cancel := context.WithDeadline(ctx, pending.Deadline.Add(-margin))
+	defer cancel()
+	checked := make(chan error, 1)
+	go func() {
+		checked <- confirm(confirmCtx)
+	}()
+
+	select {
+	case err = <-checked:
+	case <-confirmCtx.Done():
+		err = fmt.Errorf("confirmed commit not checked in time: %w", confirmCtx.Err())
+	}
+
+	// the commit is settled even when the caller context is done, within its timeout
+	settleCtx, settleCancel := context.WithDeadline(context.Background(), pending.Deadline)
+	defer settleCancel()
+	if err != nil {
+		if cancelErr := session.retryReconnected(settleCtx, pending.Cancel); cancelErr != nil {
+			return fmt.Errorf("%w, and failed to cancel the commit: %v", err, cancelErr)
+		}
+		return err
+	}
+	return session.retryReconnected(settleCtx, pending.Confirm)
+}
+
+// retryReconnected runs the operation, running it again once the session is reconnected when it failed because
+// the session was closed, and the session can be reconnected.
+func (session *Session) retryReconnected(ctx context.Context, operation func(ctx context.Context) error) error {
+	err := operation(ctx)
+	if err == nil || !errors.Is(err, ErrSessionClosed) || session.redial == nil {
+		return err
+	}
+	if reconnectErr := session.Reconnect(nil); reconnectErr != nil {
+		return fmt.Errorf("%w, and %v", err, reconnectErr)
+	}
+	return operation(ctx)
+}
@@ -0,0 +1,221 @@
+/*
+Copyright 2021. Alexis de Talhouët
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package netconf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/openshift-telco/go-netconf-client/netconf/message"
+)
+
+var (
+	// ErrTestOptionNotSupported is returned by EditConfig when the operation sets a test-option while the server
+	// does not advertise the :validate capability.
+	ErrTestOptionNotSupported = errors.New("server does not support the edit-config test-option")
+	// ErrRollbackOnErrorNotSupported is returned by EditConfig when the operation sets the rollback-on-error
+	// error-option while the server does not advertise the :rollback-on-error capability.
+	ErrRollbackOnErrorNotSupported = errors.New("server does not support the rollback-on-error error-option")
+	// ErrURLNotSupported is returned when an operation uses a URL while the server does not advertise the :url
+	// capability, or not the scheme of the URL.
+	ErrURLNotSupported = errors.New("server does not support the url")
+)
+
+// EditConfig applies the edit-config operation, see message.NewEditConfigWithOptions.
+// It fails with ErrTestOptionNotSupported or ErrRollbackOnErrorNotSupported when the operation sets a
+// test-option or the rollback-on-error error-option the server does not advertise, with ErrURLNotSupported
+// when applying a URL the server does not accept, and when the server replies with rpc-errors.
+func (session *Session) EditConfig(ctx context.Context, edit *message.EditConfig) error {
+	if edit.URL != "" {
+		if err := session.checkURL(edit.URL); err != nil {
+			return err
+		}
+	}
+	if edit.TestOption != "" && !session.features.Validate {
+		return ErrTestOptionNotSupported
+	}
+	if edit.ErrorOption == message.ErrorOptionRollbackOnError && !session.features.RollbackOnError {
+		return ErrRollbackOnErrorNotSupported
+	}
+	return session.rpcOK(ctx, "edit-config", edit)
+}
+
+// ConfigData is the configuration returned by GetConfigData.
+type ConfigData struct {
+	// Raw is the content of the data element, see message.RPCReply.DataContent.
+	Raw []byte
+	// Nodes are the top-level elements of the configuration, see message.RPCReply.DataNodes.
+	Nodes []message.Node
+}
+
+// GetConfigData retrieves the configuration of the datastore, e.g. message.DatastoreRunning, selected by the
+// subtree filter, and returns the content of the data element of the reply. An empty filter selects the whole
+// configuration. It fails when the server replies with rpc-errors.
+func (session *Session) GetConfigData(ctx context.Context, datastore string, filter string) (*ConfigData, error) {
+	reply, err := session.SyncRPCContext(ctx, message.NewGetConfig(datastore, message.FilterTypeSubtree, filter))
+	if err != nil {
+		return nil, err
+	}
+	if err := session.replyErr(reply); err != nil {
+		return nil, fmt.Errorf("get-config failed with errors: %w", err)
+	}
+
+	raw, err := reply.DataContent()
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := reply.DataNodes()
+	if err != nil {
+		return nil, err
+	}
+	return &ConfigData{Raw: raw, Nodes: nodes}, nil
+}
+
+// Validate checks the configuration of the provided datastore, e.g. message.DatastoreCandidate before a commit.
+// It fails when the server replies with rpc-errors.
+func (session *Session) Validate(ctx context.Context, datastore string) error {
+	return session.rpcOK(ctx, "validate", message.NewValidate(datastore))
+}
+
+// ValidateConfig checks the provided configuration, without applying it.
+// It fails when the server replies with rpc-errors.
+func (session *Session) ValidateConfig(ctx context.Context, data string) error {
+	return session.rpcOK(ctx, "validate", message.NewValidateConfig(data))
+}
+
+// CopyConfig replaces the target datastore with the content of the source one, e.g. copying
+// message.DatastoreRunning to message.DatastoreStartup on servers advertising the :startup capability.
+// It fails when the server replies with rpc-errors.
+func (session *Session) CopyConfig(ctx context.Context, target string, source string) error {
+	return session.rpcOK(ctx, "copy-config", message.NewCopyConfig(target, source))
+}
+
+// CopyConfigFromURL replaces the target datastore with the configuration located at the URL.
+// It fails with ErrURLNotSupported when the server does not accept the URL, and when the server replies with
+// rpc-errors.
+func (session *Session) CopyConfigFromURL(ctx context.Context, target string, sourceURL string) error {
+	return session.CopyConfigDatastores(ctx, message.NewDatastore(target), message.NewURLDatastore(sourceURL))
+}
+
+// CopyConfigToURL saves the source datastore to the URL.
+// It fails with ErrURLNotSupported when the server does not accept the URL, and when the server replies with
+// rpc-errors.
+func (session *Session) CopyConfigToURL(ctx context.Context, targetURL string, source string) error {
+	return session.CopyConfigDatastores(ctx, message.NewURLDatastore(targetURL), message.NewDatastore(source))
+}
+
+// CopyConfigDatastores replaces the target with the content of the source, see message.NewCopyConfigDatastores.
+// It fails when the target or the source is not valid, with ErrURLNotSupported when the server does not accept
+// the URL of the target or the source, and when the server replies with rpc-errors.
+func (session *Session) CopyConfigDatastores(ctx context.Context, target *message.Datastore, source *message.Datastore) error {
+	rpc, err := message.NewCopyConfigDatastores(target, source)
+	if err != nil {
+		return err
+	}
+	for _, d := range []*message.Datastore{target, source} {
+		if d.URL != "" {
+			if err := session.checkURL(d.URL); err != nil {
+				return err
+			}
+		}
+	}
+	return session.rpcOK(ctx, "copy-config", rpc)
+}
+
+// CopyConfigInline replaces the target datastore with the provided configuration.
+// It fails when the server replies with rpc-errors.
+func (session *Session) CopyConfigInline(ctx context.Context, target string, data string) error {
+	return session.rpcOK(ctx, "copy-config", message.NewCopyConfigInline(target, data))
+}
+
+// DeleteConfig deletes the target datastore, e.g. message.DatastoreStartup, which cannot be the running one.
+// It fails when the server replies with rpc-errors.
+func (session *Session) DeleteConfig(ctx context.Context, target string) error {
+	return session.rpcOK(ctx, "delete-config", message.NewDeleteConfig(target))
+}
+
+// DiscardChanges reverts the candidate datastore to the running configuration, e.g. to abort the edits failing
+// validation. It fails when the server replies with rpc-errors.
+func (session *Session) DiscardChanges(ctx context.Context) error {
+	return session.rpcOK(ctx, "discard-changes", message.NewDiscardChanges())
+}
+
+// CancelCommit cancels the ongoing confirmed commit, reverting the configuration to its state before the commit.
+// The persistID is the one given to a persistent confirmed commit, empty otherwise.
+// It fails when the server replies with rpc-errors.
+func (session *Session) CancelCommit(ctx context.Context, persistID string) error {
+	return session.rpcOK(ctx, "cancel-commit", message.NewCancelCommit(persistID))
+}
+
+// KillSession forces the termination of another session, e.g. a stale one holding a datastore lock: the server
+// aborts its operations, releases its locks and closes its connection. It cannot kill the current session, which
+// is ended with Close. It fails when the server replies with rpc-errors.
+func (session *Session) KillSession(ctx context.Context, sessionID uint32) error {
+	if sessionID == 0 {
+		return fmt.Errorf("invalid session-id 0")
+	}
+	if sessionID == session.SessionID() {
+		return fmt.Errorf("cannot kill the current session %d, use Close instead", sessionID)
+	}
+	return session.rpcOK(ctx, "kill-session", message.NewKillSession(strconv.FormatUint(uint64(sessionID), 10)))
+}
+
+// Action invokes the YANG 1.1 action described by the data tree, see message.NewAction, and returns the reply
+// holding the action output, if any. It fails when the server replies with rpc-errors.
+func (session *Session) Action(ctx context.Context, data string) (*message.RPCReply, error) {
+	reply, err := session.SyncRPCContext(ctx, message.NewAction(data))
+	if err != nil {
+		return nil, err
+	}
+	if err := session.replyErr(reply); err != nil {
+		return reply, fmt.Errorf("action failed with errors: %w", err)
+	}
+	return reply, nil
+}
+
+// checkURL tells whether the server accepts the URL, according to its :url capability.
+func (session *Session) checkURL(rawURL string) error {
+	if !session.features.URL {
+		return ErrURLNotSupported
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid url %s: %w", rawURL, err)
+	}
+	for _, scheme := range session.features.URLSchemes {
+		if strings.EqualFold(scheme, u.Scheme) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: scheme %s is not one of %v", ErrURLNotSupported, u.Scheme, session.features.URLSchemes)
+}
+
+// rpcOK executes the operation, failing when no reply is received or the reply carries rpc-errors.
+func (session *Session) rpcOK(ctx context.Context, name string, operation message.RPCMethod) error {
+	reply, err := session.SyncRPCContext(ctx, operation)
+	if err != nil {
+		return err
+	}
+	if err := session.replyErr(reply); err != nil {
+		return fmt.Errorf("%s failed with errors: %w", name, err)
+	}
+	return nil
+}
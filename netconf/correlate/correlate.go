@@ -0,0 +1,157 @@
+/*
+Copyright 2021. Alexis de Talhouët
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package correlate matches NETCONF messages with the handlers registered for them, e.g. an rpc-reply with the
+// handler of the rpc carrying the same message-id. It is independent of the session, so proxies and servers can
+// reuse it.
+package correlate
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Correlator holds the handlers registered for message identifiers. It is safe for concurrent use.
+type Correlator[H any] struct {
+	lock    sync.Mutex
+	entries map[string]entry[H]
+	stats   Stats
+}
+
+type entry[H any] struct {
+	handler    H
+	registered time.Time
+	deadline   time.Time
+}
+
+// Expired is a registration whose deadline passed before it was matched.
+type Expired[H any] struct {
+	ID      string
+	Handler H
+	// Registered is when the handler was registered.
+	Registered time.Time
+}
+
+// Stats holds the counters of a correlator.
+type Stats struct {
+	// Registered is the number of registrations.
+	Registered uint64
+	// Matched is the number of registrations matched, and removed, by Match.
+	Matched uint64
+	// Unmatched is the number of calls to Match or Peek for an identifier having no registration.
+	Unmatched uint64
+	// TimedOut is the number of registrations expired by Timeouts.
+	TimedOut uint64
+	// Pending is the number of current registrations.
+	Pending int
+	// MaxLatency is the longest time observed between a registration and its match.
+	MaxLatency time.Duration
+}
+
+// New creates a correlator with no registration.
+func New[H any]() *Correlator[H] {
+	return &Correlator[H]{entries: make(map[string]entry[H])}
+}
+
+// Register registers the handler for the identifier, replacing any previous registration.
+// A zero deadline means the registration never expires, see Timeouts.
+func (c *Correlator[H]) Register(id string, handler H, deadline time.Time) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.entries[id] = entry[H]{handler: handler, registered: time.Now(), deadline: deadline}
+	c.stats.Registered++
+}
+
+// Match returns the handler registered for the identifier, and removes the registration.
+func (c *Correlator[H]) Match(id string) (H, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	e, ok := c.entries[id]
+	if !ok {
+		c.stats.Unmatched++
+		return e.handler, false
+	}
+	delete(c.entries, id)
+	c.stats.Matched++
+	if latency := time.Since(e.registered); latency > c.stats.MaxLatency {
+		c.stats.MaxLatency = latency
+	}
+	return e.handler, true
+}
+
+// Peek returns the handler registered for the identifier, keeping the registration, as needed by
+// subscriptions receiving several messages.
+func (c *Correlator[H]) Peek(id string) (H, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	e, ok := c.entries[id]
+	if !ok {
+		c.stats.Unmatched++
+	}
+	return e.handler, ok
+}
+
+// Remove removes the registration of the identifier, if any.
+func (c *Correlator[H]) Remove(id string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	delete(c.entries, id)
+}
+
+// Len returns the number of current registrations.
+func (c *Correlator[H]) Len() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return len(c.entries)
+}
+
+// IDs returns the identifiers having a registration, sorted.
+func (c *Correlator[H]) IDs() []string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	ids := make([]string, 0, len(c.entries))
+	for id := range c.entries {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Timeouts removes, and returns, the registrations whose deadline is before now.
+func (c *Correlator[H]) Timeouts(now time.Time) []Expired[H] {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	var expired []Expired[H]
+	for id, e := range c.entries {
+		if e.deadline.IsZero() || !e.deadline.Before(now) {
+			continue
+		}
+		delete(c.entries, id)
+		c.stats.TimedOut++
+		expired = append(expired, Expired[H]{ID: id, Handler: e.handler, Registered: e.registered})
+	}
+	return expired
+}
+
+// Stats returns a snapshot of the correlator counters.
+func (c *Correlator[H]) Stats() Stats {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	stats := c.stats
+	stats.Pending = len(c.entries)
+	return stats
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2021. Alexis de Talhouët
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package netconf
+
+import (
+	"sync"
+	"time"
+)
+
+// recentErrorsSize is the number of errors kept for the DebugSnapshot.
+const recentErrorsSize = 16
+
+// DebugSnapshot is the client side view of a session, meant to be attached to bug reports against devices,
+// hence the JSON tags. See Session.DebugSnapshot.
+type DebugSnapshot struct {
+	CapturedAt         time.Time `json:"capturedAt"`
+	Version            string    `json:"version"`
+	SessionID          uint32    `json:"sessionId"`
+	State              string    `json:"state"`
+	FramingVersion     string    `json:"framingVersion"`
+	ServerCapabilities []string  `json:"serverCapabilities"`
+	ClientCapabilities []string  `json:"clientCapabilities"`
+	// Pending lists the identifiers having a callback registered: the message-ids of the RPCs awaiting their
+	// reply, and the keys of the notification and error handlers.
+	Pending        []string             `json:"pending"`
+	InFlight       int                  `json:"inFlight"`
+	SendQueueDepth int                  `json:"sendQueueDepth"`
+	IdleSince      time.Time            `json:"idleSince"`
+	Subscription   SubscriptionSnapshot `json:"subscription"`
+	Stats          SessionStats         `json:"stats"`
+	RecentErrors   []ErrorRecord        `json:"recentErrors,omitempty"`
+}
+
+// SubscriptionSnapshot is the state of the notification subscription of a session.
+type SubscriptionSnapshot struct {
+	// Active tells whether a create-subscription was replied successfully and did not complete yet.
+	Active bool `json:"active"`
+	// Interleave tells whether the server accepts RPCs during the subscription.
+	Interleave bool `json:"interleave"`
+}
+
+// ErrorRecord is an error raised by a session, see DebugSnapshot.
+type ErrorRecord struct {
+	At    time.Time `json:"at"`
+	Error string    `json:"error"`
+}
+
+// DebugSnapshot captures the state of the session: its capabilities, the pending requests, the subscription,
+// the counters, and the most recent errors reported on Errors or failing the session.
+func (session *Session) DebugSnapshot() DebugSnapshot {
+	snapshot := DebugSnapshot{
+		CapturedAt:         time.Now(),
+		Version:            Version(),
+		SessionID:          session.SessionID(),
+		State:              session.State().String(),
+		FramingVersion:     session.FramingVersion(),
+		ServerCapabilities: append([]string(nil), session.Capabilities...),
+		ClientCapabilities: append([]string(nil), session.helloCapabilities...),
+		InFlight:           session.InFlight(),
+		SendQueueDepth:     session.SendQueueDepth(),
+		IdleSince:          session.IdleSince(),
+		Subscription: SubscriptionSnapshot{
+			Active:     session.activeSubscription() != nil,
+			Interleave: session.features.Interleave,
+		},
+		Stats:        session.Stats(),
+		RecentErrors: session.recentErrors.snapshot(),
+	}
+	if session.Listener != nil {
+		snapshot.Pending = session.Listener.callbacks.IDs()
+	}
+	return snapshot
+}
+
+// recentErrors keeps the most recent errors of a session.
+type recentErrors struct {
+	lock    sync.Mutex
+	records []ErrorRecord
+}
+
+// record keeps the error, dropping the oldest one once recentErrorsSize are kept.
+func (e *recentErrors) record(err error) {
+	if err == nil {
+		return
+	}
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	e.records = append(e.records, ErrorRecord{At: time.Now(), Error: err.Error()})
+	if len(e.records) > recentErrorsSize {
+		e.records = e.records[len(e.records)-recentErrorsSize:]
+	}
+}
+
+// snapshot returns the kept errors, oldest first.
+func (e *recentErrors) snapshot() []ErrorRecord {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	return append([]ErrorRecord(nil), e.records...)
+}
@@ -0,0 +1,129 @@
+/*
+Copyright 2021. Alexis de Talhouët
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package netconf
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"time"
+)
+
+// DialOption allow optional configuration of the connection to the device.
+type DialOption func(*dialConfig)
+
+// dialConfig holds the configuration set by the DialOption.
+type dialConfig struct {
+	sourceIP        net.IP
+	sourceZone      string
+	sourceInterface string
+}
+
+// WithSourceAddress makes the connection originate from the provided local IP address, as required by
+// management networks only accepting connections from a specific loopback address.
+// IPv6 link-local addresses carry the zone identifier of their interface, e.g. `fe80::2%eth0`.
+func WithSourceAddress(ip string) DialOption {
+	return func(c *dialConfig) {
+		addr, err := netip.ParseAddr(ip)
+		if err != nil {
+			// keep the invalid address around so dialing reports it
+			c.sourceIP = net.IP{}
+			return
+		}
+		c.sourceIP = addr.AsSlice()
+		c.sourceZone = addr.Zone()
+	}
+}
+
+// WithSourceInterface makes the connection originate from the first address of the provided network interface
+// matching the IP family of the target.
+func WithSourceInterface(name string) DialOption {
+	return func(c *dialConfig) {
+		c.sourceInterface = name
+	}
+}
+
+// WithDialOptions sets the DialOption used by the session factories when connecting to the device.
+func WithDialOptions(options ...DialOption) SessionOption {
+	return func(s *Session) {
+		s.dialOptions = append(s.dialOptions, options...)
+	}
+}
+
+// dialOptionsFrom returns the DialOption set through WithDialOptions in the provided session options.
+func dialOptionsFrom(options []SessionOption) []DialOption {
+	s := new(Session)
+	for _, opt := range options {
+		opt(s)
+	}
+	return s.dialOptions
+}
+
+// newDialer returns a dialer to reach the address according to the DialOption.
+func newDialer(address string, timeout time.Duration, options []DialOption) (*net.Dialer, error) {
+	config := &dialConfig{}
+	for _, opt := range options {
+		opt(config)
+	}
+
+	dialer := &net.Dialer{Timeout: timeout}
+	switch {
+	case config.sourceInterface != "":
+		local, err := interfaceAddress(config.sourceInterface, address)
+		if err != nil {
+			return nil, err
+		}
+		dialer.LocalAddr = local
+	case config.sourceIP != nil:
+		if len(config.sourceIP) == 0 {
+			return nil, fmt.Errorf("invalid source address")
+		}
+		dialer.LocalAddr = &net.TCPAddr{IP: config.sourceIP, Zone: config.sourceZone}
+	}
+	return dialer, nil
+}
+
+// interfaceAddress returns the first address of the interface matching the IP family of the address.
+func interfaceAddress(name string, address string) (*net.TCPAddr, error) {
+	iface, err := net.InterfaceByName(name)
+	if err != nil {
+		return nil, err
+	}
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return nil, err
+	}
+
+	wantV4 := true
+	if host, _, err := net.SplitHostPort(address); err == nil {
+		if ip, err := netip.ParseAddr(host); err == nil {
+			wantV4 = ip.Unmap().Is4()
+		}
+	}
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok || (ipNet.IP.To4() != nil) != wantV4 {
+			continue
+		}
+		local := &net.TCPAddr{IP: ipNet.IP}
+		if ipNet.IP.IsLinkLocalUnicast() {
+			local.Zone = name
+		}
+		return local, nil
+	}
+	return nil, fmt.Errorf("interface %s has no address matching the family of %s", name, address)
+}
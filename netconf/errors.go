@@ -0,0 +1,187 @@
+/*
+Copyright 2021. Alexis de Talhouët
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package netconf
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/openshift-telco/go-netconf-client/netconf/message"
+)
+
+// errorsBuffer is the number of errors buffered by the Errors channel before the subsequent ones are dropped.
+const errorsBuffer = 32
+
+// ErrUnknownMessage is the error of the received messages which are neither an rpc-reply nor a notification.
+var ErrUnknownMessage = errors.New("unknown message type")
+
+// ReceiveError is an error raised while receiving, or decoding, a message of the session.
+type ReceiveError struct {
+	// Message is the received message, nil when the error occurred while receiving it.
+	Message []byte
+	Err     error
+}
+
+// Error generates a string representation of the receive error
+func (e *ReceiveError) Error() string {
+	if e.Message == nil {
+		return fmt.Sprintf("fail to receive message: %s", e.Err)
+	}
+	return fmt.Sprintf("fail to decode received message: %s", e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *ReceiveError) Unwrap() error {
+	return e.Err
+}
+
+// ProtocolError reports a message of the session breaking RFC 6241: a client hello carrying a session-id, or an
+// rpc-reply whose message-id matches no outstanding request, e.g. the late reply of a timed out RPC.
+type ProtocolError struct {
+	Reason string
+	// Message is the offending message, nil when it was not sent.
+	Message []byte
+}
+
+// Error generates a string representation of the protocol error
+func (e *ProtocolError) Error() string {
+	return "netconf protocol violation: " + e.Reason
+}
+
+// ErrorPolicy decides whether the session survives the errors raised while receiving messages: messages
+// which cannot be received or decoded, and unknown messages. Errors closing the session, e.g. an unrecoverable
+// framing, and the server closing it, are not subject to the policy.
+type ErrorPolicy struct {
+	// maxConsecutive is the number of consecutive errors failing the session, zero for never
+	maxConsecutive int
+}
+
+var (
+	// ContinueOnError keeps the session open whatever the errors, which are only reported. It is the default.
+	ContinueOnError = ErrorPolicy{}
+	// FailFast fails the session on the first error.
+	FailFast = ErrorPolicy{maxConsecutive: 1}
+)
+
+// FailAfter fails the session after the provided number of consecutive errors, a message received successfully
+// resetting the count.
+func FailAfter(consecutive int) ErrorPolicy {
+	if consecutive < 1 {
+		panic(fmt.Sprintf("provided number of consecutive errors %d is not supported, expecting a positive number", consecutive))
+	}
+	return ErrorPolicy{maxConsecutive: consecutive}
+}
+
+// WithErrorPolicy sets the policy applied to the errors raised while receiving messages, see ErrorPolicy.
+// A session failed by the policy is closed with an *ErrorPolicyError.
+func WithErrorPolicy(policy ErrorPolicy) SessionOption {
+	return func(s *Session) {
+		s.errorPolicy = policy
+	}
+}
+
+// ErrorPolicyError is the error failing a session once the errors raised while receiving messages exceed
+// its ErrorPolicy.
+type ErrorPolicyError struct {
+	// Consecutive is the number of consecutive errors raised.
+	Consecutive int
+	// Err is the last error raised.
+	Err error
+}
+
+// Error generates a string representation of the error policy error
+func (e *ErrorPolicyError) Error() string {
+	return fmt.Sprintf("closing session after %d consecutive receive errors: %s", e.Consecutive, e.Err)
+}
+
+// Unwrap returns the last error raised
+func (e *ErrorPolicyError) Unwrap() error {
+	return e.Err
+}
+
+// fails tells whether the number of consecutive errors fails the session.
+func (p ErrorPolicy) fails(consecutive int) bool {
+	return p.maxConsecutive > 0 && consecutive >= p.maxConsecutive
+}
+
+// WarningPolicy decides whether the rpc-errors of the warning severity fail the operations, e.g. EditConfig, as
+// the ones of the error severity do. SyncRPC and AsyncRPC return the reply whatever its rpc-errors, the policy
+// deciding whether the RPC is counted as failed, see Stats, and recorded in the support bundles.
+type WarningPolicy int
+
+const (
+	// WarningsFail fails the operations replied with warnings, as with errors. It is the default.
+	WarningsFail WarningPolicy = iota
+	// WarningsIgnore lets the operations replied with warnings succeed. Servers may reply with warnings along
+	// with the data, e.g. the get-config of a deprecated node.
+	WarningsIgnore
+)
+
+// WithWarningPolicy sets the policy applied to the rpc-errors of the warning severity, see WarningPolicy.
+func WithWarningPolicy(policy WarningPolicy) SessionOption {
+	return func(s *Session) {
+		s.warningPolicy = policy
+	}
+}
+
+// replyErr returns the rpc-errors failing the reply following the warning policy, nil when there is none.
+func (session *Session) replyErr(reply *message.RPCReply) error {
+	if session.warningPolicy == WarningsIgnore {
+		return reply.FatalErr()
+	}
+	return reply.Err()
+}
+
+// Errors returns a channel delivering the errors raised while receiving messages: a *ReceiveError when a message
+// cannot be received or decoded, framing errors, a *ProtocolError for an rpc-reply matching no outstanding request,
+// and a *KeepaliveError once the server stops replying to keepalives.
+// The same errors are dispatched to the ErrorEventHandler registration.
+//
+// The channel buffers the latest errors, the subsequent ones being dropped until it is drained, and is closed once
+// the session stops receiving messages. Reconnect creates a new channel.
+func (session *Session) Errors() <-chan error {
+	session.errLock.Lock()
+	defer session.errLock.Unlock()
+	return session.errs
+}
+
+// reportError delivers an error raised while receiving messages to the Errors channel and to the
+// ErrorEventHandler registration.
+func (session *Session) reportError(err error) {
+	session.metrics.receiveFailed()
+	session.recentErrors.record(err)
+	session.errLock.Lock()
+	if session.errs != nil && !session.errsClosed {
+		select {
+		case session.errs <- err:
+		default:
+			session.logger.Warn("dropped session error, the errors channel is full", session.logArgs("err", err)...)
+		}
+	}
+	session.errLock.Unlock()
+	session.Listener.dispatch(session.callbacks, ErrorEventHandler, EventTypeError, err)
+}
+
+// closeErrors closes the Errors channel.
+func (session *Session) closeErrors() {
+	session.errLock.Lock()
+	defer session.errLock.Unlock()
+	if session.errs != nil && !session.errsClosed {
+		session.errsClosed = true
+		close(session.errs)
+	}
+}
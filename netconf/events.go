@@ -17,8 +17,13 @@ limitations under the License.
 package netconf
 
 import (
-	"github.com/openshift-telco/go-netconf-client/netconf/message"
+	"log/slog"
+	"runtime/debug"
+	"sync"
 	"time"
+
+	"github.com/openshift-telco/go-netconf-client/netconf/correlate"
+	"github.com/openshift-telco/go-netconf-client/netconf/message"
 )
 
 /**
@@ -29,12 +34,31 @@ order to process their response.
 
 // Names of event types
 var eventTypeStrings = [...]string{
-	"rpc-reply", "notification",
+	"rpc-reply", "notification", "error", "close",
 }
 
 // EventType is an enumeration of the kind of events that can occur.
 type EventType uint16
 
+const (
+	// EventTypeRPCReply is the type of events carrying an RPCReply.
+	EventTypeRPCReply EventType = iota
+	// EventTypeNotification is the type of events carrying a Notification.
+	EventTypeNotification
+	// EventTypeError is the type of events carrying an error raised while receiving messages.
+	EventTypeError
+	// EventTypeClose is the type of the event carrying the *SessionClosedError of a session which stopped
+	// receiving messages.
+	EventTypeClose
+)
+
+const (
+	// ErrorEventHandler identifies the callback registration receiving the error events of a session.
+	ErrorEventHandler = "SESSION_ERROR"
+	// CloseEventHandler identifies the callback registration receiving the close event of a session.
+	CloseEventHandler = "SESSION_CLOSE"
+)
+
 // String returns the name of event types
 func (t EventType) String() string {
 	return eventTypeStrings[t]
@@ -43,38 +67,163 @@ func (t EventType) String() string {
 // Callback is a function that can receive events.
 type Callback func(Event)
 
+// Executor runs the callbacks, allowing applications to integrate them with their own worker pools,
+// panic handlers or tracing wrappers.
+type Executor interface {
+	// Submit runs task, either synchronously or asynchronously.
+	Submit(task func())
+}
+
+// ExecutorFunc is an adapter to allow the use of ordinary functions as Executor.
+type ExecutorFunc func(task func())
+
+// Submit calls f(task).
+func (f ExecutorFunc) Submit(task func()) {
+	f(task)
+}
+
 // Dispatcher objects can register callbacks for specific events, then when
 // those events occur, dispatch them its according callback functions.
 type Dispatcher struct {
-	callbacks map[string]Callback
+	lock      sync.Mutex
+	callbacks *correlate.Correlator[Callback]
+	// running is the number of callbacks being invoked, and idle is signaled whenever there is no more
+	// registration nor running callback
+	running   int
+	idle      *sync.Cond
+	statsLock sync.Mutex
+	stats     map[string]*CallbackStats
+}
+
+// callbackRunner runs the callbacks of the events dispatched by a session, so sessions sharing a dispatcher
+// keep their own executor and logger, and only wait for their own callbacks.
+type callbackRunner struct {
+	// executor runs the callbacks; when nil, they run in the goroutine dispatching the events
+	executor Executor
+	// logger reports the callback panics; when nil, they are only counted
+	logger Logger
+	// logArgs adds the fields identifying the session to the logged ones
+	logArgs func(args ...any) []any
+	lock    sync.Mutex
+	running int
+	idle    *sync.Cond
+}
+
+func newCallbackRunner(executor Executor, logger Logger, logArgs func(args ...any) []any) *callbackRunner {
+	r := &callbackRunner{executor: executor, logger: logger, logArgs: logArgs}
+	r.idle = sync.NewCond(&r.lock)
+	return r
+}
+
+// add records the start, when delta is 1, or the end, when delta is -1, of a callback invocation.
+func (r *callbackRunner) add(delta int) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.running += delta
+	if r.running == 0 {
+		r.idle.Broadcast()
+	}
+}
+
+// wait waits for all the invoked callbacks to return.
+func (r *callbackRunner) wait() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	for r.running != 0 {
+		r.idle.Wait()
+	}
+}
+
+// CallbackStats holds the execution metrics of the callbacks dispatched for a given key.
+type CallbackStats struct {
+	// Calls is the number of times a callback was invoked.
+	Calls uint64
+	// Panics is the number of invocations that panicked.
+	Panics uint64
+	// TotalDuration is the cumulated execution time of all invocations.
+	TotalDuration time.Duration
+	// MaxDuration is the longest execution time observed.
+	MaxDuration time.Duration
+	// LastDuration is the execution time of the latest invocation.
+	LastDuration time.Duration
+}
+
+// NewDispatcher creates a dispatcher with no registered callback.
+func NewDispatcher() *Dispatcher {
+	d := &Dispatcher{}
+	d.init()
+	return d
 }
 
 // init a dispatcher creating the callbacks map.
 func (d *Dispatcher) init() {
-	d.callbacks = make(map[string]Callback)
+	d.callbacks = correlate.New[Callback]()
+	d.stats = make(map[string]*CallbackStats)
+	d.idle = sync.NewCond(&d.lock)
 }
 
 // Register a callback function for the specified eventID.
 func (d *Dispatcher) Register(eventID string, callback Callback) {
-	d.callbacks[eventID] = callback
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.callbacks.Register(eventID, callback, time.Time{})
+}
+
+// registerUntil registers a callback function for the specified eventID, expired by expire once the deadline
+// passed without the event being dispatched.
+func (d *Dispatcher) registerUntil(eventID string, callback Callback, deadline time.Time) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.callbacks.Register(eventID, callback, deadline)
+}
+
+// expire removes the callbacks whose deadline is before now, and invokes each of them with an event carrying
+// the error returned by reason.
+func (d *Dispatcher) expire(runner *callbackRunner, now time.Time, reason func(eventID string, registered time.Time) error) {
+	d.lock.Lock()
+	expired := d.callbacks.Timeouts(now)
+	d.running += len(expired)
+	d.lock.Unlock()
+
+	for _, e := range expired {
+		d.run(runner, statsKey(e.ID, EventTypeRPCReply), e.Handler, &event{eventID: e.ID, value: reason(e.ID, e.Registered)})
+	}
 }
 
 // Remove a callback function for the specified eventID.
 func (d *Dispatcher) Remove(eventID string) {
-	delete(d.callbacks, eventID)
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.callbacks.Remove(eventID)
+	d.signalIdle()
 }
 
-// WaitForMessages waits for all messages in the queue to be processed
+// WaitForMessages waits for all messages in the queue to be processed, that is until there is no more
+// registered callback, and all the invoked ones returned.
 // TODO support timeout
 func (d *Dispatcher) WaitForMessages() {
-	for len(d.callbacks) != 0 {
-		time.Sleep(1 * time.Second)
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	for d.callbacks.Len() != 0 || d.running != 0 {
+		d.idle.Wait()
 	}
 }
 
-// Dispatch an event by triggering its associated callback.
-// FIXME manage errors
+// signalIdle wakes up the waiters once no callback is running, so they check what is left. The lock must be held.
+func (d *Dispatcher) signalIdle() {
+	if d.running == 0 {
+		d.idle.Broadcast()
+	}
+}
+
+// Dispatch an event by triggering its associated callback, in the calling goroutine.
 func (d *Dispatcher) Dispatch(eventID string, eventType EventType, value interface{}) {
+	d.dispatch(nil, eventID, eventType, value)
+}
+
+// dispatch triggers the callback associated to the event using the runner, in the calling goroutine when nil,
+// and tells whether there is one.
+func (d *Dispatcher) dispatch(runner *callbackRunner, eventID string, eventType EventType, value interface{}) bool {
 	// Create the event
 	e := &event{
 		eventID: eventID,
@@ -82,21 +231,133 @@ func (d *Dispatcher) Dispatch(eventID string, eventType EventType, value interfa
 	}
 
 	// Dispatch the event to the callback
-	callback := d.callbacks[eventID]
-	if callback == nil {
-		return
-	}
-	callback(e)
-
 	// In case of rpc-reply, auto-remove registration
 	// If it is a notification, we need to keep the registration active
 	// as we can have still receive notification related to the subscriptionID
+	d.lock.Lock()
+	var callback Callback
 	switch eventType.String() {
 	case "rpc-reply":
-		d.Remove(eventID)
-	case "notification":
-		// NOOP
+		callback, _ = d.callbacks.Match(eventID)
+	case "notification", "error", "close":
+		callback, _ = d.callbacks.Peek(eventID)
 	}
+	if callback == nil {
+		d.lock.Unlock()
+		return false
+	}
+	d.running++
+	d.lock.Unlock()
+
+	d.run(runner, statsKey(eventID, eventType), callback, e)
+	return true
+}
+
+// run invokes the callback, already counted as running, through the runner executor if any.
+func (d *Dispatcher) run(runner *callbackRunner, key string, callback Callback, e Event) {
+	if runner == nil {
+		defer d.done()
+		d.invoke(nil, key, callback, e)
+		return
+	}
+
+	runner.add(1)
+	task := func() {
+		defer runner.add(-1)
+		defer d.done()
+		d.invoke(runner, key, callback, e)
+	}
+	if runner.executor == nil {
+		task()
+		return
+	}
+	runner.executor.Submit(task)
+}
+
+// done records the end of a callback invocation.
+func (d *Dispatcher) done() {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.running--
+	d.signalIdle()
+}
+
+// CorrelationStats returns the counters of the message-id correlation of the dispatched events.
+func (d *Dispatcher) CorrelationStats() correlate.Stats {
+	return d.callbacks.Stats()
+}
+
+// Stats returns a snapshot of the callback execution metrics.
+// Notification callbacks are reported under their registration key, while rpc-reply callbacks,
+// whose key is a unique message-id, are aggregated under the "rpc-reply" key.
+func (d *Dispatcher) Stats() map[string]CallbackStats {
+	d.statsLock.Lock()
+	defer d.statsLock.Unlock()
+
+	stats := make(map[string]CallbackStats, len(d.stats))
+	for key, value := range d.stats {
+		stats[key] = *value
+	}
+	return stats
+}
+
+// invoke executes the callback, recording its duration and any panic it raises.
+// A panic is logged, by the session logger or, for the events dispatched with Dispatch, by the default slog
+// logger, then raised again when the callback runs through an executor, so the executor panic handler sees it;
+// otherwise it is recovered, not to stop the goroutine dispatching the events.
+func (d *Dispatcher) invoke(runner *callbackRunner, key string, callback Callback, e Event) {
+	start := time.Now()
+	defer func() {
+		recovered := recover()
+		d.record(key, time.Since(start), recovered != nil)
+		if recovered == nil {
+			return
+		}
+		args := []any{"key", key, "panic", recovered, "stack", string(debug.Stack())}
+		if runner == nil {
+			slog.Default().Error("callback panicked", args...)
+			return
+		}
+		if runner.logger != nil {
+			if runner.logArgs != nil {
+				args = runner.logArgs(args...)
+			}
+			runner.logger.Error("callback panicked", args...)
+		}
+		if runner.executor != nil {
+			panic(recovered)
+		}
+	}()
+	callback(e)
+}
+
+// record updates the metrics of the provided key.
+func (d *Dispatcher) record(key string, duration time.Duration, panicked bool) {
+	d.statsLock.Lock()
+	defer d.statsLock.Unlock()
+
+	stats, ok := d.stats[key]
+	if !ok {
+		stats = &CallbackStats{}
+		d.stats[key] = stats
+	}
+	stats.Calls++
+	if panicked {
+		stats.Panics++
+	}
+	stats.TotalDuration += duration
+	stats.LastDuration = duration
+	if duration > stats.MaxDuration {
+		stats.MaxDuration = duration
+	}
+}
+
+// statsKey returns the key under which the metrics of an event are recorded.
+func statsKey(eventID string, eventType EventType) string {
+	if eventType.String() == "rpc-reply" {
+		return eventType.String()
+	}
+	return eventID
 }
 
 // Event represents actions that occur during NETCONF exchange. Listeners can
@@ -108,6 +369,22 @@ type Event interface {
 	Notification() *message.Notification
 }
 
+// ErrorEvent is implemented by the events carrying an error, e.g. the *RPCTimeoutError of an AsyncRPC callback
+// or the cause of a close event. It is kept apart from Event not to break its other implementations.
+type ErrorEvent interface {
+	Event
+	Err() error
+}
+
+// EventErr returns the error carried by the event, nil when there is none.
+func EventErr(e Event) error {
+	if ee, ok := e.(ErrorEvent); ok {
+		return ee.Err()
+	}
+	err, _ := e.Value().(error)
+	return err
+}
+
 // event is an internal implementation of the Event interface.
 type event struct {
 	eventID string
@@ -141,3 +418,12 @@ func (e *event) Notification() *message.Notification {
 	}
 	return nil
 }
+
+// Err returns the error from the associated value.
+func (e *event) Err() error {
+	err, ok := e.value.(error)
+	if ok {
+		return err
+	}
+	return nil
+}
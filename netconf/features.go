@@ -0,0 +1,143 @@
+/*
+Copyright 2021. Alexis de Talhouët
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package netconf
+
+import "strings"
+
+const (
+	// CapabilityWritableRunning is the capability of servers allowing to edit the running datastore
+	CapabilityWritableRunning = "urn:ietf:params:netconf:capability:writable-running:1.0"
+	// CapabilityCandidate is the capability of servers supporting the candidate datastore
+	CapabilityCandidate = "urn:ietf:params:netconf:capability:candidate:1.0"
+	// CapabilityConfirmedCommit is the capability of servers supporting confirmed commits
+	CapabilityConfirmedCommit = "urn:ietf:params:netconf:capability:confirmed-commit:1.1"
+	// CapabilityRollbackOnError is the capability of servers supporting the rollback-on-error error-option
+	CapabilityRollbackOnError = "urn:ietf:params:netconf:capability:rollback-on-error:1.0"
+	// CapabilityValidate is the capability of servers supporting the validate operation
+	CapabilityValidate = "urn:ietf:params:netconf:capability:validate:1.1"
+	// CapabilityStartup is the capability of servers supporting the startup datastore
+	CapabilityStartup = "urn:ietf:params:netconf:capability:startup:1.0"
+	// CapabilityURL is the capability of servers accepting URLs as source and target of operations
+	CapabilityURL = "urn:ietf:params:netconf:capability:url:1.0"
+	// CapabilityXPath is the capability of servers supporting XPath filters
+	CapabilityXPath = "urn:ietf:params:netconf:capability:xpath:1.0"
+	// CapabilityNotification is the capability of servers supporting event notifications, see RFC 5277
+	CapabilityNotification = "urn:ietf:params:netconf:capability:notification:1.0"
+	// CapabilityInterleave is the capability of servers accepting RPCs during a notification subscription
+	CapabilityInterleave = "urn:ietf:params:netconf:capability:interleave:1.0"
+	// CapabilityPartialLock is the capability of servers supporting partial locks, see RFC 5717
+	CapabilityPartialLock = "urn:ietf:params:netconf:capability:partial-lock:1.0"
+	// CapabilityWithDefaults is the capability of servers supporting the with-defaults parameter, see RFC 6243
+	CapabilityWithDefaults = "urn:ietf:params:netconf:capability:with-defaults:1.0"
+)
+
+// NegotiatedFeatures tells which optional NETCONF features the server advertised in its hello.
+// Any version of a capability enables the feature, e.g. both validate:1.0 and validate:1.1 set Validate.
+type NegotiatedFeatures struct {
+	Candidate       bool
+	ConfirmedCommit bool
+	Validate        bool
+	Startup         bool
+	URL             bool
+	XPath           bool
+	Notifications   bool
+	Interleave      bool
+	WritableRunning bool
+	RollbackOnError bool
+	PartialLock     bool
+	// URLSchemes lists the schemes accepted when URL is set, e.g. "file" or "https".
+	URLSchemes []string
+	// WithDefaults tells the server supports the with-defaults parameter, reporting the default values
+	// following WithDefaultsBasicMode when the parameter is omitted.
+	WithDefaults          bool
+	WithDefaultsBasicMode string
+	// WithDefaultsModes lists the modes accepted by the with-defaults parameter, including the basic mode.
+	WithDefaultsModes []string
+}
+
+// SupportsWithDefaults tells whether the server accepts the with-defaults mode, e.g. message.WithDefaultsTrim.
+func (features NegotiatedFeatures) SupportsWithDefaults(mode string) bool {
+	for _, supported := range features.WithDefaultsModes {
+		if supported == mode {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseFeatures computes the features enabled by the provided capabilities.
+func ParseFeatures(capabilities []string) NegotiatedFeatures {
+	var features NegotiatedFeatures
+	for _, raw := range capabilities {
+		capability, err := ParseCapability(raw)
+		if err != nil {
+			// A malformed query does not prevent detecting the feature.
+			uri, _, _ := strings.Cut(strings.TrimSpace(raw), "?")
+			capability = Capability{URI: uri}
+		}
+		switch capability.Name() {
+		case capabilityName(CapabilityCandidate):
+			features.Candidate = true
+		case capabilityName(CapabilityConfirmedCommit):
+			features.ConfirmedCommit = true
+		case capabilityName(CapabilityValidate):
+			features.Validate = true
+		case capabilityName(CapabilityStartup):
+			features.Startup = true
+		case capabilityName(CapabilityURL):
+			features.URL = true
+			if schemes := capability.Parameters["scheme"]; schemes != "" {
+				features.URLSchemes = append(features.URLSchemes, strings.Split(schemes, ",")...)
+			}
+		case capabilityName(CapabilityXPath):
+			features.XPath = true
+		case capabilityName(CapabilityNotification):
+			features.Notifications = true
+		case capabilityName(CapabilityInterleave):
+			features.Interleave = true
+		case capabilityName(CapabilityWritableRunning):
+			features.WritableRunning = true
+		case capabilityName(CapabilityRollbackOnError):
+			features.RollbackOnError = true
+		case capabilityName(CapabilityPartialLock):
+			features.PartialLock = true
+		case capabilityName(CapabilityWithDefaults):
+			features.WithDefaults = true
+			if mode := capability.Parameters["basic-mode"]; mode != "" {
+				features.WithDefaultsBasicMode = mode
+				features.WithDefaultsModes = append(features.WithDefaultsModes, mode)
+			}
+			if modes := capability.Parameters["also-supported"]; modes != "" {
+				features.WithDefaultsModes = append(features.WithDefaultsModes, strings.Split(modes, ",")...)
+			}
+		}
+	}
+	return features
+}
+
+// capabilityName returns the capability URI without its version.
+func capabilityName(uri string) string {
+	if i := strings.LastIndex(uri, ":"); i >= 0 {
+		return uri[:i]
+	}
+	return uri
+}
+
+// Features returns the optional features advertised by the server, computed once the server hello is received.
+func (session *Session) Features() NegotiatedFeatures {
+	return session.features
+}
@@ -0,0 +1,256 @@
+/*
+Copyright 2021. Alexis de Talhouët
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package netconf
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+)
+
+// FrameReceiver is implemented by transports able to hand over a received message as a stream, so it can be
+// parsed incrementally, e.g. with an xml.Decoder, without buffering the whole message first.
+// A Session decodes the received messages from it, see message.DecodeRPCReply.
+type FrameReceiver interface {
+	// ReceiveFrame waits for the next message and returns a reader over its content, with the transport framing
+	// removed. The reader returns io.EOF at the end of the message. It is only valid until the next call to
+	// ReceiveFrame, which discards whatever was left unread.
+	// Framing errors, and messages exceeding the maximum size, are reported by the reader.
+	ReceiveFrame() (io.Reader, error)
+}
+
+// ReceiveFrame returns the next message received by the transport as a stream.
+// See FrameReceiver; transports not implementing it have their message fully received first.
+func ReceiveFrame(t Transport) (io.Reader, error) {
+	if receiver, ok := t.(FrameReceiver); ok {
+		return receiver.ReceiveFrame()
+	}
+	b, err := t.Receive()
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(b), nil
+}
+
+// readFrame reads the whole message of the frame.
+func readFrame(frame io.Reader, err error) ([]byte, error) {
+	if err != nil {
+		return nil, err
+	}
+	b, err := io.ReadAll(frame)
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// ReceiveFrame waits for the next message, see FrameReceiver.
+func (t *transportBasicIO) ReceiveFrame() (io.Reader, error) {
+	if t.frame != nil {
+		// Skip what is left of the previous message to reach the next one.
+		_, _ = io.Copy(io.Discard, t.frame)
+		t.frame = nil
+	}
+	if t.broken != nil {
+		return nil, t.broken
+	}
+	if t.autoDetect && !t.detected.Load() && t.framingVersion() != "" {
+		if err := t.detectFraming(); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := t.bufferedReader().Peek(1); err != nil {
+		return nil, err
+	}
+	t.frame = &frameReader{t: t, r: t.bufferedReader(), chunked: t.framingVersion() == "v1.1"}
+	return t.frame, nil
+}
+
+// frameReader streams the content of a single message, decoding the framing as it goes.
+type frameReader struct {
+	t       *transportBasicIO
+	r       *bufio.Reader
+	chunked bool
+	err     error
+	// size is the number of bytes of the message read so far
+	size int
+	// chunked framing: bytes left in the current chunk, and whether a chunk was read
+	remaining uint64
+	started   bool
+	// end-of-message framing: bytes read but not yet returned, and whether the separator was read
+	pending []byte
+	done    bool
+}
+
+func (f *frameReader) Read(p []byte) (int, error) {
+	if f.err != nil {
+		return 0, f.err
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+	var n int
+	var err error
+	if f.chunked {
+		n, err = f.readChunked(p)
+	} else {
+		n, err = f.readEndOfMessage(p)
+	}
+	if err != nil {
+		f.fail(err)
+	}
+	return n, err
+}
+
+// fail ends the frame with the provided error, io.EOF denoting the end of the message.
+func (f *frameReader) fail(err error) {
+	f.err = err
+	if err == io.EOF {
+		f.t.counters.framesReceived.Add(1)
+		return
+	}
+
+	var framingErr *FramingError
+	if errors.As(err, &framingErr) {
+		f.t.counters.framingErrors.Add(1)
+		if f.t.recovery && f.t.skipToEndOfChunks() == nil {
+			framingErr.Recovered = true
+			return
+		}
+		// The stream position is unknown, any further message would be mis-decoded.
+		f.t.broken = framingErr
+	}
+}
+
+// readChunked reads from a message framed with the NETCONF 1.1 chunked framing.
+// https://datatracker.ietf.org/doc/html/rfc6242#section-4.2
+func (f *frameReader) readChunked(p []byte) (int, error) {
+	for f.remaining == 0 {
+		size, err := readChunkHeader(f.r)
+		if err != nil {
+			return 0, unexpectedEOF(err)
+		}
+		if size == 0 {
+			if !f.started {
+				return 0, &FramingError{Err: ErrBadChunk, Reason: "end-of-chunks without any chunk"}
+			}
+			return 0, io.EOF
+		}
+		f.started = true
+		f.remaining = size
+		if limit := f.t.maxSize; limit > 0 && uint64(f.size)+size > uint64(limit) {
+			return 0, f.discardChunks()
+		}
+	}
+
+	if uint64(len(p)) > f.remaining {
+		p = p[:f.remaining]
+	}
+	n, err := f.r.Read(p)
+	f.remaining -= uint64(n)
+	f.size += n
+	return n, unexpectedEOF(err)
+}
+
+// discardChunks consumes the rest of the message, so the stream stays in sync, and returns the
+// MessageTooLargeError to report.
+func (f *frameReader) discardChunks() error {
+	size := uint64(f.size)
+	for {
+		if _, err := io.CopyN(io.Discard, f.r, int64(f.remaining)); err != nil {
+			return unexpectedEOF(err)
+		}
+		size += f.remaining
+		chunk, err := readChunkHeader(f.r)
+		if err != nil {
+			return unexpectedEOF(err)
+		}
+		if chunk == 0 {
+			return &MessageTooLargeError{Limit: f.t.maxSize, Size: int(size)}
+		}
+		f.remaining = chunk
+	}
+}
+
+// readEndOfMessage reads from a message framed with the NETCONF 1.0 end-of-message separator.
+func (f *frameReader) readEndOfMessage(p []byte) (int, error) {
+	for {
+		// Until the separator is found, the tail of the pending bytes may be its beginning.
+		ready := len(f.pending)
+		if !f.done {
+			ready -= len(msgSeparator) - 1
+		}
+		if ready > 0 {
+			if limit := f.t.maxSize; limit > 0 && f.size+ready > limit {
+				return 0, f.discardEndOfMessage()
+			}
+			n := copy(p, f.pending[:ready])
+			f.pending = append(f.pending[:0], f.pending[n:]...)
+			f.size += n
+			return n, nil
+		}
+		if f.done {
+			return 0, io.EOF
+		}
+
+		b, err := f.r.ReadSlice('>')
+		f.pending = append(f.pending, b...)
+		if bytes.HasSuffix(f.pending, []byte(msgSeparator)) {
+			f.pending = f.pending[:len(f.pending)-len(msgSeparator)]
+			f.done = true
+			continue
+		}
+		if err != nil && err != bufio.ErrBufferFull {
+			return 0, unexpectedEOF(err)
+		}
+	}
+}
+
+// discardEndOfMessage consumes the rest of the message, so the stream stays in sync, and returns the
+// MessageTooLargeError to report.
+func (f *frameReader) discardEndOfMessage() error {
+	size := f.size
+	for !f.done {
+		// Only keep what is needed to detect the separator.
+		if trim := len(f.pending) - len(msgSeparator); trim > 0 {
+			size += trim
+			f.pending = append(f.pending[:0], f.pending[trim:]...)
+		}
+		b, err := f.r.ReadSlice('>')
+		f.pending = append(f.pending, b...)
+		if bytes.HasSuffix(f.pending, []byte(msgSeparator)) {
+			f.pending = f.pending[:len(f.pending)-len(msgSeparator)]
+			f.done = true
+			break
+		}
+		if err != nil && err != bufio.ErrBufferFull {
+			return unexpectedEOF(err)
+		}
+	}
+	size += len(f.pending)
+	f.pending = nil
+	return &MessageTooLargeError{Limit: f.t.maxSize, Size: size}
+}
+
+// unexpectedEOF reports the end of the stream in the middle of a message as io.ErrUnexpectedEOF.
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2021. Alexis de Talhouët
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package netconf
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/openshift-telco/go-netconf-client/netconf/message"
+)
+
+// baseCapabilityPrefix prefixes the base protocol capabilities, e.g. message.NetconfVersion11.
+const baseCapabilityPrefix = "urn:ietf:params:netconf:base:"
+
+// urlSchemeRegex matches the URL schemes as defined by RFC 3986.
+var urlSchemeRegex = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9+.-]*$`)
+
+// ClientCapabilities declares the capabilities of the client hello, so callers do not have to assemble the
+// capability URIs, see NewClientHello.
+type ClientCapabilities struct {
+	// BaseVersions are the base protocol capabilities, message.NetconfVersion10 and/or message.NetconfVersion11;
+	// both when empty.
+	BaseVersions []string
+	// Startup advertises the :startup capability.
+	Startup bool
+	// URLSchemes advertises the :url capability with the provided schemes, e.g. "file" or "https", when not empty.
+	URLSchemes []string
+	// Vendor lists any other capability URI, e.g. a vendor extension.
+	Vendor []string
+}
+
+// Capabilities returns the capability URIs, failing when the declaration is invalid: an unknown base version,
+// an invalid URL scheme, a vendor capability which is not an absolute URI or duplicates another capability.
+func (c ClientCapabilities) Capabilities() ([]string, error) {
+	versions := c.BaseVersions
+	if len(versions) == 0 {
+		versions = DefaultCapabilities
+	}
+
+	var capabilities []string
+	seen := map[string]bool{}
+	// add appends the capability, the duplicates being detected on its URI, without parameters
+	add := func(capability string) error {
+		uri, _, _ := strings.Cut(capability, "?")
+		if seen[uri] {
+			return fmt.Errorf("duplicated capability %s", uri)
+		}
+		seen[uri] = true
+		capabilities = append(capabilities, capability)
+		return nil
+	}
+
+	for _, version := range versions {
+		if version != message.NetconfVersion10 && version != message.NetconfVersion11 {
+			return nil, fmt.Errorf("unsupported base version %q, expecting %s or %s", version,
+				message.NetconfVersion10, message.NetconfVersion11)
+		}
+		if err := add(version); err != nil {
+			return nil, err
+		}
+	}
+	if c.Startup {
+		_ = add(CapabilityStartup)
+	}
+	if len(c.URLSchemes) > 0 {
+		for _, scheme := range c.URLSchemes {
+			if !urlSchemeRegex.MatchString(scheme) {
+				return nil, fmt.Errorf("invalid url scheme %q", scheme)
+			}
+		}
+		_ = add(CapabilityURL + "?scheme=" + strings.Join(c.URLSchemes, ","))
+	}
+	for _, capability := range c.Vendor {
+		uri, _, _ := strings.Cut(capability, "?")
+		if parsed, err := url.Parse(uri); err != nil || parsed.Scheme == "" {
+			return nil, fmt.Errorf("capability %q is not an absolute URI", capability)
+		}
+		if strings.HasPrefix(uri, baseCapabilityPrefix) {
+			return nil, fmt.Errorf("base capability %s must be set as a base version", capability)
+		}
+		if err := add(capability); err != nil {
+			return nil, err
+		}
+	}
+	return capabilities, nil
+}
+
+// NewClientHello creates the client hello advertising the declared capabilities, to be sent with SendHello.
+func NewClientHello(capabilities ClientCapabilities) (*message.Hello, error) {
+	uris, err := capabilities.Capabilities()
+	if err != nil {
+		return nil, fmt.Errorf("invalid client capabilities: %w", err)
+	}
+	return &message.Hello{Capabilities: uris}, nil
+}
@@ -0,0 +1,132 @@
+/*
+Copyright 2021. Alexis de Talhouët
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package netconf
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// IdleHook is called once the session was idle, i.e. nothing was sent nor received, for the idle period,
+// with the time elapsed since the latest message.
+type IdleHook func(session *Session, idle time.Duration)
+
+// WithIdlePeriod sets the idle period after which the hooks registered with OnIdle are called, e.g. to send a
+// keepalive or tear down unused sessions. The hooks are called once per idle stretch, the next message sent or
+// received starting a new one. It is disabled by default.
+func WithIdlePeriod(period time.Duration) SessionOption {
+	return func(s *Session) {
+		s.idlePeriod = period
+	}
+}
+
+// WithIdleHook registers a hook called once the session is idle, see OnIdle.
+func WithIdleHook(hook IdleHook) SessionOption {
+	return func(s *Session) {
+		s.userIdleHooks = append(s.userIdleHooks, hook)
+	}
+}
+
+// OnIdle registers a hook called once the session was idle for the period set by WithIdlePeriod.
+// Hooks are kept upon Reconnect.
+func (session *Session) OnIdle(hook IdleHook) {
+	session.stateLock.Lock()
+	defer session.stateLock.Unlock()
+	session.userIdleHooks = append(session.userIdleHooks, hook)
+}
+
+// LastSent returns the time of the latest message sent, zero if none was.
+func (session *Session) LastSent() time.Time {
+	return session.activity.at(&session.activity.sent)
+}
+
+// LastReceived returns the time of the latest message received, zero if none was.
+func (session *Session) LastReceived() time.Time {
+	return session.activity.at(&session.activity.received)
+}
+
+// IdleSince returns the time of the latest message sent or received, zero if none was.
+func (session *Session) IdleSince() time.Time {
+	sent, received := session.LastSent(), session.LastReceived()
+	if sent.After(received) {
+		return sent
+	}
+	return received
+}
+
+// activity records the time of the latest messages of a session, as Unix nanoseconds.
+type activity struct {
+	sent     atomic.Int64
+	received atomic.Int64
+}
+
+// record sets the last activity to now.
+func (a *activity) record(last *atomic.Int64) {
+	last.Store(time.Now().UnixNano())
+}
+
+// at returns the time of the last activity, zero if none.
+func (a *activity) at(last *atomic.Int64) time.Time {
+	if nanos := last.Load(); nanos != 0 {
+		return time.Unix(0, nanos)
+	}
+	return time.Time{}
+}
+
+// watchIdle starts the goroutine calling the idle hooks, when enabled, stopping once the session stops
+// receiving messages.
+func (session *Session) watchIdle() {
+	period := session.idlePeriod
+	if period <= 0 {
+		return
+	}
+
+	stop := make(chan struct{})
+	session.onClose(func() { close(stop) })
+
+	go func() {
+		timer := time.NewTimer(period)
+		defer timer.Stop()
+		// notified is the start of the idle stretch the hooks were called for
+		var notified time.Time
+		for {
+			select {
+			case <-stop:
+				return
+			case <-timer.C:
+				since := session.IdleSince()
+				idle := time.Since(since)
+				if idle < period {
+					timer.Reset(period - idle)
+					continue
+				}
+				timer.Reset(period)
+				if since.Equal(notified) {
+					continue
+				}
+				notified = since
+
+				session.stateLock.Lock()
+				hooks := append([]IdleHook(nil), session.userIdleHooks...)
+				session.stateLock.Unlock()
+				for _, hook := range hooks {
+					hook(session, idle)
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2021. Alexis de Talhouët
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package netconf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrTooManyInFlight is returned by the RPCs exceeding the maximum number of outstanding RPCs of a session
+// using InFlightFail, see WithMaxInFlight.
+var ErrTooManyInFlight = errors.New("too many outstanding netconf RPCs")
+
+// InFlightPolicy tells what an RPC does when the maximum number of outstanding RPCs is reached.
+type InFlightPolicy int
+
+const (
+	// InFlightWait makes the RPC wait for an outstanding one to complete, within its timeout.
+	InFlightWait InFlightPolicy = iota
+	// InFlightFail makes the RPC fail right away with ErrTooManyInFlight.
+	InFlightFail
+)
+
+// WithMaxInFlight limits the number of RPCs sent and not replied yet, as many devices misbehave when flooded.
+// An RPC exceeding the limit either waits or fails, following the policy. An RPC is outstanding until its reply
+// is received, it times out, or the session is closed.
+func WithMaxInFlight(max int, policy InFlightPolicy) SessionOption {
+	if max < 1 {
+		panic(fmt.Sprintf("provided max in-flight RPCs %d is not supported, expecting a positive number", max))
+	}
+	return func(s *Session) {
+		s.inFlight = make(chan struct{}, max)
+		s.inFlightPolicy = policy
+	}
+}
+
+// InFlight returns the number of outstanding RPCs counted by the limit set using WithMaxInFlight, zero
+// when there is none.
+func (session *Session) InFlight() int {
+	return len(session.inFlightSlots())
+}
+
+// inFlightSlots returns the semaphore of the outstanding RPCs, nil when unlimited.
+func (session *Session) inFlightSlots() chan struct{} {
+	session.closeLock.Lock()
+	defer session.closeLock.Unlock()
+	return session.inFlight
+}
+
+// acquireInFlight reserves an outstanding RPC slot, following the session policy, and returns the function
+// releasing it. Waiting for a slot ends when the context is done or the session stops receiving messages.
+func (session *Session) acquireInFlight(ctx context.Context) (func(), error) {
+	slots := session.inFlightSlots()
+	if slots == nil {
+		return func() {}, nil
+	}
+	release := func() { <-slots }
+
+	select {
+	case slots <- struct{}{}:
+		return release, nil
+	default:
+	}
+	if session.inFlightPolicy == InFlightFail {
+		return nil, ErrTooManyInFlight
+	}
+	select {
+	case slots <- struct{}{}:
+		return release, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-session.stoppedChan():
+		return nil, session.closedError()
+	}
+}
+
+// resetInFlight drops the slots of the RPCs outstanding when the session was closed, as they may never
+// be released.
+func (session *Session) resetInFlight() {
+	if session.inFlight != nil {
+		session.inFlight = make(chan struct{}, cap(session.inFlight))
+	}
+}
@@ -0,0 +1,122 @@
+/*
+Copyright 2021. Alexis de Talhouët
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package netconf
+
+import (
+	"context"
+	"errors"
+	"regexp"
+
+	"github.com/openshift-telco/go-netconf-client/netconf/message"
+)
+
+// ErrNoInterleave is returned by the RPCs sent while a create-subscription is active on a session whose server
+// does not advertise the interleave capability, when using InterleaveReject.
+var ErrNoInterleave = errors.New("netconf server does not support RPCs during a notification subscription")
+
+// InterleavePolicy tells what the RPCs do while a create-subscription is active on a session whose server does not
+// advertise the interleave capability, in which case RFC 5277 only requires the server to process close-session.
+type InterleavePolicy int
+
+const (
+	// InterleaveAllow sends the RPCs anyway, leaving the server to handle them. It is the default.
+	InterleaveAllow InterleavePolicy = iota
+	// InterleaveReject fails the RPCs right away with ErrNoInterleave.
+	InterleaveReject
+	// InterleaveQueue makes the RPCs wait, within their timeout, for the subscription to complete, i.e. for its
+	// stop time to be reached, the server then sending a notificationComplete notification.
+	InterleaveQueue
+)
+
+// WithInterleavePolicy sets what the RPCs do during a create-subscription when the server does not advertise the
+// interleave capability, see InterleavePolicy. close-session is always sent.
+func WithInterleavePolicy(policy InterleavePolicy) SessionOption {
+	return func(s *Session) {
+		s.interleavePolicy = policy
+	}
+}
+
+// SupportsInterleave tells whether the server advertised the interleave capability, accepting RPCs during a
+// notification subscription, see RFC 5277.
+func (session *Session) SupportsInterleave() bool {
+	return session.features.Interleave
+}
+
+// awaitInterleave checks the named operation can be sent, following the interleave policy, waiting for the active
+// subscription to complete if needed.
+func (session *Session) awaitInterleave(ctx context.Context, name string) error {
+	if name == "close-session" || !session.interleaveBlocked() {
+		return nil
+	}
+	completed := session.activeSubscription()
+	if completed == nil {
+		return nil
+	}
+	if session.interleavePolicy == InterleaveReject {
+		return ErrNoInterleave
+	}
+	select {
+	case <-completed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-session.stoppedChan():
+		return session.closedError()
+	}
+}
+
+// interleaveBlocked tells whether the RPCs are held back by the interleave policy.
+func (session *Session) interleaveBlocked() bool {
+	if session.interleavePolicy == InterleaveAllow || session.features.Interleave {
+		return false
+	}
+	return session.activeSubscription() != nil
+}
+
+// activeSubscription returns the channel closed once the active create-subscription completes, nil if none.
+func (session *Session) activeSubscription() <-chan struct{} {
+	session.closeLock.Lock()
+	defer session.closeLock.Unlock()
+	return session.subscription
+}
+
+// subscribed records a create-subscription replied successfully.
+func (session *Session) subscribed() {
+	session.closeLock.Lock()
+	defer session.closeLock.Unlock()
+	if session.subscription == nil {
+		session.subscription = make(chan struct{})
+	}
+}
+
+// subscriptionCompleted ends the active create-subscription, if any.
+func (session *Session) subscriptionCompleted() {
+	session.closeLock.Lock()
+	defer session.closeLock.Unlock()
+	if session.subscription != nil {
+		close(session.subscription)
+		session.subscription = nil
+	}
+}
+
+// notificationCompleteRegex matches the notificationComplete element, whatever its namespace prefix.
+var notificationCompleteRegex = regexp.MustCompile(`<(\w+:)?notificationComplete\b`)
+
+// isNotificationComplete tells whether the notification ends a create-subscription, see RFC 5277 section 2.2.1.
+func isNotificationComplete(notification *message.Notification) bool {
+	return notificationCompleteRegex.MatchString(notification.Data)
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2021. Alexis de Talhouët
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package netconf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// KeepaliveError is dispatched to the ErrorEventHandler when the session is closed after missing keepalive
+// replies.
+type KeepaliveError struct {
+	// Missed is the number of consecutive keepalive RPCs left without a successful reply.
+	Missed int
+	// Err is the failure of the last keepalive RPC.
+	Err error
+}
+
+// Error generates a string representation of the keepalive error
+func (e *KeepaliveError) Error() string {
+	return fmt.Sprintf("session failed after %d missed keepalive replies: %v", e.Missed, e.Err)
+}
+
+// Unwrap returns the failure of the last keepalive RPC
+func (e *KeepaliveError) Unwrap() error {
+	return e.Err
+}
+
+// WithKeepalive sends a keepalive RPC, a `get` with an empty subtree filter, whenever the session was idle,
+// i.e. nothing was sent nor received, for the interval. Each keepalive waits up to the interval for its reply.
+// After maxMissed consecutive keepalives without a successful reply, the session is closed and a KeepaliveError
+// is dispatched to the ErrorEventHandler, so long-running subscribers notice silent device failures.
+func WithKeepalive(interval time.Duration, maxMissed int) SessionOption {
+	return func(s *Session) {
+		s.keepaliveInterval = interval
+		s.keepaliveMaxMissed = maxMissed
+	}
+}
+
+// keepalive starts the keepalive goroutine, when enabled, stopping once the session stops receiving messages.
+func (session *Session) keepalive() {
+	if session.keepaliveInterval <= 0 {
+		return
+	}
+	maxMissed := session.keepaliveMaxMissed
+	if maxMissed < 1 {
+		maxMissed = 1
+	}
+
+	stop := make(chan struct{})
+	session.onClose(func() { close(stop) })
+
+	go func() {
+		ticker := time.NewTicker(session.keepaliveInterval)
+		defer ticker.Stop()
+		missed := 0
+		for {
+			select {
+			case <-stop:
+				return
+			case now := <-ticker.C:
+				if session.interleaveBlocked() {
+					// the server only processes close-session during the subscription
+					continue
+				}
+				last := session.TransportStats().LastActivity
+				if missed == 0 && !last.IsZero() && now.Sub(last) < session.keepaliveInterval {
+					continue
+				}
+
+				ctx, cancel := context.WithTimeout(context.Background(), session.keepaliveInterval)
+				_, err := session.rpcPing(ctx)
+				cancel()
+				if err == nil {
+					missed = 0
+					continue
+				}
+				if errors.Is(err, ErrTooManyInFlight) {
+					// a session busy with outstanding RPCs relies on their own timeouts
+					continue
+				}
+
+				missed++
+				session.logger.Warn("missed keepalive reply", session.logArgs(
+					"missed", missed,
+					"err", err,
+				)...)
+				if missed >= maxMissed {
+					keepaliveErr := &KeepaliveError{Missed: missed, Err: err}
+					session.logger.Error("closing session on missed keepalive replies", session.logArgs(
+						"err", keepaliveErr,
+					)...)
+					session.reportError(keepaliveErr)
+					session.fail(keepaliveErr)
+					return
+				}
+			}
+		}
+	}()
+}
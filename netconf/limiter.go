@@ -0,0 +1,132 @@
+/*
+Copyright 2021. Alexis de Talhouët
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package netconf
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// AdaptiveLimiter bounds the number of concurrent operations, e.g. device polls across a fleet, adjusting the
+// bound to the observed outcomes following an AIMD (additive increase, multiplicative decrease) scheme:
+// each success grows the limit so it increases by about one per limit-worth of successes, and a failure
+// or timeout halves it. This keeps the concurrency high while devices are healthy, and backs off quickly
+// when many of them slow down at once.
+// The limit is halved at most once per window: the failures of the operations already in flight when it was
+// last halved are ignored, as they were started under the previous limit and report the same slowdown.
+type AdaptiveLimiter struct {
+	lock     sync.Mutex
+	limit    float64
+	lower    int
+	upper    int
+	inFlight int
+	// window is the number of operations in flight when the limit was last halved, yet to be released
+	window  int
+	changed chan struct{}
+}
+
+// NewAdaptiveLimiter creates a limiter whose limit starts at upper and varies between lower and upper.
+func NewAdaptiveLimiter(lower int, upper int) *AdaptiveLimiter {
+	if lower < 1 {
+		lower = 1
+	}
+	if upper < lower {
+		upper = lower
+	}
+	return &AdaptiveLimiter{limit: float64(upper), lower: lower, upper: upper, changed: make(chan struct{})}
+}
+
+// Acquire blocks until an operation can start, or the context is done.
+// Every successful Acquire must be followed by a Release.
+func (l *AdaptiveLimiter) Acquire(ctx context.Context) error {
+	for {
+		l.lock.Lock()
+		if l.inFlight < int(l.limit) {
+			l.inFlight++
+			l.lock.Unlock()
+			return nil
+		}
+		changed := l.changed
+		l.lock.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-changed:
+		}
+	}
+}
+
+// Release ends an operation, adjusting the limit to its outcome: a nil error is a success, any other error
+// a failure, except context.Canceled which does not tell anything about the device and leaves the limit as is.
+func (l *AdaptiveLimiter) Release(outcome error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.inFlight--
+	// the window tracks a count rather than the operations themselves, which are not identified
+	inWindow := l.window > 0
+	if inWindow {
+		l.window--
+	}
+	switch {
+	case outcome == nil:
+		l.limit += 1 / l.limit
+		if l.limit > float64(l.upper) {
+			l.limit = float64(l.upper)
+		}
+	case errors.Is(outcome, context.Canceled):
+		// NOOP
+	case inWindow:
+		// NOOP, the limit was already halved for this window
+	default:
+		l.limit /= 2
+		if l.limit < float64(l.lower) {
+			l.limit = float64(l.lower)
+		}
+		l.window = l.inFlight
+	}
+
+	// wake up the waiters so they check the new limit
+	close(l.changed)
+	l.changed = make(chan struct{})
+}
+
+// Do runs the operation once it can start, and releases it with the returned error.
+func (l *AdaptiveLimiter) Do(ctx context.Context, operation func() error) error {
+	if err := l.Acquire(ctx); err != nil {
+		return err
+	}
+	err := operation()
+	l.Release(err)
+	return err
+}
+
+// Limit returns the current concurrency limit.
+func (l *AdaptiveLimiter) Limit() int {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return int(l.limit)
+}
+
+// InFlight returns the number of operations currently running.
+func (l *AdaptiveLimiter) InFlight() int {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return l.inFlight
+}
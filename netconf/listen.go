@@ -0,0 +1,102 @@
+/*
+Copyright 2021. Alexis de Talhouët
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package netconf
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrListening is returned by Start when the session is already receiving messages.
+var ErrListening = errors.New("netconf session already listening")
+
+// ErrHelloNotExchanged is returned by Start before SendHello completed the hello exchange.
+var ErrHelloNotExchanged = errors.New("netconf hello not exchanged")
+
+// WithManualListen makes SendHello, and Reconnect, leave the receive goroutine to be started with Start, so
+// embedding applications control when it runs. Until started no reply is received, so RPCs time out.
+func WithManualListen() SessionOption {
+	return func(s *Session) {
+		s.manualListen = true
+	}
+}
+
+// Start starts receiving the messages of the session, for sessions created with WithManualListen, from the
+// receive goroutine, along with the keepalive and RPC expiry goroutines. The session is closed once the context
+// is done, stopping them, see Stop. A session reconnected with Reconnect is started again with the same context.
+// Start must not be called concurrently with itself.
+func (session *Session) Start(ctx context.Context) error {
+	if session.FramingVersion() == "" {
+		return ErrHelloNotExchanged
+	}
+	if session.Closed() {
+		return session.closedError()
+	}
+	if session.listening.Load() {
+		return ErrListening
+	}
+	session.listenCtx = ctx
+	session.started = true
+	session.start()
+	return nil
+}
+
+// Stop closes the session, and waits for the receive goroutine to return, once the pending RPCs failed and the
+// invoked callbacks and close hooks returned, or for the context to be done, in which case ctx.Err() is returned.
+func (session *Session) Stop(ctx context.Context) error {
+	_ = session.Close()
+	if !session.listening.Load() {
+		return nil
+	}
+	select {
+	case <-session.Done():
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Done returns a channel closed once the receive goroutine returned. It is never closed when the session
+// is not started. Reconnect starts a new receive goroutine, with a new channel.
+func (session *Session) Done() <-chan struct{} {
+	session.closeLock.Lock()
+	defer session.closeLock.Unlock()
+	return session.listenDone
+}
+
+// start runs the receive, keepalive and RPC expiry goroutines, closing the session once the listen context,
+// if any, is done.
+func (session *Session) start() {
+	session.listen()
+	session.keepalive()
+	session.expireRPCs()
+	session.watchIdle()
+
+	ctx := session.listenCtx
+	if ctx == nil || ctx.Done() == nil {
+		return
+	}
+	done := session.Done()
+	go func() {
+		select {
+		case <-ctx.Done():
+			session.logger.Info("closing session as its listen context is done", session.logArgs("err", ctx.Err())...)
+			_ = session.Close()
+		case <-done:
+		}
+	}()
+}
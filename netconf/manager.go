@@ -0,0 +1,366 @@
+/*
+Copyright 2021. Alexis de Talhouët
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package netconf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+var (
+	// ErrUnknownDevice is returned by the SessionManager for a device which was not added.
+	ErrUnknownDevice = errors.New("unknown netconf device")
+	// ErrDeviceExists is returned by SessionManager.Add for a device name already in use.
+	ErrDeviceExists = errors.New("netconf device already added")
+	// ErrManagerClosed is returned by a SessionManager once closed.
+	ErrManagerClosed = errors.New("netconf session manager closed")
+)
+
+// Device is a NETCONF server handled by a SessionManager.
+type Device struct {
+	// Name identifies the device in the manager.
+	Name string
+	// Address is the host:port the device is reached at.
+	Address string
+	// SecondaryAddress is the host:port of a backup management path of the device, used when the session
+	// cannot be established, or re-established, on the other address. Empty when there is none.
+	SecondaryAddress string
+	// Options are the session options specific to the device, applied after the manager ones.
+	Options []SessionOption
+}
+
+// ConnectFunc establishes a session to the device, completing the hello exchange.
+type ConnectFunc func(ctx context.Context, device Device) (*Session, error)
+
+// CredentialsFunc returns the SSH client configuration, i.e. the user and authentication methods, used to
+// connect to the device.
+type CredentialsFunc func(device Device) (*ssh.ClientConfig, error)
+
+// SSHConnector returns a ConnectFunc connecting to the device address over SSH, with the configuration returned
+// by credentials for the device. The dial timeout of the configuration is shortened to the context deadline.
+func SSHConnector(credentials CredentialsFunc, options ...SessionOption) ConnectFunc {
+	return func(ctx context.Context, device Device) (*Session, error) {
+		config, err := credentials(device)
+		if err != nil {
+			return nil, fmt.Errorf("fail to get the credentials of device %s: %w", device.Name, err)
+		}
+		if deadline, ok := ctx.Deadline(); ok && (config.Timeout == 0 || time.Until(deadline) < config.Timeout) {
+			c := *config
+			c.Timeout = time.Until(deadline)
+			config = &c
+		}
+		return Connect(device.Address, config, append(append([]SessionOption(nil), options...), device.Options...)...)
+	}
+}
+
+// FailoverEvent reports that the session of a device was established on another address than the previous one.
+type FailoverEvent struct {
+	// Device is the name of the device.
+	Device string
+	// From is the address the session could not be established on.
+	From string
+	// To is the address the session is now established on.
+	To string
+	// Err is why the session could not be established on the From address.
+	Err error
+}
+
+// ManagerOption allow optional configuration for the session manager.
+type ManagerOption func(*SessionManager)
+
+// WithManagerLimiter bounds the number of devices RunAll operates on concurrently.
+func WithManagerLimiter(limiter *AdaptiveLimiter) ManagerOption {
+	return func(m *SessionManager) {
+		m.limiter = limiter
+	}
+}
+
+// WithManagerReinit sets the function restoring the state of a session reconnected by the manager, e.g. its
+// notification subscriptions, see Session.Reconnect.
+func WithManagerReinit(reinit func(name string, session *Session) error) ManagerOption {
+	return func(m *SessionManager) {
+		m.reinit = reinit
+	}
+}
+
+// WithManagerFailover sets the function called whenever the session of a device fails over between its address
+// and its secondary one, see Device.SecondaryAddress. It is called while the session is being established, and
+// must not block.
+func WithManagerFailover(failover func(event FailoverEvent)) ManagerOption {
+	return func(m *SessionManager) {
+		m.failover = failover
+	}
+}
+
+// SessionManager holds the sessions of many devices, keyed by name. Sessions are established on first use and
+// established again, once closed, on the next one: reconnected when the session supports it, see
+// Session.Reconnect, so the registered callbacks are kept, or connected anew otherwise. When a device has a
+// secondary address, a session which cannot be established on an address is established on the other one.
+// A SessionManager is safe for concurrent use.
+type SessionManager struct {
+	connect  ConnectFunc
+	limiter  *AdaptiveLimiter
+	reinit   func(name string, session *Session) error
+	failover func(event FailoverEvent)
+	lock     sync.Mutex
+	devices  map[string]*managedDevice
+	closed   bool
+}
+
+// managedDevice is a device of the manager, with its session once established.
+type managedDevice struct {
+	device  Device
+	session *Session
+	// address is the address the session was last established on
+	address string
+	// sem serializes establishing the session, honoring the context of the waiters
+	sem     chan struct{}
+	removed bool
+}
+
+// NewSessionManager creates a manager establishing the sessions using connect, see SSHConnector.
+func NewSessionManager(connect ConnectFunc, options ...ManagerOption) *SessionManager {
+	m := &SessionManager{connect: connect, devices: make(map[string]*managedDevice)}
+	for _, opt := range options {
+		opt(m)
+	}
+	return m
+}
+
+// Add registers a device, without connecting to it.
+func (m *SessionManager) Add(device Device) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.closed {
+		return ErrManagerClosed
+	}
+	if _, ok := m.devices[device.Name]; ok {
+		return fmt.Errorf("%w: %s", ErrDeviceExists, device.Name)
+	}
+	m.devices[device.Name] = &managedDevice{device: device, address: device.Address, sem: make(chan struct{}, 1)}
+	return nil
+}
+
+// Remove unregisters a device, closing its session if any.
+func (m *SessionManager) Remove(name string) error {
+	m.lock.Lock()
+	d, ok := m.devices[name]
+	delete(m.devices, name)
+	m.lock.Unlock()
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownDevice, name)
+	}
+	return d.close()
+}
+
+// Devices returns the names of the registered devices, sorted.
+func (m *SessionManager) Devices() []string {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	names := make([]string, 0, len(m.devices))
+	for name := range m.devices {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Session returns the session of the device, establishing it when there is none or it is closed.
+// Concurrent calls for a device share the same attempt.
+func (m *SessionManager) Session(ctx context.Context, name string) (*Session, error) {
+	m.lock.Lock()
+	d, ok := m.devices[name]
+	closed := m.closed
+	m.lock.Unlock()
+	if closed {
+		return nil, ErrManagerClosed
+	}
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownDevice, name)
+	}
+
+	select {
+	case d.sem <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	defer func() { <-d.sem }()
+
+	if d.removed {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownDevice, name)
+	}
+	if d.session != nil && !d.session.Closed() {
+		return d.session, nil
+	}
+
+	// the address which failed to reconnect is only tried again when there is no other one
+	var failed bool
+	var lastErr error
+	var reinit func(*Session) error
+	if m.reinit != nil {
+		reinit = func(s *Session) error { return m.reinit(name, s) }
+	}
+	if d.session != nil {
+		err := d.session.ReconnectContext(ctx, reinit)
+		if err == nil {
+			return d.session, nil
+		}
+		if !errors.Is(err, ErrReconnectNotSupported) {
+			if d.device.SecondaryAddress == "" || ctx.Err() != nil {
+				return nil, fmt.Errorf("fail to reconnect device %s: %w", name, err)
+			}
+			failed, lastErr = true, fmt.Errorf("fail to reconnect device %s: %w", name, err)
+		}
+	}
+
+	for _, address := range d.addresses() {
+		if failed && address == d.address {
+			continue
+		}
+		device := d.device
+		device.Address = address
+		session, err := m.connect(ctx, device)
+		if err != nil {
+			lastErr = fmt.Errorf("fail to connect device %s at %s: %w", name, address, err)
+			continue
+		}
+		if address != d.address {
+			if m.failover != nil {
+				m.failover(FailoverEvent{Device: name, From: d.address, To: address, Err: lastErr})
+			}
+			d.address = address
+		}
+		// the server knows nothing of the previous session, whose state is restored as on a reconnection
+		if d.session != nil && reinit != nil {
+			if err := reinit(session); err != nil {
+				_ = session.Close()
+				return nil, fmt.Errorf("fail to reinitialize session of device %s: %w", name, err)
+			}
+		}
+		d.session = session
+		return session, nil
+	}
+	return nil, lastErr
+}
+
+// CheckStandby checks the secondary address of the device is reachable, by opening and closing a TCP
+// connection to it, so a broken backup path is found before a failover needs it.
+func (m *SessionManager) CheckStandby(ctx context.Context, name string) error {
+	m.lock.Lock()
+	d, ok := m.devices[name]
+	m.lock.Unlock()
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownDevice, name)
+	}
+	if d.device.SecondaryAddress == "" {
+		return fmt.Errorf("device %s has no secondary address", name)
+	}
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", d.device.SecondaryAddress)
+	if err != nil {
+		return fmt.Errorf("secondary address of device %s is unreachable: %w", name, err)
+	}
+	return conn.Close()
+}
+
+// addresses returns the addresses to establish the session on, in order: the one the session was last
+// established on first, so a session stays on its current path when it is still available.
+func (d *managedDevice) addresses() []string {
+	if d.device.SecondaryAddress == "" {
+		return []string{d.device.Address}
+	}
+	if d.address == d.device.SecondaryAddress {
+		return []string{d.device.SecondaryAddress, d.device.Address}
+	}
+	return []string{d.device.Address, d.device.SecondaryAddress}
+}
+
+// Run executes the operation on the session of the device, establishing it if needed. The operation is not
+// retried when the session is closed while it runs, as it may have been applied.
+func (m *SessionManager) Run(ctx context.Context, name string, operation func(*Session) error) error {
+	session, err := m.Session(ctx, name)
+	if err != nil {
+		return err
+	}
+	return operation(session)
+}
+
+// RunAll executes the operation on every registered device concurrently, bounded by the limiter if set using
+// WithManagerLimiter, and returns the errors by device name. Devices whose operation succeeded are not in the
+// returned map.
+func (m *SessionManager) RunAll(ctx context.Context, operation func(name string, session *Session) error) map[string]error {
+	var wg sync.WaitGroup
+	var lock sync.Mutex
+	errs := make(map[string]error)
+	for _, name := range m.Devices() {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			run := func() error {
+				return m.Run(ctx, name, func(s *Session) error { return operation(name, s) })
+			}
+			var err error
+			if m.limiter != nil {
+				err = m.limiter.Do(ctx, run)
+			} else {
+				err = run()
+			}
+			if err != nil {
+				lock.Lock()
+				errs[name] = err
+				lock.Unlock()
+			}
+		}(name)
+	}
+	wg.Wait()
+	return errs
+}
+
+// Close closes the sessions of all the devices. The manager can no longer be used.
+func (m *SessionManager) Close() error {
+	m.lock.Lock()
+	m.closed = true
+	devices := m.devices
+	m.devices = make(map[string]*managedDevice)
+	m.lock.Unlock()
+
+	var errs []error
+	for _, d := range devices {
+		if err := d.close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// close closes the session of the device, waiting for an attempt to establish it to complete.
+func (d *managedDevice) close() error {
+	d.sem <- struct{}{}
+	defer func() { <-d.sem }()
+	d.removed = true
+	if d.session == nil || d.session.Closed() {
+		return nil
+	}
+	return d.session.Close()
+}
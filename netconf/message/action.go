@@ -0,0 +1,48 @@
+/*
+Copyright 2021. Alexis de Talhouët
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package message
+
+// YangXmlns is the XMLNS of the YANG 1.1 `action` element, see RFC 7950
+const YangXmlns = "urn:ietf:params:xml:ns:yang:1"
+
+// Action represents the NETCONF `action` message invoking a YANG 1.1 action.
+// https://datatracker.ietf.org/doc/html/rfc7950#section-7.15.2
+type Action struct {
+	RPC
+	Action ActionData `xml:"action"`
+}
+
+// ActionData is the struct to create a `action` message
+type ActionData struct {
+	XMLNS string      `xml:"xmlns,attr"`
+	Data  interface{} `xml:",innerxml"`
+}
+
+// NewAction can be used to create a `action` message. The data is the data tree leading to the action node,
+// the action node holding its input parameters, e.g.
+//
+//	<interfaces xmlns="urn:example:interfaces"><interface><name>eth0</name>
+//	  <reset><delay>10</delay></reset>
+//	</interface></interfaces>
+func NewAction(data string) *Action {
+	ValidateXML(data, InlineConfig{})
+
+	var rpc Action
+	rpc.Action = ActionData{XMLNS: YangXmlns, Data: data}
+	rpc.MessageID = newMessageID()
+	return &rpc
+}
@@ -16,6 +16,11 @@ limitations under the License.
 
 package message
 
+import (
+	"fmt"
+	"time"
+)
+
 // Commit represents the NETCONF `commit` message.
 // https://datatracker.ietf.org/doc/html/rfc6241#section-8.3.4.1
 type Commit struct {
@@ -27,6 +32,68 @@ type Commit struct {
 func NewCommit() *Commit {
 	var rpc Commit
 	rpc.Commit = ""
-	rpc.MessageID = uuid()
+	rpc.MessageID = newMessageID()
+	return &rpc
+}
+
+// commitParameters are the parameters of the `commit` message defined by the :confirmed-commit capability.
+// https://datatracker.ietf.org/doc/html/rfc6241#section-8.4.5.1
+type commitParameters struct {
+	Confirmed      interface{} `xml:"confirmed,omitempty"`
+	ConfirmTimeout uint32      `xml:"confirm-timeout,omitempty"`
+	Persist        string      `xml:"persist,omitempty"`
+	PersistID      string      `xml:"persist-id,omitempty"`
+}
+
+// NewConfirmedCommit can be used to create a confirmed `commit` message, the server reverting the commit unless it
+// is confirmed by another commit within the timeout, see NewConfirmingCommit. A zero timeout stands for the server
+// default, 600 seconds, and the timeout is sent in seconds, rounded up.
+// The commit is persistent when persist is not empty, i.e. it survives the session and is confirmed, or cancelled,
+// with persist as the persist-id, possibly from another session.
+func NewConfirmedCommit(timeout time.Duration, persist string) *Commit {
+	if timeout < 0 {
+		panic(fmt.Errorf("provided confirm timeout is not valid: %s. Expecting a positive duration", timeout))
+	}
+
+	params := &commitParameters{Confirmed: "", Persist: persist}
+	if timeout > 0 {
+		params.ConfirmTimeout = uint32((timeout + time.Second - 1) / time.Second)
+	}
+	var rpc Commit
+	rpc.Commit = params
+	rpc.MessageID = newMessageID()
+	return &rpc
+}
+
+// NewConfirmingCommit can be used to create the `commit` message confirming a confirmed commit. The persistID is
+// the persist value of a persistent confirmed commit, empty for a confirmed commit issued on the same session.
+func NewConfirmingCommit(persistID string) *Commit {
+	if persistID == "" {
+		return NewCommit()
+	}
+	var rpc Commit
+	rpc.Commit = &commitParameters{PersistID: persistID}
+	rpc.MessageID = newMessageID()
+	return &rpc
+}
+
+// CancelCommit represents the NETCONF `cancel-commit` message.
+// https://datatracker.ietf.org/doc/html/rfc6241#section-8.4.4.1
+type CancelCommit struct {
+	RPC
+	CancelCommit cancelCommit `xml:"cancel-commit"`
+}
+
+type cancelCommit struct {
+	PersistID string `xml:"persist-id,omitempty"`
+}
+
+// NewCancelCommit can be used to create a `cancel-commit` message, cancelling the ongoing confirmed commit.
+// The persistID is the one given to the persistent confirmed commit, empty to cancel a confirmed commit issued
+// on the same session.
+func NewCancelCommit(persistID string) *CancelCommit {
+	var rpc CancelCommit
+	rpc.CancelCommit.PersistID = persistID
+	rpc.MessageID = newMessageID()
 	return &rpc
 }
@@ -21,11 +21,14 @@ import (
 	"encoding/xml"
 	"fmt"
 	"io"
+	"net/url"
 )
 
 const (
 	// FilterTypeSubtree represent the filter for get operation
 	FilterTypeSubtree string = "subtree"
+	// FilterTypeXPath represent the XPath filter, supported by servers advertising the :xpath capability
+	FilterTypeXPath string = "xpath"
 	// DatastoreStartup represents the startup datastore
 	DatastoreStartup string = "startup"
 	// DatastoreRunning represents the running datastore
@@ -42,30 +45,33 @@ const (
 // Find examples here: https://datatracker.ietf.org/doc/html/rfc6241#section-6.4
 type Filter struct {
 	XMLName xml.Name `xml:"filter,omitempty"`
-	// Type defines the filter to use. Defaults to "subtree" and can support "XPath" if the server supports it.
-	Type string      `xml:"type,attr,omitempty"`
-	Data interface{} `xml:",innerxml"`
+	// Type defines the filter to use. Defaults to "subtree" and can support "xpath" if the server supports it.
+	Type string `xml:"type,attr,omitempty"`
+	// Select is the XPath expression of the "xpath" filter.
+	Select string `xml:"select,attr,omitempty"`
+	// Namespaces declares the prefixes used by the XPath expression.
+	Namespaces []xml.Attr  `xml:",any,attr"`
+	Data       interface{} `xml:",innerxml"`
 }
 
-// Datastore represents a NETCONF data store element
-type Datastore struct {
-	Candidate interface{} `xml:"candidate,omitempty"`
-	Running   interface{} `xml:"running,omitempty"`
-	Startup   interface{} `xml:"startup,omitempty"`
+// newFilter returns the filter of the provided type: the data is the subtree to select for "subtree" filters,
+// and the XPath expression for "xpath" filters.
+func newFilter(filterType string, data string) *Filter {
+	validateFilterType(filterType)
+	if filterType == FilterTypeXPath {
+		return NewXPathFilter(data, nil)
+	}
+	ValidateXML(data, Filter{})
+	return &Filter{Type: filterType, Data: data}
 }
 
-// datastore returns a Datastore object populated with appropriate datastoreType
-func datastore(datastoreType string) *Datastore {
-	validateDatastore(datastoreType)
-	switch datastoreType {
-	case DatastoreStartup:
-		return &Datastore{Startup: ""}
-	case DatastoreRunning:
-		return &Datastore{Running: ""}
-	case DatastoreCandidate:
-		return &Datastore{Candidate: ""}
+// NewXPathFilter returns a "xpath" filter selecting the nodes matching the XPath expression, e.g.
+// /t:top/t:users/t:user[t:name='fred']. The namespaces map the prefixes used by the expression to their namespace.
+func NewXPathFilter(selectExpr string, namespaces map[string]string) *Filter {
+	if selectExpr == "" {
+		panic(fmt.Errorf("provided XPath expression is not valid: it must not be empty"))
 	}
-	return nil // should never get there
+	return &Filter{Type: FilterTypeXPath, Select: selectExpr, Namespaces: namespaceAttrs(namespaces)}
 }
 
 // uuid generates a "good enough" uuid
@@ -95,6 +101,12 @@ func validateDatastore(datastore string) {
 	case DatastoreCandidate:
 		return
 	}
+	if IsNmdaDatastore(datastore) {
+		panic(fmt.Errorf(
+			"provided datastore is not valid: %s. It is an NMDA datastore, only supported by get-data, see NewGetData",
+			datastore,
+		))
+	}
 	panic(
 		fmt.Errorf(
 			"provided datastore is not valid: %s. Expecting `%s` or `%s`", datastore, DatastoreRunning,
@@ -103,13 +115,25 @@ func validateDatastore(datastore string) {
 	)
 }
 
+// validateURL checks the provided string is an absolute URL
+func validateURL(rawURL string) {
+	if u, err := url.Parse(rawURL); err != nil || u.Scheme == "" {
+		panic(fmt.Errorf("provided url is not valid: %s. Expecting an absolute URL, e.g. `file:///config.xml`", rawURL))
+	}
+}
+
 // validateFilterType checks the provided string is a supported FilterType
 func validateFilterType(filterType string) {
 	switch filterType {
 	case FilterTypeSubtree:
 		return
+	case FilterTypeXPath:
+		return
 	}
 	panic(
-		fmt.Errorf("provided filterType is not valid: %s. Expecting `%s`", filterType, FilterTypeSubtree),
+		fmt.Errorf(
+			"provided filterType is not valid: %s. Expecting `%s` or `%s`", filterType, FilterTypeSubtree,
+			FilterTypeXPath,
+		),
 	)
 }
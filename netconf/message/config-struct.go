@@ -0,0 +1,344 @@
+/*
+Copyright 2021. Alexis de Talhouët
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package message
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+const (
+	// NetconfBaseXmlns is the XMLNS of the NETCONF base protocol, used for the `operation` attribute
+	NetconfBaseXmlns = "urn:ietf:params:xml:ns:netconf:base:1.0"
+	// OperationTypeMerge merges the configuration data with the existing one
+	OperationTypeMerge = "merge"
+	// OperationTypeReplace replaces the existing configuration data
+	OperationTypeReplace = "replace"
+	// OperationTypeCreate creates the configuration data, failing if it already exists
+	OperationTypeCreate = "create"
+	// OperationTypeDelete deletes the configuration data, failing if it does not exist
+	OperationTypeDelete = "delete"
+	// OperationTypeRemove removes the configuration data, if it exists
+	OperationTypeRemove = "remove"
+)
+
+// NewEditConfigFromStruct can be used to create a `edit-config` message whose configuration is the XML encoding
+// of the provided value, see MarshalConfig.
+func NewEditConfigFromStruct(datastoreType string, operationType string, data interface{}) *EditConfig {
+	config, err := MarshalConfig(data)
+	if err != nil {
+		panic(fmt.Errorf("provided data cannot be encoded as configuration: %w", err))
+	}
+	return NewEditConfig(datastoreType, operationType, config)
+}
+
+// MarshalConfig returns the XML encoding of the provided configuration value.
+//
+// The value is encoded following the `xml` struct tags, as encoding/xml does, with the extra `nc` struct tag
+// setting the NETCONF operation of the element a field produces, e.g.
+//
+//	type Interface struct {
+//		XMLName xml.Name `xml:"urn:ietf:params:xml:ns:yang:ietf-interfaces interface"`
+//		Name    string   `xml:"name"`
+//		MTU     int      `xml:"mtu,omitempty" nc:"operation=replace"`
+//	}
+//
+// encodes the `mtu` element as <mtu xmlns:nc="urn:ietf:params:xml:ns:netconf:base:1.0" nc:operation="replace">.
+// Supported operations are merge, replace, create, delete and remove.
+// The `innerxml` option is not supported, as the content of such fields cannot be checked.
+func MarshalConfig(v interface{}) (string, error) {
+	var buf bytes.Buffer
+	encoder := xml.NewEncoder(&buf)
+	if err := encodeConfig(encoder, reflect.ValueOf(v), xml.Name{}, ""); err != nil {
+		return "", err
+	}
+	if err := encoder.Flush(); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+var (
+	xmlNameType   = reflect.TypeOf(xml.Name{})
+	marshalerType = reflect.TypeOf((*xml.Marshaler)(nil)).Elem()
+)
+
+// encodeConfig encodes the value as element(s) named after name when not overridden by the value itself,
+// carrying the provided operation, if any.
+func encodeConfig(encoder *xml.Encoder, v reflect.Value, name xml.Name, operation string) error {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return nil
+	}
+
+	if (v.Kind() == reflect.Slice || v.Kind() == reflect.Array) && v.Type().Elem().Kind() != reflect.Uint8 {
+		for i := 0; i < v.Len(); i++ {
+			if err := encodeConfig(encoder, v.Index(i), name, operation); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	isMarshaler := v.Type().Implements(marshalerType) || reflect.PtrTo(v.Type()).Implements(marshalerType)
+	if v.Kind() != reflect.Struct || isMarshaler {
+		if name.Local == "" {
+			name.Local = v.Type().Name()
+		}
+		start := xml.StartElement{Name: name, Attr: operationAttrs(operation)}
+		return encoder.EncodeElement(v.Interface(), start)
+	}
+
+	start := xml.StartElement{Name: structName(v, name), Attr: operationAttrs(operation)}
+	var attrs []xml.Attr
+	if err := collectAttrs(v, &attrs); err != nil {
+		return err
+	}
+	start.Attr = append(start.Attr, attrs...)
+
+	if err := encoder.EncodeToken(start); err != nil {
+		return err
+	}
+	if err := encodeFields(encoder, v); err != nil {
+		return err
+	}
+	return encoder.EncodeToken(start.End())
+}
+
+// structName returns the element name of the struct, following the encoding/xml precedence: the XMLName
+// field tag, then the XMLName field value, then the name of the field holding the struct, then the type name.
+func structName(v reflect.Value, name xml.Name) xml.Name {
+	if field, ok := v.Type().FieldByName("XMLName"); ok && field.Type == xmlNameType {
+		tag := parseXMLTag(field)
+		if tag.name != "" {
+			return xml.Name{Space: tag.space, Local: tag.name}
+		}
+		if value := v.FieldByIndex(field.Index).Interface().(xml.Name); value.Local != "" {
+			return value
+		}
+	}
+	if name.Local != "" {
+		return name
+	}
+	return xml.Name{Local: v.Type().Name()}
+}
+
+// collectAttrs gathers the attributes defined by the fields of the struct, including the embedded ones.
+func collectAttrs(v reflect.Value, attrs *[]xml.Attr) error {
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		value := v.Field(i)
+		if field.Anonymous && field.Tag.Get("xml") == "" && indirectType(field.Type).Kind() == reflect.Struct {
+			if value = indirect(value); value.IsValid() {
+				if err := collectAttrs(value, attrs); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		tag := parseXMLTag(field)
+		if field.PkgPath != "" || tag.skip || !tag.attr {
+			continue
+		}
+		if value = indirect(value); !value.IsValid() || (tag.omitEmpty && value.IsZero()) {
+			continue
+		}
+		name := tag.name
+		if name == "" {
+			name = field.Name
+		}
+		*attrs = append(*attrs, xml.Attr{Name: xml.Name{Space: tag.space, Local: name}, Value: fmt.Sprint(value.Interface())})
+	}
+	return nil
+}
+
+// encodeFields encodes the child elements and character data defined by the fields of the struct.
+func encodeFields(encoder *xml.Encoder, v reflect.Value) error {
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		value := v.Field(i)
+		if field.Anonymous && field.Tag.Get("xml") == "" && indirectType(field.Type).Kind() == reflect.Struct {
+			if value = indirect(value); value.IsValid() {
+				if err := encodeFields(encoder, value); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		tag := parseXMLTag(field)
+		if field.PkgPath != "" || tag.skip || tag.attr || field.Name == "XMLName" {
+			continue
+		}
+		operation, err := parseOperationTag(field)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case tag.innerXML:
+			return fmt.Errorf("field %s: innerxml is not supported", field.Name)
+		case tag.comment:
+			if value := indirect(value); value.IsValid() && !value.IsZero() {
+				if err := encoder.EncodeToken(xml.Comment(fmt.Sprint(value.Interface()))); err != nil {
+					return err
+				}
+			}
+			continue
+		case tag.charData:
+			if value := indirect(value); value.IsValid() {
+				if err := encoder.EncodeToken(xml.CharData(fmt.Sprint(value.Interface()))); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if (tag.omitEmpty && isEmpty(value)) || !indirect(value).IsValid() {
+			continue
+		}
+
+		name := tag.name
+		if name == "" {
+			name = field.Name
+		}
+		// a>b>c nests the element in its parents
+		parents := strings.Split(name, ">")
+		name = parents[len(parents)-1]
+		parents = parents[:len(parents)-1]
+		for _, parent := range parents {
+			if err := encoder.EncodeToken(xml.StartElement{Name: xml.Name{Local: parent}}); err != nil {
+				return err
+			}
+		}
+		if err := encodeConfig(encoder, value, xml.Name{Space: tag.space, Local: name}, operation); err != nil {
+			return err
+		}
+		for i := len(parents) - 1; i >= 0; i-- {
+			if err := encoder.EncodeToken(xml.EndElement{Name: xml.Name{Local: parents[i]}}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// operationAttrs returns the attributes setting the NETCONF operation of an element.
+func operationAttrs(operation string) []xml.Attr {
+	if operation == "" {
+		return nil
+	}
+	return []xml.Attr{
+		{Name: xml.Name{Local: "xmlns:nc"}, Value: NetconfBaseXmlns},
+		{Name: xml.Name{Local: "nc:operation"}, Value: operation},
+	}
+}
+
+// parseOperationTag returns the operation set by the `nc` struct tag of the field, if any.
+func parseOperationTag(field reflect.StructField) (string, error) {
+	tag, ok := field.Tag.Lookup("nc")
+	if !ok {
+		return "", nil
+	}
+	key, operation, found := strings.Cut(tag, "=")
+	if !found || key != "operation" {
+		return "", fmt.Errorf("field %s: invalid nc tag %q, expecting `operation=<operation>`", field.Name, tag)
+	}
+	switch operation {
+	case OperationTypeMerge, OperationTypeReplace, OperationTypeCreate, OperationTypeDelete, OperationTypeRemove:
+		return operation, nil
+	}
+	return "", fmt.Errorf("field %s: invalid operation %q", field.Name, operation)
+}
+
+// xmlTag is the parsed `xml` struct tag of a field.
+type xmlTag struct {
+	space     string
+	name      string
+	skip      bool
+	attr      bool
+	charData  bool
+	innerXML  bool
+	comment   bool
+	omitEmpty bool
+}
+
+func parseXMLTag(field reflect.StructField) xmlTag {
+	value := field.Tag.Get("xml")
+	if value == "-" {
+		return xmlTag{skip: true}
+	}
+	var tag xmlTag
+	options := strings.Split(value, ",")
+	tag.name = options[0]
+	if space, name, found := strings.Cut(tag.name, " "); found {
+		tag.space, tag.name = space, name
+	}
+	for _, option := range options[1:] {
+		switch option {
+		case "attr":
+			tag.attr = true
+		case "chardata":
+			tag.charData = true
+		case "innerxml":
+			tag.innerXML = true
+		case "comment":
+			tag.comment = true
+		case "omitempty":
+			tag.omitEmpty = true
+		}
+	}
+	return tag
+}
+
+// isEmpty tells whether the value is omitted by the `omitempty` option, as defined by encoding/xml.
+func isEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return v.IsZero()
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
+
+func indirect(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+func indirectType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
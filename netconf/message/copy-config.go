@@ -6,13 +6,62 @@ type CopyConfig struct {
 	RPC
 	Target *Datastore `xml:"copy-config>target"`
 	Source *Datastore `xml:"copy-config>source"`
+	// WithDefaults sets how the default values are copied, see NewWithDefaults.
+	WithDefaults *WithDefaults `xml:"copy-config>with-defaults,omitempty"`
 }
 
 // NewCopyConfig can be used to create a `copy-config` message.
 func NewCopyConfig(target string, source string) *CopyConfig {
+	return must(NewCopyConfigDatastores(datastore(target), datastore(source)))
+}
+
+// NewCopyConfigDatastores can be used to create a `copy-config` message replacing the target with the content of
+// the source: the target is a configuration datastore or a URL, see NewDatastore and NewURLDatastore, and the
+// source can also be an inline configuration, see NewInlineDatastore. It fails when the target or the source is
+// none of them.
+func NewCopyConfigDatastores(target *Datastore, source *Datastore) (*CopyConfig, error) {
+	err := validateTarget(
+		"copy-config target", target, DatastoreRunning, DatastoreCandidate, DatastoreStartup, datastoreURL,
+	)
+	if err != nil {
+		return nil, err
+	}
+	err = validateTarget(
+		"copy-config source", source, DatastoreRunning, DatastoreCandidate, DatastoreStartup, datastoreURL,
+		datastoreConfig,
+	)
+	if err != nil {
+		return nil, err
+	}
+
 	var rpc CopyConfig
-	rpc.Target = datastore(target)
-	rpc.Source = datastore(source)
-	rpc.MessageID = uuid()
-	return &rpc
+	rpc.Target = target
+	rpc.Source = source
+	rpc.MessageID = newMessageID()
+	return &rpc, nil
+}
+
+// NewCopyConfigWithDefaults can be used to create a `copy-config` message copying the default values following
+// the with-defaults mode, see NewWithDefaults.
+func NewCopyConfigWithDefaults(target string, source string, mode string) *CopyConfig {
+	rpc := NewCopyConfig(target, source)
+	rpc.WithDefaults = NewWithDefaults(mode)
+	return rpc
+}
+
+// NewCopyConfigFromURL can be used to create a `copy-config` message replacing the target datastore with the
+// configuration located at the URL, e.g. ftp://server/config.xml.
+func NewCopyConfigFromURL(target string, sourceURL string) *CopyConfig {
+	return must(NewCopyConfigDatastores(datastore(target), urlConfig(sourceURL)))
+}
+
+// NewCopyConfigToURL can be used to create a `copy-config` message saving the source datastore to the URL.
+func NewCopyConfigToURL(targetURL string, source string) *CopyConfig {
+	return must(NewCopyConfigDatastores(urlConfig(targetURL), datastore(source)))
+}
+
+// NewCopyConfigInline can be used to create a `copy-config` message replacing the target datastore with the
+// provided configuration.
+func NewCopyConfigInline(target string, data string) *CopyConfig {
+	return must(NewCopyConfigDatastores(datastore(target), inlineConfig(data)))
 }
@@ -0,0 +1,147 @@
+/*
+Copyright 2021. Alexis de Talhouët
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package message
+
+import (
+	"encoding/xml"
+	"fmt"
+)
+
+const (
+	// datastoreURL is the name of the datastores holding a configuration URL
+	datastoreURL = "url"
+	// datastoreConfig is the name of the datastores holding an inline configuration
+	datastoreConfig = "config"
+	// datastoreDynamic is the name of the dynamic datastores
+	datastoreDynamic = "dynamic"
+)
+
+// Datastore represents a NETCONF data store element, the source or target of the configuration operations:
+// one of the running, candidate and startup configuration datastores, a configuration URL or an inline
+// configuration. Use NewDatastore, NewURLDatastore, NewInlineDatastore or NewDynamicDatastore to create one.
+type Datastore struct {
+	Candidate interface{} `xml:"candidate,omitempty"`
+	Running   interface{} `xml:"running,omitempty"`
+	Startup   interface{} `xml:"startup,omitempty"`
+	// Config is an inline configuration, used instead of a datastore as the source of some operations.
+	Config *InlineConfig `xml:"config,omitempty"`
+	// URL is the location of a configuration, used instead of a datastore by servers advertising the :url
+	// capability.
+	URL string `xml:"url,omitempty"`
+	// Dynamic is a dynamic datastore, see RFC 8342, added to the datastores by the module defining it.
+	Dynamic *dynamicDatastore `xml:",any,omitempty"`
+}
+
+// dynamicDatastore is the element of a dynamic datastore, in the namespace of the module defining it.
+type dynamicDatastore struct {
+	XMLName xml.Name
+}
+
+// NewDatastore returns the configuration datastore with the provided name, e.g. DatastoreRunning.
+func NewDatastore(datastoreType string) *Datastore {
+	return datastore(datastoreType)
+}
+
+// NewURLDatastore returns the configuration located at the URL, e.g. ftp://server/config.xml.
+// It requires the :url capability.
+func NewURLDatastore(rawURL string) *Datastore {
+	return urlConfig(rawURL)
+}
+
+// NewInlineDatastore returns the provided configuration, only usable as the source of the operations.
+func NewInlineDatastore(data string) *Datastore {
+	return inlineConfig(data)
+}
+
+// NewDynamicDatastore returns the dynamic datastore defined by the module with the namespace, e.g. an ephemeral
+// datastore. Only lock and unlock accept it, and only on servers supporting it.
+// The NMDA datastores, e.g. DatastoreOperational, are not dynamic ones and are only usable with get-data.
+func NewDynamicDatastore(namespace string, name string) *Datastore {
+	if namespace == "" || name == "" {
+		panic(fmt.Errorf("provided datastore is not valid: both the namespace and the name are required"))
+	}
+	return &Datastore{Dynamic: &dynamicDatastore{XMLName: xml.Name{Space: namespace, Local: name}}}
+}
+
+// Name returns the name of the datastore, e.g. DatastoreRunning, "url" for URLs, "config" for inline
+// configurations and "dynamic" for dynamic datastores.
+func (d *Datastore) Name() string {
+	switch {
+	case d.Running != nil:
+		return DatastoreRunning
+	case d.Candidate != nil:
+		return DatastoreCandidate
+	case d.Startup != nil:
+		return DatastoreStartup
+	case d.URL != "":
+		return datastoreURL
+	case d.Config != nil:
+		return datastoreConfig
+	case d.Dynamic != nil:
+		return datastoreDynamic
+	}
+	return ""
+}
+
+// datastore returns a Datastore object populated with appropriate datastoreType
+func datastore(datastoreType string) *Datastore {
+	validateDatastore(datastoreType)
+	switch datastoreType {
+	case DatastoreStartup:
+		return &Datastore{Startup: ""}
+	case DatastoreRunning:
+		return &Datastore{Running: ""}
+	case DatastoreCandidate:
+		return &Datastore{Candidate: ""}
+	}
+	return nil // should never get there
+}
+
+// inlineConfig returns a Datastore object holding the provided configuration
+func inlineConfig(data string) *Datastore {
+	ValidateXML(data, InlineConfig{})
+	return &Datastore{Config: &InlineConfig{Config: data}}
+}
+
+// urlConfig returns a Datastore object holding the provided configuration URL
+func urlConfig(rawURL string) *Datastore {
+	validateURL(rawURL)
+	return &Datastore{URL: rawURL}
+}
+
+// validateTarget checks the datastore is one of the accepted ones
+func validateTarget(operation string, d *Datastore, accepted ...string) error {
+	name := ""
+	if d != nil {
+		name = d.Name()
+	}
+	for _, a := range accepted {
+		if name == a {
+			return nil
+		}
+	}
+	return fmt.Errorf("provided datastore is not valid for %s: %s. Expecting one of %v", operation, name, accepted)
+}
+
+// must returns the message built from the datastores the caller already validated, panicking otherwise as the
+// message constructors do on invalid input.
+func must[T any](rpc T, err error) T {
+	if err != nil {
+		panic(err)
+	}
+	return rpc
+}
@@ -0,0 +1,222 @@
+/*
+Copyright 2021. Alexis de Talhouët
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package message
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// Decode unmarshals the content of the `data` element of the reply into v, as encoding/xml does: the fields of v
+// describe the top-level elements of the data, e.g.
+//
+//	var config struct {
+//		Interfaces []Interface `xml:"urn:ietf:params:xml:ns:yang:ietf-interfaces interfaces>interface"`
+//	}
+//	err := reply.Decode(&config)
+//
+// When v names its element with a XMLName field other than `data`, the top-level element with that name is
+// decoded instead, see DecodeAt.
+// It returns ErrNoData when the reply does not carry a `data` element.
+func (reply *RPCReply) Decode(v interface{}) error {
+	if name := xmlName(v); name != "" && name != "data" {
+		return reply.DecodeAt(name, v)
+	}
+	decoder, start, err := reply.dataDecoder()
+	if err != nil {
+		return err
+	}
+	if err := decoder.DecodeElement(v, &start); err != nil {
+		return fmt.Errorf("fail to decode data: %w", err)
+	}
+	return nil
+}
+
+// DecodeAt unmarshals the element found at the path within the `data` element of the reply into v.
+// The path lists the local names of the elements leading to it, separated by slashes, e.g.
+// "interfaces/interface". When v is a pointer to a slice, all the elements found at the path are appended to it,
+// otherwise only the first one is decoded.
+// It returns ErrNoData when the reply does not carry a `data` element, and an error when nothing is found at
+// the path.
+func (reply *RPCReply) DecodeAt(path string, v interface{}) error {
+	names := strings.Split(strings.Trim(path, "/"), "/")
+	if path == "" || len(names) == 0 {
+		return fmt.Errorf("invalid path %q", path)
+	}
+	decoder, _, err := reply.dataDecoder()
+	if err != nil {
+		return err
+	}
+
+	value := reflect.ValueOf(v)
+	all := value.Kind() == reflect.Ptr && value.Elem().Kind() == reflect.Slice &&
+		value.Elem().Type().Elem().Kind() != reflect.Uint8
+
+	// matched counts the leading names of the path matched by the current element and its parents
+	matched, depth, found := 0, 0, 0
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return fmt.Errorf("fail to decode data: %w", err)
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			if matched == depth && t.Name.Local == names[depth] {
+				if depth == len(names)-1 {
+					if err := decoder.DecodeElement(v, &t); err != nil {
+						return fmt.Errorf("fail to decode %s: %w", path, err)
+					}
+					if found++; !all {
+						return nil
+					}
+					continue
+				}
+				matched++
+			}
+			depth++
+		case xml.EndElement:
+			if depth == 0 {
+				// end of the data element
+				if found == 0 {
+					return fmt.Errorf("no element found at %s", path)
+				}
+				return nil
+			}
+			if matched == depth {
+				matched--
+			}
+			depth--
+		}
+	}
+}
+
+// xmlName returns the local name set by the XMLName field tag of the struct v points to, if any.
+func xmlName(v interface{}) string {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return ""
+	}
+	field, ok := t.FieldByName("XMLName")
+	if !ok || field.Type != xmlNameType {
+		return ""
+	}
+	return parseXMLTag(field).name
+}
+
+// dataDecoder returns a decoder positioned after the start of the `data` element of the reply.
+func (reply *RPCReply) dataDecoder() (*xml.Decoder, xml.StartElement, error) {
+	raw := reply.RawReply
+	if raw == "" {
+		raw = reply.Data
+	}
+	decoder := xml.NewDecoder(bytes.NewReader([]byte(raw)))
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil, xml.StartElement{}, ErrNoData
+		}
+		if err != nil {
+			return nil, xml.StartElement{}, err
+		}
+		if start, ok := token.(xml.StartElement); ok && start.Name.Local == "data" {
+			return decoder, start, nil
+		}
+	}
+}
+
+// DataContent returns the content of the `data` element of the reply, as sent by the server. The prefixes
+// declared by the enclosing elements, e.g. the rpc-reply, are not declared again.
+// It returns ErrNoData when the reply does not carry a `data` element.
+func (reply *RPCReply) DataContent() ([]byte, error) {
+	raw := reply.RawReply
+	if raw == "" {
+		raw = reply.Data
+	}
+	decoder, _, err := reply.dataDecoder()
+	if err != nil {
+		return nil, err
+	}
+	begin, depth := decoder.InputOffset(), 0
+	for {
+		end := decoder.InputOffset()
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, fmt.Errorf("fail to decode data: %w", err)
+		}
+		switch token.(type) {
+		case xml.StartElement:
+			depth++
+		case xml.EndElement:
+			if depth == 0 {
+				return []byte(raw[begin:end]), nil
+			}
+			depth--
+		}
+	}
+}
+
+// Node is an element of the data of a reply, see DataNodes.
+type Node struct {
+	XMLName xml.Name
+	Attrs   []xml.Attr `xml:",any,attr"`
+	// Text is the content of the element, without the surrounding whitespace.
+	Text     string `xml:",chardata"`
+	Children []Node `xml:",any"`
+}
+
+// Child returns the first child with the local name, nil when there is none.
+func (node *Node) Child(name string) *Node {
+	for i := range node.Children {
+		if node.Children[i].XMLName.Local == name {
+			return &node.Children[i]
+		}
+	}
+	return nil
+}
+
+// DataNodes parses the content of the `data` element of the reply into a tree of nodes, one per top-level
+// element.
+// It returns ErrNoData when the reply does not carry a `data` element.
+func (reply *RPCReply) DataNodes() ([]Node, error) {
+	var data struct {
+		Nodes []Node `xml:",any"`
+	}
+	decoder, start, err := reply.dataDecoder()
+	if err != nil {
+		return nil, err
+	}
+	if err := decoder.DecodeElement(&data, &start); err != nil {
+		return nil, fmt.Errorf("fail to decode data: %w", err)
+	}
+	trimNodes(data.Nodes)
+	return data.Nodes, nil
+}
+
+// trimNodes removes the whitespace surrounding the text of the nodes, e.g. the indentation of their children.
+func trimNodes(nodes []Node) {
+	for i := range nodes {
+		nodes[i].Text = strings.TrimSpace(nodes[i].Text)
+		trimNodes(nodes[i].Children)
+	}
+}
@@ -0,0 +1,55 @@
+/*
+Copyright 2021. Alexis de Talhouët
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package message
+
+import "fmt"
+
+// DeleteConfig represents the NETCONF `delete-config` operation.
+// https://datatracker.ietf.org/doc/html/rfc6241#section-7.4
+type DeleteConfig struct {
+	RPC
+	Target *Datastore `xml:"delete-config>target"`
+}
+
+// NewDeleteConfig can be used to create a `delete-config` message.
+// The running datastore cannot be deleted, as required by RFC 6241.
+func NewDeleteConfig(target string) *DeleteConfig {
+	if target == DatastoreRunning {
+		panic(
+			fmt.Errorf(
+				"provided datastore cannot be deleted: %s. Expecting `%s` or `%s`", target, DatastoreStartup,
+				DatastoreCandidate,
+			),
+		)
+	}
+
+	return must(NewDeleteConfigDatastore(datastore(target)))
+}
+
+// NewDeleteConfigDatastore can be used to create a `delete-config` message deleting the startup or candidate
+// datastore, or the configuration located at a URL, see NewDatastore and NewURLDatastore. It fails when the
+// target is none of them.
+func NewDeleteConfigDatastore(target *Datastore) (*DeleteConfig, error) {
+	if err := validateTarget("delete-config", target, DatastoreStartup, DatastoreCandidate, datastoreURL); err != nil {
+		return nil, err
+	}
+
+	var rpc DeleteConfig
+	rpc.Target = target
+	rpc.MessageID = newMessageID()
+	return &rpc, nil
+}
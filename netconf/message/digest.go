@@ -0,0 +1,123 @@
+/*
+Copyright 2021. Alexis de Talhouët
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package message
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"io"
+	"sort"
+)
+
+// ErrNoData is returned when an RPCReply does not carry a `data` element.
+var ErrNoData = errors.New("rpc-reply does not contain a data element")
+
+// DataDigest computes a SHA-256 digest of the canonicalized `data` subtree of the reply.
+// Canonicalization resolves namespace prefixes, sorts attributes, and drops comments, processing instructions
+// and whitespace-only text, so two replies carrying the same data produce the same digest regardless of
+// formatting or prefix choices made by the server. The text of the leaves is kept as is.
+func (reply *RPCReply) DataDigest() (string, error) {
+	raw := reply.RawReply
+	if raw == "" {
+		raw = "<rpc-reply xmlns=\"" + NetconfBaseXmlns + "\">" + reply.Data + "</rpc-reply>"
+	}
+
+	canonical, err := canonicalData([]byte(raw))
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(canonical)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// canonicalData returns the canonical form of the `data` element of the rpc-reply in the provided XML, both in
+// the NETCONF base namespace.
+func canonicalData(raw []byte) ([]byte, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(raw))
+	var out bytes.Buffer
+	// depth is the depth within the data element, and parents the elements opened outside of it
+	depth := 0
+	var parents []xml.Name
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if depth == 0 && !isReplyData(parents, t.Name) {
+				parents = append(parents, t.Name)
+				continue
+			}
+			depth++
+			writeCanonicalStart(&out, t)
+		case xml.EndElement:
+			if depth == 0 {
+				parents = parents[:len(parents)-1]
+				continue
+			}
+			depth--
+			out.WriteString("</{" + t.Name.Space + "}" + t.Name.Local + ">")
+			if depth == 0 {
+				return out.Bytes(), nil
+			}
+		case xml.CharData:
+			if depth == 0 {
+				continue
+			}
+			if len(bytes.TrimSpace(t)) != 0 {
+				_ = xml.EscapeText(&out, t)
+			}
+		}
+	}
+	return nil, ErrNoData
+}
+
+// isReplyData tells whether the element opened within the parents is the data element of an rpc-reply.
+func isReplyData(parents []xml.Name, name xml.Name) bool {
+	return name == xml.Name{Space: NetconfBaseXmlns, Local: "data"} &&
+		len(parents) == 1 && parents[0] == xml.Name{Space: NetconfBaseXmlns, Local: "rpc-reply"}
+}
+
+// writeCanonicalStart writes a start element using its resolved namespace and sorted attributes.
+// Namespace declarations are dropped as they are already reflected in the resolved names.
+func writeCanonicalStart(out *bytes.Buffer, start xml.StartElement) {
+	var attrs []string
+	for _, attr := range start.Attr {
+		if attr.Name.Space == "xmlns" || (attr.Name.Space == "" && attr.Name.Local == "xmlns") {
+			continue
+		}
+		var value bytes.Buffer
+		_ = xml.EscapeText(&value, []byte(attr.Value))
+		attrs = append(attrs, "{"+attr.Name.Space+"}"+attr.Name.Local+"=\""+value.String()+"\"")
+	}
+	sort.Strings(attrs)
+
+	out.WriteString("<{" + start.Name.Space + "}" + start.Name.Local)
+	for _, attr := range attrs {
+		out.WriteString(" " + attr)
+	}
+	out.WriteString(">")
+}
@@ -0,0 +1,32 @@
+/*
+Copyright 2021. Alexis de Talhouët
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package message
+
+// DiscardChanges represents the NETCONF `discard-changes` message.
+// https://datatracker.ietf.org/doc/html/rfc6241#section-8.3.4.2
+type DiscardChanges struct {
+	RPC
+	DiscardChanges interface{} `xml:"discard-changes"`
+}
+
+// NewDiscardChanges can be used to create a `discard-changes` message.
+func NewDiscardChanges() *DiscardChanges {
+	var rpc DiscardChanges
+	rpc.DiscardChanges = ""
+	rpc.MessageID = newMessageID()
+	return &rpc
+}
@@ -0,0 +1,194 @@
+/*
+Copyright 2021. Alexis de Talhouët
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package message
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// EditConfigBuilder assembles an `edit-config` message whose configuration holds several subtrees, each one
+// carrying its own operation, e.g.
+//
+//	NewEditConfigBuilder(NewDatastore(DatastoreCandidate)).
+//		DefaultOperation(DefaultOperationTypeNone).
+//		Merge(`<system xmlns="urn:example:system"><hostname>r1</hostname></system>`).
+//		Delete(`<users xmlns="urn:example:system"><user><name>bob</name></user></users>`).
+//		Build()
+//
+// The operation is set on the top element of each subtree, which must not already carry one.
+// Build fails when the target is not a configuration datastore or no subtree was added; the other methods
+// panic on invalid input, as the message constructors do.
+type EditConfigBuilder struct {
+	target           *Datastore
+	err              error
+	defaultOperation string
+	testOption       string
+	errorOption      string
+	subtrees         []string
+}
+
+// NewEditConfigBuilder returns a builder of a `edit-config` message editing the configuration datastore,
+// see NewDatastore.
+func NewEditConfigBuilder(target *Datastore) *EditConfigBuilder {
+	err := validateTarget("edit-config", target, DatastoreRunning, DatastoreCandidate, DatastoreStartup)
+	return &EditConfigBuilder{target: target, err: err}
+}
+
+// DefaultOperation sets the default-operation parameter, omitted by default, in which case the server merges.
+func (b *EditConfigBuilder) DefaultOperation(operation string) *EditConfigBuilder {
+	validDefaultOperation(operation)
+	b.defaultOperation = operation
+	return b
+}
+
+// TestOption sets the test-option parameter, see NewEditConfigWithOptions.
+func (b *EditConfigBuilder) TestOption(option string) *EditConfigBuilder {
+	validTestOption(option)
+	b.testOption = option
+	return b
+}
+
+// ErrorOption sets the error-option parameter, see NewEditConfigWithOptions.
+func (b *EditConfigBuilder) ErrorOption(option string) *EditConfigBuilder {
+	validErrorOption(option)
+	b.errorOption = option
+	return b
+}
+
+// Merge adds the subtree, merged with the existing configuration.
+func (b *EditConfigBuilder) Merge(data string) *EditConfigBuilder {
+	return b.Add(OperationTypeMerge, data)
+}
+
+// Replace adds the subtree, replacing the existing configuration.
+func (b *EditConfigBuilder) Replace(data string) *EditConfigBuilder {
+	return b.Add(OperationTypeReplace, data)
+}
+
+// Create adds the subtree, created only if it does not exist yet.
+func (b *EditConfigBuilder) Create(data string) *EditConfigBuilder {
+	return b.Add(OperationTypeCreate, data)
+}
+
+// Delete adds the subtree to delete, failing if it does not exist.
+func (b *EditConfigBuilder) Delete(data string) *EditConfigBuilder {
+	return b.Add(OperationTypeDelete, data)
+}
+
+// Remove adds the subtree to remove, if it exists.
+func (b *EditConfigBuilder) Remove(data string) *EditConfigBuilder {
+	return b.Add(OperationTypeRemove, data)
+}
+
+// Add adds the subtree carrying the operation, e.g. OperationTypeMerge. An empty operation adds the subtree as
+// is, applying the default operation.
+func (b *EditConfigBuilder) Add(operation string, data string) *EditConfigBuilder {
+	switch operation {
+	case "", OperationTypeMerge, OperationTypeReplace, OperationTypeCreate, OperationTypeDelete, OperationTypeRemove:
+	default:
+		panic(fmt.Errorf(
+			"provided operation is not valid: %s. Expecting either `%s`, `%s`, `%s`, `%s`, or `%s`", operation,
+			OperationTypeMerge, OperationTypeReplace, OperationTypeCreate, OperationTypeDelete, OperationTypeRemove,
+		))
+	}
+	subtree, err := withOperation(data, operation)
+	if err != nil {
+		panic(fmt.Errorf("provided XML is not valid: %s. \n%s", data, err))
+	}
+	b.subtrees = append(b.subtrees, subtree)
+	return b
+}
+
+// Build returns the `edit-config` message holding the added subtrees, in the order they were added.
+func (b *EditConfigBuilder) Build() (*EditConfig, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	if len(b.subtrees) == 0 {
+		return nil, fmt.Errorf("provided edit-config is not valid: it has no configuration")
+	}
+
+	var rpc EditConfig
+	rpc.Target = b.target
+	rpc.DefaultOperation = b.defaultOperation
+	rpc.TestOption = b.testOption
+	rpc.ErrorOption = b.errorOption
+	rpc.Config = &InlineConfig{Config: strings.Join(b.subtrees, "")}
+	rpc.MessageID = newMessageID()
+	return &rpc, nil
+}
+
+// withOperation returns the subtree, its single top element carrying the operation.
+// The attribute is inserted in the original text, so the prefixes and the formatting of the subtree are kept.
+func withOperation(data string, operation string) (string, error) {
+	decoder := xml.NewDecoder(strings.NewReader(data))
+	var (
+		insertAt int64 = -1
+		depth    int
+	)
+	for {
+		offset := decoder.InputOffset()
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			if depth == 0 {
+				if insertAt >= 0 {
+					return "", fmt.Errorf("expecting a single top element, got %s too", t.Name.Local)
+				}
+				for _, attr := range t.Attr {
+					if attr.Name.Space == NetconfBaseXmlns && attr.Name.Local == "operation" {
+						return "", fmt.Errorf("element %s already carries an operation", t.Name.Local)
+					}
+				}
+				// the attribute goes right after the element name
+				insertAt = offset + 1 + int64(len(rawName(data[offset+1:])))
+			}
+			depth++
+		case xml.EndElement:
+			depth--
+		case xml.CharData:
+			if depth == 0 && len(strings.TrimSpace(string(t))) != 0 {
+				return "", fmt.Errorf("unexpected text %q outside of the top element", strings.TrimSpace(string(t)))
+			}
+		}
+	}
+	if insertAt < 0 {
+		return "", fmt.Errorf("expecting a single top element, got none")
+	}
+	if operation == "" {
+		return data, nil
+	}
+	attrs := fmt.Sprintf(` xmlns:nc="%s" nc:operation="%s"`, NetconfBaseXmlns, operation)
+	return data[:insertAt] + attrs + data[insertAt:], nil
+}
+
+// rawName returns the element name at the start of the text, as written, e.g. `if:interfaces`.
+func rawName(text string) string {
+	if i := strings.IndexAny(text, " \t\r\n/>"); i >= 0 {
+		return text[:i]
+	}
+	return text
+}
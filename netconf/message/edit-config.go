@@ -25,34 +25,92 @@ const (
 	DefaultOperationTypeReplace string = "replace"
 	// DefaultOperationTypeNone represents the default operation to apply when doing an edit-config operation
 	DefaultOperationTypeNone string = "none"
+
+	// TestOptionTestThenSet validates the configuration before applying it
+	TestOptionTestThenSet string = "test-then-set"
+	// TestOptionSet applies the configuration without validating it first
+	TestOptionSet string = "set"
+	// TestOptionTestOnly validates the configuration without applying it
+	TestOptionTestOnly string = "test-only"
+
+	// ErrorOptionStopOnError aborts the edit-config operation on the first error
+	ErrorOptionStopOnError string = "stop-on-error"
+	// ErrorOptionContinueOnError continues the edit-config operation despite errors, reporting them
+	ErrorOptionContinueOnError string = "continue-on-error"
+	// ErrorOptionRollbackOnError aborts the edit-config operation on the first error, restoring the configuration
+	// as it was before the operation
+	ErrorOptionRollbackOnError string = "rollback-on-error"
 )
 
 // EditConfig represents the NETCONF `edit-config` operation.
 // https://datatracker.ietf.org/doc/html/rfc6241#section-7.2
 type EditConfig struct {
 	RPC
-	Target           *Datastore `xml:"edit-config>target"`
-	DefaultOperation string     `xml:"edit-config>default-operation,omitempty"`
-	Config           *config    `xml:"edit-config>config"`
+	Target           *Datastore    `xml:"edit-config>target"`
+	DefaultOperation string        `xml:"edit-config>default-operation,omitempty"`
+	TestOption       string        `xml:"edit-config>test-option,omitempty"`
+	ErrorOption      string        `xml:"edit-config>error-option,omitempty"`
+	Config           *InlineConfig `xml:"edit-config>config"`
+	// URL is the location of the configuration to apply, used instead of Config.
+	URL string `xml:"edit-config>url,omitempty"`
 }
 
-type config struct {
+// InlineConfig is a configuration carried by a message, in a `config` element.
+type InlineConfig struct {
 	Config interface{} `xml:",innerxml"`
 }
 
 // NewEditConfig can be used to create a `edit-config` message.
 func NewEditConfig(datastoreType string, operationType string, data string) *EditConfig {
-	ValidateXML(data, config{})
+	return must(NewEditConfigDatastore(datastore(datastoreType), operationType, data))
+}
+
+// NewEditConfigDatastore can be used to create a `edit-config` message editing the configuration datastore,
+// see NewDatastore. It fails when the target is not a configuration datastore.
+func NewEditConfigDatastore(target *Datastore, operationType string, data string) (*EditConfig, error) {
+	if err := validateTarget("edit-config", target, DatastoreRunning, DatastoreCandidate, DatastoreStartup); err != nil {
+		return nil, err
+	}
+	ValidateXML(data, InlineConfig{})
 	validDefaultOperation(operationType)
 
+	var rpc EditConfig
+	rpc.Target = target
+	rpc.DefaultOperation = operationType
+	rpc.Config = &InlineConfig{Config: data}
+	rpc.MessageID = newMessageID()
+	return &rpc, nil
+}
+
+// NewEditConfigFromURL can be used to create a `edit-config` message applying the configuration located at the
+// URL, e.g. https://server/config.xml, instead of an inline one.
+func NewEditConfigFromURL(datastoreType string, operationType string, configURL string) *EditConfig {
+	validDefaultOperation(operationType)
+	validateURL(configURL)
+
 	var rpc EditConfig
 	rpc.Target = datastore(datastoreType)
 	rpc.DefaultOperation = operationType
-	rpc.Config = &config{Config: data}
-	rpc.MessageID = uuid()
+	rpc.URL = configURL
+	rpc.MessageID = newMessageID()
 	return &rpc
 }
 
+// NewEditConfigWithOptions can be used to create a `edit-config` message with the test-option and the
+// error-option parameters, each omitted when empty. The test-option requires the :validate capability, and the
+// rollback-on-error error-option the :rollback-on-error capability.
+func NewEditConfigWithOptions(
+	datastoreType string, operationType string, testOption string, errorOption string, data string,
+) *EditConfig {
+	validTestOption(testOption)
+	validErrorOption(errorOption)
+
+	rpc := NewEditConfig(datastoreType, operationType, data)
+	rpc.TestOption = testOption
+	rpc.ErrorOption = errorOption
+	return rpc
+}
+
 func validDefaultOperation(operation string) {
 	switch operation {
 	case DefaultOperationTypeMerge:
@@ -69,3 +127,29 @@ func validDefaultOperation(operation string) {
 		),
 	)
 }
+
+func validTestOption(option string) {
+	switch option {
+	case "", TestOptionTestThenSet, TestOptionSet, TestOptionTestOnly:
+		return
+	}
+	panic(
+		fmt.Errorf(
+			"provided test-option is not valid: %s. Expecting either `%s`, `%s`, or `%s`", option,
+			TestOptionTestThenSet, TestOptionSet, TestOptionTestOnly,
+		),
+	)
+}
+
+func validErrorOption(option string) {
+	switch option {
+	case "", ErrorOptionStopOnError, ErrorOptionContinueOnError, ErrorOptionRollbackOnError:
+		return
+	}
+	panic(
+		fmt.Errorf(
+			"provided error-option is not valid: %s. Expecting either `%s`, `%s`, or `%s`", option,
+			ErrorOptionStopOnError, ErrorOptionContinueOnError, ErrorOptionRollbackOnError,
+		),
+	)
+}
@@ -0,0 +1,78 @@
+/*
+Copyright 2021. Alexis de Talhouët
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package message
+
+import "fmt"
+
+// EditData represents the NETCONF `edit-data` message.
+// https://datatracker.ietf.org/doc/html/rfc8526#section-3.1.2
+type EditData struct {
+	RPC
+	EditData EditDataData `xml:"edit-data"`
+}
+
+// EditDataData is the struct to create a `edit-data` message
+type EditDataData struct {
+	XMLNS            string        `xml:"xmlns,attr"`
+	DSXMLNS          string        `xml:"xmlns:ds,attr"`
+	Datastore        datastoreName `xml:"datastore"`
+	DefaultOperation string        `xml:"default-operation,omitempty"`
+	Config           *InlineConfig `xml:"config"`
+}
+
+// datastoreName is a datastore identity, declaring its namespace when it is not one of the ietf-datastores ones.
+type datastoreName struct {
+	XMLNS    string `xml:"xmlns:dsx,attr,omitempty"`
+	Identity string `xml:",chardata"`
+}
+
+// NewEditData can be used to create a `edit-data` message editing a writable NMDA datastore: DatastoreRunning,
+// DatastoreCandidate or DatastoreStartup. See NewEditDataDynamic for the datastores defined by other modules.
+func NewEditData(datastore string, operationType string, data string) *EditData {
+	if IsNmdaDatastore(datastore) {
+		panic(fmt.Errorf("provided datastore is not valid: %s. It is read-only", datastore))
+	}
+	validateNmdaDatastore(datastore)
+	return newEditData(datastoreName{Identity: "ds:" + datastore}, operationType, data)
+}
+
+// NewEditDataDynamic can be used to create a `edit-data` message editing a datastore defined by another module,
+// e.g. a dynamic datastore, identified by the namespace of the module and the name of its identity.
+func NewEditDataDynamic(namespace string, identity string, operationType string, data string) *EditData {
+	if namespace == "" || identity == "" {
+		panic(fmt.Errorf("provided datastore is not valid: both the namespace and the identity are required"))
+	}
+	return newEditData(datastoreName{XMLNS: namespace, Identity: "dsx:" + identity}, operationType, data)
+}
+
+func newEditData(datastore datastoreName, operationType string, data string) *EditData {
+	ValidateXML(data, InlineConfig{})
+	if operationType != "" {
+		validDefaultOperation(operationType)
+	}
+
+	var rpc EditData
+	rpc.EditData = EditDataData{
+		XMLNS:            NetconfNmdaXmlns,
+		DSXMLNS:          DatastoresXmlns,
+		Datastore:        datastore,
+		DefaultOperation: operationType,
+		Config:           &InlineConfig{Config: data},
+	}
+	rpc.MessageID = newMessageID()
+	return &rpc
+}
@@ -0,0 +1,140 @@
+/*
+Copyright 2021. Alexis de Talhouët
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package message
+
+import (
+	"fmt"
+	"strconv"
+)
+
+const (
+	// NetconfNmdaXmlns is the XMLNS of the NETCONF NMDA operations, see RFC 8526
+	NetconfNmdaXmlns = "urn:ietf:params:xml:ns:yang:ietf-netconf-nmda"
+	// DatastoresXmlns is the XMLNS of the datastore identities, see RFC 8342
+	DatastoresXmlns = "urn:ietf:params:xml:ns:yang:ietf-datastores"
+	// OriginXmlns is the XMLNS of the origin identities, see RFC 8342
+	OriginXmlns = "urn:ietf:params:xml:ns:yang:ietf-origin"
+	// DatastoreIntended represents the intended NMDA datastore
+	DatastoreIntended string = "intended"
+	// DatastoreOperational represents the operational state NMDA datastore
+	DatastoreOperational string = "operational"
+	// DatastoreSystem represents the system NMDA datastore, holding the configuration provided by the system
+	DatastoreSystem string = "system"
+)
+
+// GetData represents the NETCONF `get-data` message.
+// https://datatracker.ietf.org/doc/html/rfc8526#section-3.1.1
+type GetData struct {
+	RPC
+	GetData GetDataData `xml:"get-data"`
+}
+
+// GetDataData is the struct to create a `get-data` message
+type GetDataData struct {
+	XMLNS   string `xml:"xmlns,attr"`
+	DSXMLNS string `xml:"xmlns:ds,attr"`
+	// ORXMLNS is set when origin filters are used.
+	ORXMLNS       string        `xml:"xmlns:or,attr,omitempty"`
+	Datastore     string        `xml:"datastore"`
+	SubtreeFilter *subtree      `xml:"subtree-filter,omitempty"`
+	ConfigFilter  *bool         `xml:"config-filter,omitempty"`
+	OriginFilters []string      `xml:"origin-filter,omitempty"`
+	MaxDepth      string        `xml:"max-depth,omitempty"`
+	WithOrigin    interface{}   `xml:"with-origin,omitempty"`
+	WithDefaults  *WithDefaults `xml:"with-defaults,omitempty"`
+}
+
+type subtree struct {
+	Data interface{} `xml:",innerxml"`
+}
+
+// NewGetData can be used to create a `get-data` message, retrieving data from an NMDA datastore, e.g.
+// DatastoreOperational.
+//   - filter is a subtree filter, empty to select the whole datastore.
+//   - configFilter selects the configuration data when true, the state data when false, both when nil.
+//   - originFilters select the data by origin, e.g. "intended" or "learned", see RFC 8342. The filter is only
+//     applicable to the operational datastore.
+//   - withOrigin reports the origin of the data, only applicable to the operational datastore.
+//   - maxDepth is the maximum depth of the returned subtrees, zero for unbounded.
+//   - defaults is the with-defaults mode, e.g. WithDefaultsReportAll, empty for the server default.
+func NewGetData(
+	datastore string, filter string, configFilter *bool, originFilters []string, withOrigin bool, maxDepth int,
+	defaults string,
+) *GetData {
+	validateNmdaDatastore(datastore)
+	if maxDepth < 0 || maxDepth > 65535 {
+		panic(fmt.Errorf("provided max-depth is not valid: %d. Expecting a number between 0 and 65535", maxDepth))
+	}
+	if (len(originFilters) > 0 || withOrigin) && datastore != DatastoreOperational {
+		panic(fmt.Errorf("provided datastore is not valid: %s. Origin is only supported by `%s`", datastore, DatastoreOperational))
+	}
+
+	data := GetDataData{
+		XMLNS:        NetconfNmdaXmlns,
+		DSXMLNS:      DatastoresXmlns,
+		Datastore:    "ds:" + datastore,
+		ConfigFilter: configFilter,
+		WithDefaults: NewWithDefaults(defaults),
+	}
+	if filter != "" {
+		ValidateXML(filter, Filter{})
+		data.SubtreeFilter = &subtree{Data: filter}
+	}
+	if len(originFilters) > 0 {
+		data.ORXMLNS = OriginXmlns
+		for _, origin := range originFilters {
+			data.OriginFilters = append(data.OriginFilters, "or:"+origin)
+		}
+	}
+	if maxDepth > 0 {
+		data.MaxDepth = strconv.Itoa(maxDepth)
+	}
+	if withOrigin {
+		data.WithOrigin = ""
+	}
+
+	var rpc GetData
+	rpc.GetData = data
+	rpc.MessageID = newMessageID()
+	return &rpc
+}
+
+// validateNmdaDatastore checks the provided string is a supported NMDA datastore
+func validateNmdaDatastore(datastore string) {
+	switch datastore {
+	case DatastoreRunning, DatastoreCandidate, DatastoreStartup, DatastoreIntended, DatastoreOperational,
+		DatastoreSystem:
+		return
+	}
+	panic(
+		fmt.Errorf(
+			"provided datastore is not valid: %s. Expecting `%s`, `%s`, `%s`, `%s`, `%s` or `%s`", datastore,
+			DatastoreRunning, DatastoreCandidate, DatastoreStartup, DatastoreIntended, DatastoreOperational,
+			DatastoreSystem,
+		),
+	)
+}
+
+// IsNmdaDatastore tells whether the datastore is only defined by NMDA, see RFC 8342: DatastoreIntended,
+// DatastoreOperational and DatastoreSystem. These are read-only and only usable with get-data.
+func IsNmdaDatastore(datastore string) bool {
+	switch datastore {
+	case DatastoreIntended, DatastoreOperational, DatastoreSystem:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,62 @@
+/*
+Copyright 2021. Alexis de Talhouët
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package message
+
+import "fmt"
+
+const (
+	// NetconfMonitoringXmlns is the XMLNS of the NETCONF monitoring YANG module, see RFC 6022
+	NetconfMonitoringXmlns = "urn:ietf:params:xml:ns:yang:ietf-netconf-monitoring"
+	// SchemaFormatYang is the format of the schemas written in YANG
+	SchemaFormatYang = "yang"
+	// SchemaFormatYin is the format of the schemas written in YIN, the XML representation of YANG
+	SchemaFormatYin = "yin"
+)
+
+// GetSchema represents the NETCONF `get-schema` message.
+// https://datatracker.ietf.org/doc/html/rfc6022#section-3.1
+type GetSchema struct {
+	RPC
+	GetSchema GetSchemaData `xml:"get-schema"`
+}
+
+// GetSchemaData is the struct to create a `get-schema` message
+type GetSchemaData struct {
+	XMLNS      string `xml:"xmlns,attr"`
+	Identifier string `xml:"identifier"`
+	Version    string `xml:"version,omitempty"`
+	Format     string `xml:"format,omitempty"`
+}
+
+// NewGetSchema can be used to create a `get-schema` message retrieving the schema with the provided identifier,
+// e.g. a YANG module name. The version, e.g. a YANG module revision, and the format, e.g. SchemaFormatYang, are
+// optional, the server choosing the schema when they are empty.
+func NewGetSchema(identifier string, version string, format string) *GetSchema {
+	if identifier == "" {
+		panic(fmt.Errorf("provided schema identifier is not valid: it must not be empty"))
+	}
+
+	var rpc GetSchema
+	rpc.GetSchema = GetSchemaData{
+		XMLNS:      NetconfMonitoringXmlns,
+		Identifier: identifier,
+		Version:    version,
+		Format:     format,
+	}
+	rpc.MessageID = newMessageID()
+	return &rpc
+}
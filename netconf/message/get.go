@@ -21,23 +21,34 @@ package message
 type Get struct {
 	RPC
 	Get struct {
-		Filter *Filter `xml:"filter"`
+		Filter       *Filter       `xml:"filter"`
+		WithDefaults *WithDefaults `xml:"with-defaults,omitempty"`
 	} `xml:"get"`
 }
 
-// NewGet can be used to create a `get` message.
+// NewGet can be used to create a `get` message. The data is the subtree to select when using the "subtree"
+// filter, and the XPath expression when using the "xpath" one, see NewGetXPath to use prefixes in the expression.
 func NewGet(filterType string, data string) *Get {
 	var rpc Get
 	if data != "" {
-		ValidateXML(data, Filter{})
-		validateFilterType(filterType)
-
-		filter := Filter{
-			Type: filterType,
-			Data: data,
-		}
-		rpc.Get.Filter = &filter
+		rpc.Get.Filter = newFilter(filterType, data)
 	}
-	rpc.MessageID = uuid()
+	rpc.MessageID = newMessageID()
+	return &rpc
+}
+
+// NewGetWithDefaults can be used to create a `get` message reporting the default values following the
+// with-defaults mode, see NewWithDefaults.
+func NewGetWithDefaults(filterType string, data string, mode string) *Get {
+	rpc := NewGet(filterType, data)
+	rpc.Get.WithDefaults = NewWithDefaults(mode)
+	return rpc
+}
+
+// NewGetXPath can be used to create a `get` message filtered by the XPath expression, see NewXPathFilter.
+func NewGetXPath(selectExpr string, namespaces map[string]string) *Get {
+	var rpc Get
+	rpc.Get.Filter = NewXPathFilter(selectExpr, namespaces)
+	rpc.MessageID = newMessageID()
 	return &rpc
 }
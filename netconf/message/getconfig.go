@@ -22,22 +22,36 @@ type GetConfig struct {
 	RPC
 	Source *Datastore `xml:"get-config>source"`
 	Filter *Filter    `xml:"get-config>filter"`
+	// WithDefaults sets how the default values are reported, see NewWithDefaults.
+	WithDefaults *WithDefaults `xml:"get-config>with-defaults,omitempty"`
 }
 
-// NewGetConfig can be used to create a `get-config` message.
+// NewGetConfig can be used to create a `get-config` message. The filterData is the subtree to select when using
+// the "subtree" filter, and the XPath expression when using the "xpath" one.
 func NewGetConfig(datastoreType string, filterType string, filterData string) *GetConfig {
 	var rpc GetConfig
 	if filterData != "" {
-		ValidateXML(filterData, Filter{})
-		validateFilterType(filterType)
-
-		filter := Filter{
-			Type: filterType,
-			Data: filterData,
-		}
-		rpc.Filter = &filter
+		rpc.Filter = newFilter(filterType, filterData)
 	}
 	rpc.Source = datastore(datastoreType)
-	rpc.MessageID = uuid()
+	rpc.MessageID = newMessageID()
+	return &rpc
+}
+
+// NewGetConfigWithDefaults can be used to create a `get-config` message reporting the default values
+// following the with-defaults mode, see NewWithDefaults.
+func NewGetConfigWithDefaults(datastoreType string, filterType string, filterData string, mode string) *GetConfig {
+	rpc := NewGetConfig(datastoreType, filterType, filterData)
+	rpc.WithDefaults = NewWithDefaults(mode)
+	return rpc
+}
+
+// NewGetConfigXPath can be used to create a `get-config` message filtered by the XPath expression,
+// see NewXPathFilter.
+func NewGetConfigXPath(datastoreType string, selectExpr string, namespaces map[string]string) *GetConfig {
+	var rpc GetConfig
+	rpc.Filter = NewXPathFilter(selectExpr, namespaces)
+	rpc.Source = datastore(datastoreType)
+	rpc.MessageID = newMessageID()
 	return &rpc
 }
@@ -25,8 +25,19 @@ type Lock struct {
 
 // NewLock can be used to create a `lock` message.
 func NewLock(datastoreType string) *Lock {
+	return must(NewLockDatastore(datastore(datastoreType)))
+}
+
+// NewLockDatastore can be used to create a `lock` message locking the configuration datastore, see NewDatastore,
+// or the dynamic datastore, see NewDynamicDatastore. It fails when the target is none of them.
+func NewLockDatastore(target *Datastore) (*Lock, error) {
+	err := validateTarget("lock", target, DatastoreRunning, DatastoreCandidate, DatastoreStartup, datastoreDynamic)
+	if err != nil {
+		return nil, err
+	}
+
 	var rpc Lock
-	rpc.Target = datastore(datastoreType)
-	rpc.MessageID = uuid()
-	return &rpc
+	rpc.Target = target
+	rpc.MessageID = newMessageID()
+	return &rpc, nil
 }
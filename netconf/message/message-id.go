@@ -0,0 +1,73 @@
+/*
+Copyright 2021. Alexis de Talhouët
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package message
+
+import (
+	"strconv"
+	"sync/atomic"
+)
+
+// MessageIDGenerator returns the message-id of a new RPC. It must be safe for concurrent use and must not return
+// the same id twice within a session.
+type MessageIDGenerator func() string
+
+// messageIDGenerator is the generator used by the RPC builders, nil meaning UUIDMessageID.
+var messageIDGenerator atomic.Pointer[MessageIDGenerator]
+
+// SetMessageIDGenerator sets the generator of the message-id of the RPCs created by this package, e.g. NewGet.
+// A nil generator restores the default one, UUIDMessageID. See netconf.WithMessageIDGenerator to set it per session.
+func SetMessageIDGenerator(generator MessageIDGenerator) {
+	if generator == nil {
+		messageIDGenerator.Store(nil)
+		return
+	}
+	messageIDGenerator.Store(&generator)
+}
+
+// newMessageID returns the message-id of a new RPC, using the generator set with SetMessageIDGenerator.
+func newMessageID() string {
+	if generator := messageIDGenerator.Load(); generator != nil {
+		return (*generator)()
+	}
+	return uuid()
+}
+
+// UUIDMessageID returns a random UUID, the default message-id.
+func UUIDMessageID() string {
+	return uuid()
+}
+
+// NewCounterMessageID returns a generator of increasing numeric ids, starting at start. The ids are short, for
+// the devices having issues with UUID ids, and deterministic, e.g. for tests.
+func NewCounterMessageID(start uint64) MessageIDGenerator {
+	var counter atomic.Uint64
+	counter.Store(start)
+	return func() string {
+		return strconv.FormatUint(counter.Add(1)-1, 10)
+	}
+}
+
+// NewPrefixMessageID returns a generator prefixing the ids of the provided one, e.g. with a session name to tell
+// the RPCs of several sessions apart. A nil generator defaults to UUIDMessageID.
+func NewPrefixMessageID(prefix string, generator MessageIDGenerator) MessageIDGenerator {
+	if generator == nil {
+		generator = UUIDMessageID
+	}
+	return func() string {
+		return prefix + generator()
+	}
+}
@@ -16,7 +16,13 @@ limitations under the License.
 
 package message
 
-import "encoding/xml"
+import (
+	"encoding/xml"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
 
 const (
 	// NetconfNotificationXmlns is the XMLNS for the YANG model supporting NETCONF notification
@@ -30,7 +36,7 @@ const (
 type Notification struct {
 	XMLName   xml.Name `xml:"notification"`
 	XMLNS     string   `xml:"xmlns,attr"`
-	EventTime string   `xml:"eventTime"`
+	EventTime string   `xml:"eventTime"` // raw value, see Time for the parsed one
 	EventData string   `xml:"eventData,omitempty"`
 	// The ietf-yang-push model cisco is using isn't following rfc8641, hence accommodating here.
 	// https://github.com/YangModels/yang/blob/master/vendor/cisco/xe/1761/ietf-yang-push.yang#L367
@@ -51,6 +57,44 @@ func (notification *Notification) GetSubscriptionID() string {
 	return ""
 }
 
+// Time returns the parsed eventTime of the notification, see ParseEventTime.
+func (notification *Notification) Time() (time.Time, error) {
+	return ParseEventTime(notification.EventTime)
+}
+
+// eventTimeOffset matches the offsets lacking the colon or the minutes, e.g. +0100 or +01
+var eventTimeOffset = regexp.MustCompile(`([+-]\d{2}):?(\d{2})?$`)
+
+// ParseEventTime parses an eventTime, a RFC 3339 date-and-time with or without fractional seconds.
+// Deviations seen on devices are accepted too: a lowercase or space separator, a lowercase `z`, offsets without
+// colon or minutes, and a missing offset, taken as UTC.
+func ParseEventTime(value string) (time.Time, error) {
+	normalized := strings.ToUpper(strings.TrimSpace(value))
+	if len(normalized) > 10 && normalized[10] == ' ' {
+		normalized = normalized[:10] + "T" + normalized[11:]
+	}
+	if t, err := time.Parse(time.RFC3339Nano, normalized); err == nil {
+		return t, nil
+	}
+
+	if date, clock, found := strings.Cut(normalized, "T"); found {
+		if match := eventTimeOffset.FindStringSubmatchIndex(clock); match != nil && match[0] >= 8 {
+			minutes := "00"
+			if match[4] >= 0 {
+				minutes = clock[match[4]:match[5]]
+			}
+			clock = clock[:match[0]] + clock[match[2]:match[3]] + ":" + minutes
+			if t, err := time.Parse(time.RFC3339Nano, date+"T"+clock); err == nil {
+				return t, nil
+			}
+		}
+	}
+	if t, err := time.Parse("2006-01-02T15:04:05.999999999", normalized); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid eventTime %q, expecting a RFC 3339 date-and-time", value)
+}
+
 // NewNotification creates an instance of an Notification based on what was received
 func NewNotification(rawXML []byte) (*Notification, error) {
 	reply := &Notification{}
@@ -63,6 +107,21 @@ func NewNotification(rawXML []byte) (*Notification, error) {
 	return reply, nil
 }
 
+// DecodeNotification decodes the notification element opened by start as it streams in through the decoder.
+// rawXML returns the whole raw message once the element is decoded, see DecodeRPCReply.
+func DecodeNotification(decoder *xml.Decoder, start *xml.StartElement, rawXML func() ([]byte, error)) (*Notification, error) {
+	notification := &Notification{}
+	if err := decoder.DecodeElement(notification, start); err != nil {
+		return nil, err
+	}
+	raw, err := rawXML()
+	if err != nil {
+		return nil, err
+	}
+	notification.RawReply = string(raw)
+	return notification, nil
+}
+
 // CreateSubscription represents the NETCONF `create-subscription` message.
 // https://datatracker.ietf.org/doc/html/rfc5277#section-2.1.1
 type CreateSubscription struct {
@@ -85,7 +144,7 @@ func NewCreateSubscriptionDefault() *CreateSubscription {
 		NetconfNotificationXmlns, "", "", "",
 	}
 	rpc.Subscription = *sub
-	rpc.MessageID = uuid()
+	rpc.MessageID = newMessageID()
 	return &rpc
 }
 
@@ -96,7 +155,7 @@ func NewCreateSubscription(stopTime string, startTime string, stream string) *Cr
 		NetconfNotificationXmlns, stream, startTime, stopTime,
 	}
 	rpc.Subscription = *sub
-	rpc.MessageID = uuid()
+	rpc.MessageID = newMessageID()
 	return &rpc
 }
 
@@ -112,6 +171,6 @@ type EstablishSubscription struct {
 func NewEstablishSubscription(data string) *EstablishSubscription {
 	var rpc EstablishSubscription
 	rpc.Data = data
-	rpc.MessageID = uuid()
+	rpc.MessageID = newMessageID()
 	return &rpc
 }
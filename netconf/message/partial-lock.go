@@ -0,0 +1,96 @@
+/*
+Copyright 2021. Alexis de Talhouët
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package message
+
+import (
+	"encoding/xml"
+	"fmt"
+	"sort"
+)
+
+// NetconfPartialLockXmlns is the XMLNS of the partial lock operations, see RFC 5717
+const NetconfPartialLockXmlns = "urn:ietf:params:xml:ns:netconf:partial-lock:1.0"
+
+// PartialLock represents the NETCONF `partial-lock` message.
+// https://datatracker.ietf.org/doc/html/rfc5717#section-2.4.1
+type PartialLock struct {
+	RPC
+	PartialLock PartialLockData `xml:"partial-lock"`
+}
+
+// PartialLockData is the struct to create a `partial-lock` message
+type PartialLockData struct {
+	XMLNS string `xml:"xmlns,attr"`
+	// Namespaces declares the prefixes used by the selections.
+	Namespaces []xml.Attr `xml:",any,attr"`
+	Select     []string   `xml:"select"`
+}
+
+// NewPartialLock can be used to create a `partial-lock` message, locking the parts of the running datastore
+// selected by the XPath expressions, e.g. /if:interfaces/if:interface[if:name='eth0']. The namespaces map the
+// prefixes used by the expressions to their namespace.
+func NewPartialLock(selects []string, namespaces map[string]string) *PartialLock {
+	if len(selects) == 0 {
+		panic(fmt.Errorf("provided selections are not valid: at least one XPath expression is required"))
+	}
+
+	var rpc PartialLock
+	rpc.PartialLock = PartialLockData{
+		XMLNS:      NetconfPartialLockXmlns,
+		Namespaces: namespaceAttrs(namespaces),
+		Select:     selects,
+	}
+	rpc.MessageID = newMessageID()
+	return &rpc
+}
+
+// PartialUnlock represents the NETCONF `partial-unlock` message.
+// https://datatracker.ietf.org/doc/html/rfc5717#section-2.4.2
+type PartialUnlock struct {
+	RPC
+	PartialUnlock PartialUnlockData `xml:"partial-unlock"`
+}
+
+// PartialUnlockData is the struct to create a `partial-unlock` message
+type PartialUnlockData struct {
+	XMLNS  string `xml:"xmlns,attr"`
+	LockID uint32 `xml:"lock-id"`
+}
+
+// NewPartialUnlock can be used to create a `partial-unlock` message, releasing the lock-id returned by the
+// `partial-lock`.
+func NewPartialUnlock(lockID uint32) *PartialUnlock {
+	var rpc PartialUnlock
+	rpc.PartialUnlock = PartialUnlockData{XMLNS: NetconfPartialLockXmlns, LockID: lockID}
+	rpc.MessageID = newMessageID()
+	return &rpc
+}
+
+// namespaceAttrs returns the attributes declaring the provided prefixes, sorted by prefix.
+func namespaceAttrs(namespaces map[string]string) []xml.Attr {
+	prefixes := make([]string, 0, len(namespaces))
+	for prefix := range namespaces {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Strings(prefixes)
+
+	var attrs []xml.Attr
+	for _, prefix := range prefixes {
+		attrs = append(attrs, xml.Attr{Name: xml.Name{Local: "xmlns:" + prefix}, Value: namespaces[prefix]})
+	}
+	return attrs
+}
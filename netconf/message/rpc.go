@@ -17,8 +17,10 @@ limitations under the License.
 package message
 
 import (
+	"bytes"
 	"encoding/xml"
 	"fmt"
+	"strings"
 )
 
 const RpcReplyRegex = ".*rpc-reply"
@@ -31,9 +33,11 @@ type RPCMethod interface {
 
 // RPC is used as a wrapper for any sent RPC
 type RPC struct {
-	XMLName   xml.Name    `xml:"urn:ietf:params:xml:ns:netconf:base:1.0 rpc"`
-	MessageID string      `xml:"message-id,attr"`
-	Data      interface{} `xml:",innerxml"`
+	XMLName   xml.Name `xml:"urn:ietf:params:xml:ns:netconf:base:1.0 rpc"`
+	MessageID string   `xml:"message-id,attr"`
+	// Attrs are the additional attributes of the rpc element, e.g. vendor attributes or namespace declarations.
+	Attrs []xml.Attr  `xml:",any,attr"`
+	Data  interface{} `xml:",innerxml"`
 }
 
 // GetMessageID returns the message-id of the RPC
@@ -41,42 +45,281 @@ func (rpc *RPC) GetMessageID() string {
 	return rpc.MessageID
 }
 
-// NewRPC formats an RPC message
-func NewRPC(data interface{}) *RPC {
+// SetMessageID replaces the message-id of the RPC, e.g. to use the generator of a session.
+func (rpc *RPC) SetMessageID(id string) {
+	rpc.MessageID = id
+}
+
+// RPCOption customizes the rpc element built by NewRPC.
+type RPCOption func(*RPC)
+
+// WithRPCAttr adds the attribute to the rpc element, e.g. a vendor attribute. The name may be prefixed by a
+// prefix declared with WithRPCNamespace, e.g. junos:format.
+func WithRPCAttr(name string, value string) RPCOption {
+	return func(rpc *RPC) {
+		rpc.Attrs = append(rpc.Attrs, xml.Attr{Name: xml.Name{Local: name}, Value: value})
+	}
+}
+
+// WithRPCNamespace declares the prefix of the namespace on the rpc element, for the body or the attributes to
+// use it.
+func WithRPCNamespace(prefix string, namespace string) RPCOption {
+	return func(rpc *RPC) {
+		rpc.Attrs = append(rpc.Attrs, namespaceAttrs(map[string]string{prefix: namespace})...)
+	}
+}
+
+// NewRPC formats an RPC message. The data is the body of the rpc element: a string or []byte is sent as is, while
+// any other value, e.g. an xml.Marshaler or a struct with xml tags, is marshalled.
+// It panics when the data cannot be marshalled.
+func NewRPC(data interface{}, options ...RPCOption) *RPC {
 	reply := &RPC{}
-	reply.MessageID = uuid()
-	reply.Data = data
+	reply.MessageID = newMessageID()
+	switch data.(type) {
+	case nil, string, []byte:
+		reply.Data = data
+	default:
+		body, err := xml.Marshal(data)
+		if err != nil {
+			panic(fmt.Errorf("provided RPC body is not valid: %w", err))
+		}
+		reply.Data = string(body)
+	}
+	for _, option := range options {
+		option(reply)
+	}
 
 	return reply
 }
 
-// RPCError defines an error reply to a RPC request
+const (
+	// ErrorTypeTransport is the error-type of the errors raised by the secure transport layer
+	ErrorTypeTransport = "transport"
+	// ErrorTypeRPC is the error-type of the errors raised by the messages layer
+	ErrorTypeRPC = "rpc"
+	// ErrorTypeProtocol is the error-type of the errors raised by the operations layer
+	ErrorTypeProtocol = "protocol"
+	// ErrorTypeApplication is the error-type of the errors raised by the content layer
+	ErrorTypeApplication = "application"
+
+	// ErrorSeverityError is the error-severity of the errors
+	ErrorSeverityError = "error"
+	// ErrorSeverityWarning is the error-severity of the warnings
+	ErrorSeverityWarning = "warning"
+)
+
+// The error-tag values, see https://datatracker.ietf.org/doc/html/rfc6241#appendix-A
+const (
+	ErrorTagInUse                 = "in-use"
+	ErrorTagInvalidValue          = "invalid-value"
+	ErrorTagTooBig                = "too-big"
+	ErrorTagMissingAttribute      = "missing-attribute"
+	ErrorTagBadAttribute          = "bad-attribute"
+	ErrorTagUnknownAttribute      = "unknown-attribute"
+	ErrorTagMissingElement        = "missing-element"
+	ErrorTagBadElement            = "bad-element"
+	ErrorTagUnknownElement        = "unknown-element"
+	ErrorTagUnknownNamespace      = "unknown-namespace"
+	ErrorTagAccessDenied          = "access-denied"
+	ErrorTagLockDenied            = "lock-denied"
+	ErrorTagResourceDenied        = "resource-denied"
+	ErrorTagRollbackFailed        = "rollback-failed"
+	ErrorTagDataExists            = "data-exists"
+	ErrorTagDataMissing           = "data-missing"
+	ErrorTagOperationNotSupported = "operation-not-supported"
+	ErrorTagOperationFailed       = "operation-failed"
+	ErrorTagPartialOperation      = "partial-operation"
+	ErrorTagMalformedMessage      = "malformed-message"
+)
+
+// RPCError defines an error reply to a RPC request.
+// Errors returned for the rpc-errors of a reply wrap them, see RPCReply.Err, so callers can branch on the
+// error-tag using errors.As:
+//
+//	var rpcErr *message.RPCError
+//	if errors.As(err, &rpcErr) && rpcErr.Tag == message.ErrorTagLockDenied {
+//		...
+//	}
 type RPCError struct {
 	Type     string `xml:"error-type"`
 	Tag      string `xml:"error-tag"`
 	Severity string `xml:"error-severity"`
+	AppTag   string `xml:"error-app-tag"`
 	Path     string `xml:"error-path"`
-	Message  string `xml:"error-message"`
-	Info     string `xml:",innerxml"`
+	// PathNamespaces maps the prefixes in scope of the error-path to their namespace, to resolve the prefixes used
+	// by the XPath expression.
+	PathNamespaces map[string]string `xml:"-"`
+	Message        string            `xml:"error-message"`
+	// ErrorInfo is the content of the error-info element, nil when there is none.
+	ErrorInfo *ErrorInfo `xml:"error-info"`
+	Info      string     `xml:",innerxml"`
+}
+
+// ErrorInfo holds the protocol or data-model specific details of a rpc-error.
+// https://datatracker.ietf.org/doc/html/rfc6241#appendix-A
+type ErrorInfo struct {
+	BadAttribute string `xml:"bad-attribute"`
+	BadElement   string `xml:"bad-element"`
+	BadNamespace string `xml:"bad-namespace"`
+	// SessionID is the session holding the lock for lock-denied errors, zero when held by a non-NETCONF entity.
+	SessionID    uint32   `xml:"session-id"`
+	OkElements   []string `xml:"ok-element"`
+	ErrElements  []string `xml:"err-element"`
+	NoopElements []string `xml:"noop-element"`
+	// Content is the raw content of the error-info, including the data-model specific elements.
+	Content string `xml:",innerxml"`
+}
+
+// UnmarshalXML decodes the rpc-error, collecting the namespaces declared on the error and its error-path.
+func (re *RPCError) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	type rpcError RPCError
+	var raw struct {
+		rpcError
+		ErrorPath *struct {
+			Attrs []xml.Attr `xml:",any,attr"`
+			Path  string     `xml:",chardata"`
+		} `xml:"error-path"`
+	}
+	if err := d.DecodeElement(&raw, &start); err != nil {
+		return err
+	}
+	*re = RPCError(raw.rpcError)
+	if raw.ErrorPath == nil {
+		return nil
+	}
+	re.Path = strings.TrimSpace(raw.ErrorPath.Path)
+	re.PathNamespaces = namespaceDeclarations(re.PathNamespaces, start.Attr)
+	re.PathNamespaces = namespaceDeclarations(re.PathNamespaces, raw.ErrorPath.Attrs)
+	return nil
+}
+
+// namespaceDeclarations adds the namespaces declared by the attributes to the provided prefixes, the default
+// namespace using the empty prefix.
+func namespaceDeclarations(namespaces map[string]string, attrs []xml.Attr) map[string]string {
+	for _, attr := range attrs {
+		prefix := ""
+		switch {
+		case attr.Name.Space == "xmlns":
+			prefix = attr.Name.Local
+		case attr.Name.Space == "" && attr.Name.Local == "xmlns":
+		default:
+			continue
+		}
+		if namespaces == nil {
+			namespaces = make(map[string]string)
+		}
+		namespaces[prefix] = attr.Value
+	}
+	return namespaces
 }
 
 // Error generates a string representation of the provided RPC error
 func (re *RPCError) Error() string {
+	if re.Message == "" {
+		return fmt.Sprintf("netconf rpc [%s] '%s'", re.Severity, re.Tag)
+	}
 	return fmt.Sprintf("netconf rpc [%s] '%s'", re.Severity, re.Message)
 }
 
+// Is tells whether the error matches the target RPCError, whose empty fields match any value, so
+// errors.Is(err, &message.RPCError{Tag: message.ErrorTagInUse}) tells whether any rpc-error is in-use.
+func (re *RPCError) Is(target error) bool {
+	t, ok := target.(*RPCError)
+	if !ok {
+		return false
+	}
+	return (t.Type == "" || t.Type == re.Type) &&
+		(t.Tag == "" || t.Tag == re.Tag) &&
+		(t.Severity == "" || t.Severity == re.Severity)
+}
+
+// IsWarning tells whether the error is a warning, i.e. the operation succeeded despite it.
+func (re *RPCError) IsWarning() bool {
+	return re.Severity == ErrorSeverityWarning
+}
+
+// RPCErrors are the rpc-errors of a reply, as an error wrapping each of them.
+type RPCErrors []RPCError
+
+// Error joins the representation of the errors
+func (errs RPCErrors) Error() string {
+	messages := make([]string, 0, len(errs))
+	for i := range errs {
+		messages = append(messages, errs[i].Error())
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Unwrap returns the errors, for errors.Is and errors.As
+func (errs RPCErrors) Unwrap() []error {
+	wrapped := make([]error, 0, len(errs))
+	for i := range errs {
+		wrapped = append(wrapped, &errs[i])
+	}
+	return wrapped
+}
+
 // RPCReply defines a reply to a RPC request
 type RPCReply struct {
 	XMLName   xml.Name   `xml:"rpc-reply"` //urn:ietf:params:xml:ns:netconf:base:1.0
 	MessageID string     `xml:"message-id,attr"`
 	Errors    []RPCError `xml:"rpc-error,omitempty"`
 	Data      string     `xml:",innerxml"`
-	Ok        bool       `xml:"ok,omitempty"`
-	RawReply  string     `xml:"-"`
+	// OkReply is the ok element of the reply, nil when the reply carries none.
+	OkReply *OkReply `xml:"ok"`
+	// Ok tells whether the reply carries the ok element, see OK.
+	Ok       bool   `xml:"-"`
+	RawReply string `xml:"-"`
 	// this is in the case we are receiving a reply to a NETCONF notification subscription
 	SubscriptionID string `xml:"subscription-id,omitempty"`
 }
 
+// OkReply is the ok element of a reply, sent when an operation without output succeeds.
+// It is matched whatever its namespace prefix and content, e.g. <ok/>, <nc:ok/> or <ok></ok>.
+type OkReply struct {
+	XMLName xml.Name `xml:"ok"`
+}
+
+// OK tells whether the reply carries the ok element and no rpc-error of the error severity, i.e. the operation
+// succeeded, possibly with warnings.
+func (reply *RPCReply) OK() bool {
+	return reply.OkReply != nil && reply.FatalErr() == nil
+}
+
+// Warnings returns the rpc-errors of the warning severity, reported while the operation succeeded.
+func (reply *RPCReply) Warnings() []RPCError {
+	var warnings []RPCError
+	for i := range reply.Errors {
+		if reply.Errors[i].IsWarning() {
+			warnings = append(warnings, reply.Errors[i])
+		}
+	}
+	return warnings
+}
+
+// FatalErr returns the rpc-errors of the error severity as an error, see RPCErrors, nil when there is none.
+// Unlike Err, it ignores the warnings.
+func (reply *RPCReply) FatalErr() error {
+	var errs RPCErrors
+	for i := range reply.Errors {
+		if !reply.Errors[i].IsWarning() {
+			errs = append(errs, reply.Errors[i])
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// Err returns the rpc-errors of the reply as an error, see RPCErrors, nil when there is none.
+func (reply *RPCReply) Err() error {
+	if len(reply.Errors) == 0 {
+		return nil
+	}
+	return RPCErrors(reply.Errors)
+}
+
 // NewRPCReply creates an instance of an RPCReply based on what was received
 func NewRPCReply(rawXML []byte) (*RPCReply, error) {
 	reply := &RPCReply{}
@@ -85,6 +328,55 @@ func NewRPCReply(rawXML []byte) (*RPCReply, error) {
 	if err := xml.Unmarshal(rawXML, reply); err != nil {
 		return nil, err
 	}
+	reply.Ok = reply.OkReply != nil
+	if len(reply.Errors) != 0 {
+		reply.inheritPathNamespaces(rawXML)
+	}
 
 	return reply, nil
 }
+
+// DecodeRPCReply decodes the rpc-reply element opened by start as it streams in through the decoder.
+// rawXML returns the whole raw message once the element is decoded, e.g. from a buffer the input of the
+// decoder is teed into, for the RawReply of the reply.
+func DecodeRPCReply(decoder *xml.Decoder, start *xml.StartElement, rawXML func() ([]byte, error)) (*RPCReply, error) {
+	reply := &RPCReply{}
+	if err := decoder.DecodeElement(reply, start); err != nil {
+		return nil, err
+	}
+	raw, err := rawXML()
+	if err != nil {
+		return nil, err
+	}
+	reply.RawReply = string(raw)
+	reply.Ok = reply.OkReply != nil
+	if len(reply.Errors) != 0 {
+		reply.inheritPathNamespaces(raw)
+	}
+	return reply, nil
+}
+
+// inheritPathNamespaces adds the namespaces declared on the rpc-reply element to the ones in scope of the
+// error-path of its errors.
+func (reply *RPCReply) inheritPathNamespaces(rawXML []byte) {
+	decoder := xml.NewDecoder(bytes.NewReader(rawXML))
+	for {
+		token, err := decoder.RawToken()
+		if err != nil {
+			return
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			for i := range reply.Errors {
+				if reply.Errors[i].Path == "" {
+					continue
+				}
+				inherited := namespaceDeclarations(make(map[string]string), start.Attr)
+				for prefix, namespace := range reply.Errors[i].PathNamespaces {
+					inherited[prefix] = namespace
+				}
+				reply.Errors[i].PathNamespaces = inherited
+			}
+			return
+		}
+	}
+}
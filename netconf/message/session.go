@@ -27,7 +27,7 @@ type CloseSession struct {
 func NewCloseSession() *CloseSession {
 	var rpc CloseSession
 	rpc.CloseSession = ""
-	rpc.MessageID = uuid()
+	rpc.MessageID = newMessageID()
 	return &rpc
 }
 
@@ -42,6 +42,6 @@ type KillSession struct {
 func NewKillSession(sessionID string) *KillSession {
 	var rpc KillSession
 	rpc.SessionID = sessionID
-	rpc.MessageID = uuid()
+	rpc.MessageID = newMessageID()
 	return &rpc
 }
@@ -0,0 +1,128 @@
+/*
+Copyright 2021. Alexis de Talhouët
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package message
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+)
+
+// SubtreeNode is a node of a subtree filter, see https://datatracker.ietf.org/doc/html/rfc6241#section-6.2.
+// A node without children nor content is a selection node, one with content is a content match node, and one
+// with children is a containment node. Its attributes are attribute match expressions.
+//
+// For example, selecting the name and the mtu of the interface eth0:
+//
+//	filter := NewSubtree("urn:ietf:params:xml:ns:yang:ietf-interfaces", "interfaces")
+//	filter.Container("interface").Match("name", "eth0").Select("mtu")
+//	NewGetConfig(DatastoreRunning, FilterTypeSubtree, SubtreeFilter(filter))
+type SubtreeNode struct {
+	Name string
+	// Namespace is the namespace of the node, declared when it differs from the one of its parent.
+	Namespace string
+	Attrs     []xml.Attr
+	// Content is the value the content match node must match, nil for the other nodes.
+	Content  *string
+	Children []*SubtreeNode
+}
+
+// NewSubtree returns the top node of a subtree filter, in the namespace of its YANG module.
+func NewSubtree(namespace string, name string) *SubtreeNode {
+	validateNodeName(name)
+	return &SubtreeNode{Name: name, Namespace: namespace}
+}
+
+// Container adds a containment node, in the namespace of this node, and returns it.
+func (node *SubtreeNode) Container(name string) *SubtreeNode {
+	child := NewSubtree(node.Namespace, name)
+	node.Children = append(node.Children, child)
+	return child
+}
+
+// Add adds the provided nodes as children, e.g. a node of an augmenting module with its own namespace.
+func (node *SubtreeNode) Add(children ...*SubtreeNode) *SubtreeNode {
+	node.Children = append(node.Children, children...)
+	return node
+}
+
+// Select adds selection nodes, selecting the elements and their whole subtree.
+func (node *SubtreeNode) Select(names ...string) *SubtreeNode {
+	for _, name := range names {
+		node.Container(name)
+	}
+	return node
+}
+
+// Match adds a content match node, selecting the siblings of the elements whose content equals the value.
+func (node *SubtreeNode) Match(name string, value string) *SubtreeNode {
+	node.Container(name).Content = &value
+	return node
+}
+
+// MatchAttr adds an attribute match expression, selecting the elements whose attribute equals the value.
+func (node *SubtreeNode) MatchAttr(name string, value string) *SubtreeNode {
+	validateNodeName(name)
+	node.Attrs = append(node.Attrs, xml.Attr{Name: xml.Name{Local: name}, Value: value})
+	return node
+}
+
+// String returns the XML encoding of the node.
+func (node *SubtreeNode) String() string {
+	return SubtreeFilter(node)
+}
+
+// SubtreeFilter returns the XML encoding of the nodes, the data of a "subtree" filter, e.g. for NewGet.
+func SubtreeFilter(nodes ...*SubtreeNode) string {
+	var buf bytes.Buffer
+	encoder := xml.NewEncoder(&buf)
+	for _, node := range nodes {
+		// the nodes only hold names, attributes and text, which always encode
+		_ = node.encode(encoder, "")
+	}
+	_ = encoder.Flush()
+	return buf.String()
+}
+
+// encode writes the node, declaring its namespace when it differs from the one of its parent.
+func (node *SubtreeNode) encode(encoder *xml.Encoder, parentNamespace string) error {
+	start := xml.StartElement{Name: xml.Name{Local: node.Name}}
+	if node.Namespace != parentNamespace {
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "xmlns"}, Value: node.Namespace})
+	}
+	start.Attr = append(start.Attr, node.Attrs...)
+	if err := encoder.EncodeToken(start); err != nil {
+		return err
+	}
+	if node.Content != nil {
+		if err := encoder.EncodeToken(xml.CharData(*node.Content)); err != nil {
+			return err
+		}
+	}
+	for _, child := range node.Children {
+		if err := child.encode(encoder, node.Namespace); err != nil {
+			return err
+		}
+	}
+	return encoder.EncodeToken(start.End())
+}
+
+func validateNodeName(name string) {
+	if name == "" {
+		panic(fmt.Errorf("provided subtree filter node is not valid: its name must not be empty"))
+	}
+}
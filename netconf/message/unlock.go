@@ -25,8 +25,19 @@ type Unlock struct {
 
 // NewUnlock can be used to create a `unlock` message.
 func NewUnlock(datastoreType string) *Unlock {
+	return must(NewUnlockDatastore(datastore(datastoreType)))
+}
+
+// NewUnlockDatastore can be used to create a `unlock` message unlocking the configuration datastore,
+// see NewDatastore, or the dynamic datastore, see NewDynamicDatastore. It fails when the target is none of them.
+func NewUnlockDatastore(target *Datastore) (*Unlock, error) {
+	err := validateTarget("unlock", target, DatastoreRunning, DatastoreCandidate, DatastoreStartup, datastoreDynamic)
+	if err != nil {
+		return nil, err
+	}
+
 	var rpc Unlock
-	rpc.Target = datastore(datastoreType)
-	rpc.MessageID = uuid()
-	return &rpc
+	rpc.Target = target
+	rpc.MessageID = newMessageID()
+	return &rpc, nil
 }
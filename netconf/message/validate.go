@@ -23,10 +23,29 @@ type Validate struct {
 	Source *Datastore `xml:"validate>source"`
 }
 
-// NewValidate can be used to create a `lock` message.
+// NewValidate can be used to create a `validate` message.
 func NewValidate(datastoreType string) *Validate {
+	return must(NewValidateDatastore(datastore(datastoreType)))
+}
+
+// NewValidateConfig can be used to create a `validate` message checking the provided configuration, instead of
+// a datastore.
+func NewValidateConfig(data string) *Validate {
+	return must(NewValidateDatastore(inlineConfig(data)))
+}
+
+// NewValidateDatastore can be used to create a `validate` message checking any datastore, see NewDatastore,
+// NewURLDatastore and NewInlineDatastore. It fails when the source is none of them, e.g. a dynamic datastore.
+func NewValidateDatastore(source *Datastore) (*Validate, error) {
+	err := validateTarget(
+		"validate", source, DatastoreRunning, DatastoreCandidate, DatastoreStartup, datastoreURL, datastoreConfig,
+	)
+	if err != nil {
+		return nil, err
+	}
+
 	var rpc Validate
-	rpc.Source = datastore(datastoreType)
-	rpc.MessageID = uuid()
-	return &rpc
+	rpc.Source = source
+	rpc.MessageID = newMessageID()
+	return &rpc, nil
 }
@@ -0,0 +1,63 @@
+/*
+Copyright 2021. Alexis de Talhouët
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package message
+
+import "fmt"
+
+const (
+	// NetconfWithDefaultsXmlns is the XMLNS of the with-defaults parameter, see RFC 6243
+	NetconfWithDefaultsXmlns = "urn:ietf:params:xml:ns:yang:ietf-netconf-with-defaults"
+	// WithDefaultsReportAll reports all the data nodes, including the ones set to their default value
+	WithDefaultsReportAll = "report-all"
+	// WithDefaultsReportAllTagged reports all the data nodes, tagging the ones set to their default value
+	WithDefaultsReportAllTagged = "report-all-tagged"
+	// WithDefaultsTrim omits the data nodes set to their default value
+	WithDefaultsTrim = "trim"
+	// WithDefaultsExplicit reports the data nodes explicitly set, even to their default value
+	WithDefaultsExplicit = "explicit"
+)
+
+// WithDefaults represents the `with-defaults` parameter of the retrieval operations.
+// https://datatracker.ietf.org/doc/html/rfc6243#section-4.5
+type WithDefaults struct {
+	XMLNS string `xml:"xmlns,attr"`
+	Mode  string `xml:",chardata"`
+}
+
+// NewWithDefaults returns the with-defaults parameter for the provided mode, e.g. WithDefaultsReportAll.
+// An empty mode returns nil, keeping the server basic mode.
+func NewWithDefaults(mode string) *WithDefaults {
+	if mode == "" {
+		return nil
+	}
+	validateWithDefaults(mode)
+	return &WithDefaults{XMLNS: NetconfWithDefaultsXmlns, Mode: mode}
+}
+
+// validateWithDefaults checks the provided string is a supported with-defaults mode
+func validateWithDefaults(mode string) {
+	switch mode {
+	case WithDefaultsReportAll, WithDefaultsReportAllTagged, WithDefaultsTrim, WithDefaultsExplicit:
+		return
+	}
+	panic(
+		fmt.Errorf(
+			"provided with-defaults mode is not valid: %s. Expecting `%s`, `%s`, `%s` or `%s`", mode,
+			WithDefaultsReportAll, WithDefaultsReportAllTagged, WithDefaultsTrim, WithDefaultsExplicit,
+		),
+	)
+}
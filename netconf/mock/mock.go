@@ -0,0 +1,218 @@
+/*
+Copyright 2021. Alexis de Talhouët
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package mock provides an in-memory NETCONF server transport, to test code using the netconf package without
+// any device. The transport exchanges messages without framing, and is safe for concurrent use.
+package mock
+
+import (
+	"fmt"
+	"io"
+	"math/rand"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// DefaultHello is the hello sent by the mock server unless configured with WithHello.
+const DefaultHello = `<hello xmlns="urn:ietf:params:xml:ns:netconf:base:1.0"><capabilities>` +
+	`<capability>urn:ietf:params:netconf:base:1.0</capability>` +
+	`<capability>urn:ietf:params:netconf:base:1.1</capability>` +
+	`</capabilities><session-id>1</session-id></hello>`
+
+var messageIDRegex = regexp.MustCompile(`message-id="([^"]*)"`)
+
+// Handler computes the reply of the mock server to an RPC, given its message-id. A nil reply sends nothing.
+type Handler func(messageID string, request []byte) []byte
+
+// Option allow optional configuration of the mock transport.
+type Option func(*Transport)
+
+// WithHello sets the hello sent by the server when the transport is created.
+func WithHello(hello string) Option {
+	return func(t *Transport) {
+		t.hello = hello
+	}
+}
+
+// WithHandler sets the handler computing the replies, ReplyOK by default.
+func WithHandler(handler Handler) Option {
+	return func(t *Transport) {
+		t.handler = handler
+	}
+}
+
+// ReplyOK replies <ok/> to every RPC.
+func ReplyOK(messageID string, request []byte) []byte {
+	return Reply(messageID, "<ok/>")
+}
+
+// ReplyData returns a handler replying the provided data to every RPC.
+func ReplyData(data string) Handler {
+	return func(messageID string, request []byte) []byte {
+		return Reply(messageID, "<data>"+data+"</data>")
+	}
+}
+
+// NoReply never replies, simulating an unresponsive server.
+func NoReply(messageID string, request []byte) []byte {
+	return nil
+}
+
+// Reply returns an rpc-reply message with the provided content.
+func Reply(messageID string, content string) []byte {
+	return []byte(fmt.Sprintf(
+		`<rpc-reply xmlns="urn:ietf:params:xml:ns:netconf:base:1.0" message-id="%s">%s</rpc-reply>`, messageID, content,
+	))
+}
+
+// Transport is the client side of an in-memory connection to a mock NETCONF server.
+type Transport struct {
+	lock     sync.Mutex
+	hello    string
+	handler  Handler
+	queue    []delivery
+	requests [][]byte
+	closed   bool
+	profile  Profile
+	random   *rand.Rand
+	// last is the time the latest queued message can be received
+	last time.Time
+	// ready is signaled when a message is queued or the transport is closed
+	ready chan struct{}
+}
+
+// delivery is a message of the mock server, queued until it can be received.
+type delivery struct {
+	data []byte
+	err  error
+	at   time.Time
+}
+
+// NewTransport creates a mock transport whose server already sent its hello.
+func NewTransport(options ...Option) *Transport {
+	t := &Transport{hello: DefaultHello, handler: ReplyOK, ready: make(chan struct{}, 1)}
+	for _, opt := range options {
+		opt(t)
+	}
+	t.push([]byte(t.hello), false)
+	return t
+}
+
+// Send delivers a message to the mock server, which replies to RPCs using its handler.
+func (t *Transport) Send(data []byte) error {
+	t.lock.Lock()
+	if t.closed {
+		t.lock.Unlock()
+		return io.ErrClosedPipe
+	}
+	t.requests = append(t.requests, append([]byte(nil), data...))
+	handler := t.handler
+	t.lock.Unlock()
+
+	match := messageIDRegex.FindSubmatch(data)
+	if match == nil {
+		// the client hello, or a message the server does not reply to
+		return nil
+	}
+	if reply := handler(string(match[1]), data); reply != nil {
+		t.push(reply, true)
+	}
+	return nil
+}
+
+// Receive returns the next message sent by the mock server, blocking until there is one.
+// It returns io.EOF once the transport is closed and the messages already sent are received.
+func (t *Transport) Receive() ([]byte, error) {
+	for {
+		t.lock.Lock()
+		if t.closed && len(t.queue) == 0 {
+			t.lock.Unlock()
+			return nil, io.EOF
+		}
+		if len(t.queue) == 0 {
+			t.lock.Unlock()
+			<-t.ready
+			continue
+		}
+		next := t.queue[0]
+		if wait := time.Until(next.at); wait > 0 && !t.closed {
+			t.lock.Unlock()
+			select {
+			case <-t.ready:
+			case <-time.After(wait):
+			}
+			continue
+		}
+		t.queue = t.queue[1:]
+		t.lock.Unlock()
+		return next.data, next.err
+	}
+}
+
+// Close closes the transport, unblocking any pending Receive.
+func (t *Transport) Close() error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.closed {
+		return io.ErrClosedPipe
+	}
+	t.closed = true
+	t.signal()
+	return nil
+}
+
+// SetVersion is a no-op, as messages are exchanged without framing.
+func (t *Transport) SetVersion(version string) {}
+
+// Notify sends a notification from the mock server.
+func (t *Transport) Notify(notification string) {
+	t.push([]byte(notification), false)
+}
+
+// Requests returns the messages received by the mock server, in order, the client hello included.
+func (t *Transport) Requests() [][]byte {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return append([][]byte(nil), t.requests...)
+}
+
+// push queues a message from the mock server, applying the profile when it is a reply.
+func (t *Transport) push(data []byte, reply bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.closed {
+		return
+	}
+	at, dropped, corrupted := t.schedule(reply)
+	switch {
+	case dropped:
+		return
+	case corrupted:
+		t.queue = append(t.queue, delivery{err: malformed, at: at})
+	default:
+		t.queue = append(t.queue, delivery{data: data, at: at})
+	}
+	t.signal()
+}
+
+// signal wakes up a pending Receive. The lock must be held.
+func (t *Transport) signal() {
+	select {
+	case t.ready <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2021. Alexis de Talhouët
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mock
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/openshift-telco/go-netconf-client/netconf"
+)
+
+// Profile simulates the network and device conditions affecting the replies of the mock server.
+// The zero value delivers every reply right away.
+type Profile struct {
+	// Latency is the minimum delay before a reply is received.
+	Latency time.Duration
+	// Jitter is the maximum random delay added to the latency, uniformly distributed.
+	// Replies are still received in order, as on a NETCONF session.
+	Jitter time.Duration
+	// DropRate is the probability, between 0 and 1, that a reply is never sent.
+	DropRate float64
+	// MalformedRate is the probability, between 0 and 1, that a reply is received as a message with an invalid
+	// framing, which the transport drops before resynchronizing on the next message.
+	MalformedRate float64
+	// Seed initializes the random source, so a simulation can be replayed. Zero uses a time based seed.
+	Seed int64
+}
+
+var (
+	// ProfileLAN simulates a device on the local network.
+	ProfileLAN = Profile{Latency: time.Millisecond, Jitter: time.Millisecond}
+	// ProfileWAN simulates a remote device.
+	ProfileWAN = Profile{Latency: 50 * time.Millisecond, Jitter: 30 * time.Millisecond}
+	// ProfileLossy simulates a remote device over an unreliable link, losing and corrupting some replies.
+	ProfileLossy = Profile{Latency: 50 * time.Millisecond, Jitter: 100 * time.Millisecond, DropRate: 0.05, MalformedRate: 0.05}
+)
+
+// WithProfile sets the conditions simulated for the replies of the server, see Profile.
+// Notifications are delayed alike, but never dropped nor corrupted.
+func WithProfile(profile Profile) Option {
+	return func(t *Transport) {
+		seed := profile.Seed
+		if seed == 0 {
+			seed = time.Now().UnixNano()
+		}
+		t.profile = profile
+		t.random = rand.New(rand.NewSource(seed))
+	}
+}
+
+// malformed is the error received in place of a corrupted reply.
+var malformed = &netconf.FramingError{Err: netconf.ErrBadChunk, Reason: "simulated malformed frame", Recovered: true}
+
+// schedule returns when a message can be received given the profile, and whether it is dropped or corrupted.
+// The lock must be held.
+func (t *Transport) schedule(reply bool) (at time.Time, dropped bool, corrupted bool) {
+	at = time.Now()
+	if t.random == nil {
+		return at, false, false
+	}
+	at = at.Add(t.profile.Latency)
+	if t.profile.Jitter > 0 {
+		at = at.Add(time.Duration(t.random.Int63n(int64(t.profile.Jitter))))
+	}
+	// messages are received in order
+	if at.Before(t.last) {
+		at = t.last
+	}
+	t.last = at
+	if reply {
+		dropped = t.random.Float64() < t.profile.DropRate
+		corrupted = !dropped && t.random.Float64() < t.profile.MalformedRate
+	}
+	return at, dropped, corrupted
+}
@@ -17,21 +17,96 @@ limitations under the License.
 package netconf
 
 import (
+	"bytes"
+	"context"
 	"encoding/xml"
 	"errors"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/openshift-telco/go-netconf-client/netconf/message"
 )
 
+// RPCTimeoutError is the error of the event an AsyncRPC callback is invoked with when no reply was received
+// in time. It matches context.DeadlineExceeded, as the SyncRPC timeouts do.
+type RPCTimeoutError struct {
+	MessageID string
+	// Elapsed is the time waited for the reply.
+	Elapsed time.Duration
+}
+
+// Error generates a string representation of the timeout error
+func (e *RPCTimeoutError) Error() string {
+	return fmt.Sprintf("timeout while executing request %s: no reply after %v", e.MessageID, e.Elapsed.Round(time.Millisecond))
+}
+
+// Unwrap returns context.DeadlineExceeded
+func (e *RPCTimeoutError) Unwrap() error {
+	return context.DeadlineExceeded
+}
+
+// expireRPCs starts a goroutine invoking the AsyncRPC callbacks whose reply was not received in time, until the
+// session stops receiving messages.
+func (session *Session) expireRPCs() {
+	ticker := time.NewTicker(expiryInterval)
+	stop := make(chan struct{})
+	session.onClose(func() { close(stop) })
+	reason := func(messageID string, registered time.Time) error {
+		err := &RPCTimeoutError{MessageID: messageID, Elapsed: time.Since(registered)}
+		session.logger.Warn("RPC timed out", session.logArgs("message-id", messageID, "err", err)...)
+		return err
+	}
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case now := <-ticker.C:
+				session.Listener.expire(session.callbacks, now, reason)
+			}
+		}
+	}()
+}
+
+// ErrSessionClosed is matched by the errors of the RPCs sent on a session which stopped receiving messages,
+// see SessionClosedError.
+var ErrSessionClosed = errors.New("netconf session closed")
+
+// SessionClosedError is returned by the RPCs pending, or sent, once the session stopped receiving messages.
+type SessionClosedError struct {
+	SessionID uint32
+	// Err is why the session was closed: io.EOF when closed by the server, e.g. on a kill-session from another
+	// client, the error failing the session, e.g. a *KeepaliveError, or nil when closed by the client.
+	Err error
+}
+
+// Error generates a string representation of the closed session error
+func (e *SessionClosedError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("netconf session %d closed", e.SessionID)
+	}
+	return fmt.Sprintf("netconf session %d closed: %s", e.SessionID, e.Err)
+}
+
+// Is matches ErrSessionClosed
+func (e *SessionClosedError) Is(target error) bool {
+	return target == ErrSessionClosed
+}
+
+// Unwrap returns why the session was closed
+func (e *SessionClosedError) Unwrap() error {
+	return e.Err
+}
+
 // CreateNotificationStream is a convenient method to create a notification stream registration.
 // TODO limitation - for now, we can only register one stream per session, because when a notification is received
 // there is no way to attribute it to a specific stream
 func (session *Session) CreateNotificationStream(
 	timeout int32, stopTime string, startTime string, stream string, callback Callback,
 ) error {
-	if session.IsNotificationStreamCreated {
+	if !session.notificationStream.CompareAndSwap(false, true) {
 		return fmt.Errorf(
 			"there is already an active notification stream subscription. " +
 				"A session can only support one notification stream at the time",
@@ -40,36 +115,117 @@ func (session *Session) CreateNotificationStream(
 	session.Listener.Register(message.NetconfNotificationStreamHandler, callback)
 	sub := message.NewCreateSubscription(stopTime, startTime, stream)
 	rpc, err := session.SyncRPC(sub, timeout)
-	if err != nil || len(rpc.Errors) != 0 {
-		return fmt.Errorf("fail to create notification stream with errors: %s. Error: %w", rpc.Errors, err)
+	if err != nil {
+		session.notificationStream.Store(false)
+		return fmt.Errorf("fail to create notification stream: %w", err)
+	}
+	if err := session.replyErr(rpc); err != nil {
+		session.notificationStream.Store(false)
+		return fmt.Errorf("fail to create notification stream with errors: %w", err)
 	}
-	session.IsNotificationStreamCreated = true
 	return nil
 }
 
 // AsyncRPC is used to send an RPC method and receive the response asynchronously.
+// When no reply is received within the session default timeout, see WithRPCTimeout, the callback is invoked
+// with an event whose Err is a *RPCTimeoutError.
 func (session *Session) AsyncRPC(operation message.RPCMethod, callback Callback) error {
+	return session.AsyncRPCTimeout(operation, callback, 0)
+}
+
+// AsyncRPCTimeout is used to send an RPC method and receive the response asynchronously, invoking the callback
+// with an event whose Err is a *RPCTimeoutError when no reply is received within the timeout, in seconds.
+// A non-positive timeout uses the session default timeout, see WithRPCTimeout.
+func (session *Session) AsyncRPCTimeout(operation message.RPCMethod, callback Callback, timeout int32) error {
+	duration := time.Duration(timeout) * time.Second
+	if timeout <= 0 {
+		duration = session.rpcTimeout
+	}
 
 	// get XML payload
+	session.assignMessageID(operation)
 	request, err := marshall(operation)
 	if err != nil {
 		return err
 	}
 
-	// register the listener for the message
-	session.Listener.Register(operation.GetMessageID(), callback)
+	// wait for an outstanding RPC slot, within the timeout
+	name := operationName(request)
+	ctx, cancel := context.WithTimeout(context.Background(), duration)
+	release, err := session.acquireInFlight(ctx)
+	if err == nil {
+		if err = session.awaitInterleave(ctx, name); err != nil {
+			release()
+		}
+	}
+	cancel()
+	if err != nil {
+		session.metrics.failed(name)
+		return err
+	}
+	// the callback may be invoked while a failed send is handled
+	release = sync.OnceFunc(release)
+
+	// register the listener for the message, recording its outcome
+	sentAt := time.Now()
+	session.Listener.registerUntil(operation.GetMessageID(), func(event Event) {
+		release()
+		if reply := event.RPCReply(); reply != nil {
+			session.metrics.replied(name, time.Since(sentAt), session.replyErr(reply) != nil)
+			if name == "create-subscription" && session.replyErr(reply) == nil {
+				session.subscribed()
+			}
+		} else {
+			session.metrics.failed(name)
+		}
+		callback(event)
+	}, sentAt.Add(duration))
 
-	session.logger.Info("Sending RPC")
-	err = session.Transport.Send(request)
+	session.logger.Info("Sending RPC", session.rpcLogArgs(operation, request)...)
+	err = session.send(request)
 	if err != nil {
+		session.Listener.Remove(operation.GetMessageID())
+		release()
+		session.metrics.failed(name)
 		return err
 	}
+	session.metrics.sent(name)
 
 	return nil
 }
 
-// SyncRPC is used to execute an RPC method and receive the response synchronously
+// SyncRPC is used to execute an RPC method and receive the response synchronously.
+// A non-positive timeout, in seconds, uses the session default timeout, see WithRPCTimeout.
 func (session *Session) SyncRPC(operation message.RPCMethod, timeout int32) (*message.RPCReply, error) {
+	ctx, cancel := session.timeoutContext(timeout)
+	defer cancel()
+
+	reply, err := session.SyncRPCContext(ctx, operation)
+	if errors.Is(err, context.DeadlineExceeded) {
+		return nil, fmt.Errorf("timeout while executing request: %w", err)
+	}
+	return reply, err
+}
+
+// timeoutContext returns a context bounded by the timeout, in seconds, or by the session default timeout, see
+// WithRPCTimeout, when it is not positive.
+func (session *Session) timeoutContext(timeout int32) (context.Context, context.CancelFunc) {
+	duration := time.Duration(timeout) * time.Second
+	if timeout <= 0 {
+		duration = session.rpcTimeout
+	}
+	return context.WithTimeout(context.Background(), duration)
+}
+
+// SyncRPCContext is used to execute an RPC method and receive the response synchronously, waiting until the
+// reply is received or the context is done, in which case ctx.Err() is returned.
+func (session *Session) SyncRPCContext(ctx context.Context, operation message.RPCMethod) (*message.RPCReply, error) {
+	session.assignMessageID(operation)
+	return session.syncRPC(ctx, operation)
+}
+
+// syncRPC is SyncRPCContext sending the RPC with its current message-id.
+func (session *Session) syncRPC(ctx context.Context, operation message.RPCMethod) (*message.RPCReply, error) {
 
 	// get XML payload
 	request, err := marshall(operation)
@@ -77,26 +233,110 @@ func (session *Session) SyncRPC(operation message.RPCMethod, timeout int32) (*me
 		return nil, err
 	}
 
+	// wait for an outstanding RPC slot
+	name := operationName(request)
+	release, err := session.acquireInFlight(ctx)
+	if err != nil {
+		session.metrics.failed(name)
+		return nil, err
+	}
+	defer release()
+	if err := session.awaitInterleave(ctx, name); err != nil {
+		session.metrics.failed(name)
+		return nil, err
+	}
+
 	// setup and register callback
 	reply := make(chan message.RPCReply, 1)
 	callback := func(event Event) {
 		reply <- *event.RPCReply()
-		session.logger.Info("Successfully executed RPC")
+		session.logger.Info("Successfully executed RPC", session.rpcLogArgs(operation, request)...)
 	}
 	session.Listener.Register(operation.GetMessageID(), callback)
 
 	// send rpc
-	session.logger.Info("Sending RPC")
-	err = session.Transport.Send(request)
+	stopped := session.stoppedChan()
+	session.logger.Info("Sending RPC", session.rpcLogArgs(operation, request)...)
+	sentAt := time.Now()
+	err = session.send(request)
 	if err != nil {
+		session.Listener.Remove(operation.GetMessageID())
+		session.metrics.failed(name)
+		session.recordFailure(operation, request, sentAt, nil, err)
 		return nil, err
 	}
+	session.metrics.sent(name)
 
 	select {
 	case res := <-reply:
+		if warnings := res.Warnings(); len(warnings) != 0 {
+			session.logger.Warn("RPC replied with warnings", session.rpcLogArgs(operation, request, "warnings", message.RPCErrors(warnings))...)
+		}
+		session.metrics.replied(name, time.Since(sentAt), session.replyErr(&res) != nil)
+		if session.replyErr(&res) != nil {
+			session.recordFailure(operation, request, sentAt, &res, nil)
+		} else if name == "create-subscription" {
+			session.subscribed()
+		}
 		return &res, nil
-	case <-time.After(time.Duration(timeout) * time.Second):
-		return nil, errors.New("timeout while executing request")
+	case <-ctx.Done():
+		// the reply will never be waited for
+		session.Listener.Remove(operation.GetMessageID())
+		session.metrics.failed(name)
+		session.recordFailure(operation, request, sentAt, nil, ctx.Err())
+		return nil, ctx.Err()
+	case <-stopped:
+		// no more reply will be received, though the last one may have been
+		select {
+		case res := <-reply:
+			session.metrics.replied(name, time.Since(sentAt), session.replyErr(&res) != nil)
+			return &res, nil
+		default:
+		}
+		session.Listener.Remove(operation.GetMessageID())
+		err := session.closedError()
+		session.metrics.failed(name)
+		session.recordFailure(operation, request, sentAt, nil, err)
+		return nil, err
+	}
+}
+
+// messageIDSetter is implemented by the RPCs whose message-id can be replaced, e.g. the ones embedding message.RPC.
+type messageIDSetter interface {
+	SetMessageID(id string)
+}
+
+// assignMessageID replaces the message-id of the RPC by one of the session generator, if any.
+func (session *Session) assignMessageID(operation message.RPCMethod) {
+	if session.messageIDs == nil {
+		return
+	}
+	if setter, ok := operation.(messageIDSetter); ok {
+		setter.SetMessageID(session.messageIDs())
+	}
+}
+
+// rpcLogArgs returns the log fields identifying the provided RPC.
+func (session *Session) rpcLogArgs(operation message.RPCMethod, request []byte, args ...any) []any {
+	fields := []any{"message-id", operation.GetMessageID(), "operation", operationName(request)}
+	return session.logArgs(append(fields, args...)...)
+}
+
+// operationName returns the name of the operation carried by the marshalled RPC, e.g. `get-config`.
+func operationName(request []byte) string {
+	decoder := xml.NewDecoder(bytes.NewReader(request))
+	depth := 0
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return ""
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			if depth == 1 {
+				return start.Name.Local
+			}
+			depth++
+		}
 	}
 }
 
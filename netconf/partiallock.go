@@ -0,0 +1,118 @@
+/*
+Copyright 2021. Alexis de Talhouët
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package netconf
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/openshift-telco/go-netconf-client/netconf/message"
+)
+
+// ErrPartialLockNotSupported is returned by PartialLock when the server does not advertise the :partial-lock
+// capability.
+var ErrPartialLockNotSupported = errors.New("server does not support partial locks")
+
+// PartialLock is a lock held on parts of the running datastore, see Session.PartialLock.
+type PartialLock struct {
+	// ID is the lock-id, used to release the lock.
+	ID uint32
+	// LockedNodes are the instance identifiers of the locked nodes.
+	LockedNodes []string
+}
+
+// PartialLock locks the parts of the running datastore selected by the XPath expressions, see
+// message.NewPartialLock, so concurrent clients can edit distinct parts of the configuration.
+// The lock is held until released with PartialUnlock, or the session is closed: CloseGracefully releases the held
+// locks before ending the session, while the server releases them when the session ends otherwise.
+func (session *Session) PartialLock(ctx context.Context, selects []string, namespaces map[string]string) (*PartialLock, error) {
+	if !session.features.PartialLock {
+		return nil, ErrPartialLockNotSupported
+	}
+	reply, err := session.SyncRPCContext(ctx, message.NewPartialLock(selects, namespaces))
+	if err != nil {
+		return nil, err
+	}
+	if err := session.replyErr(reply); err != nil {
+		return nil, fmt.Errorf("partial-lock failed with errors: %w", err)
+	}
+
+	var data struct {
+		LockID      *uint32  `xml:"lock-id"`
+		LockedNodes []string `xml:"locked-node"`
+	}
+	if err := xml.Unmarshal([]byte(reply.RawReply), &data); err != nil {
+		return nil, fmt.Errorf("fail to decode partial-lock reply: %w", err)
+	}
+	if data.LockID == nil {
+		return nil, fmt.Errorf("partial-lock reply has no lock-id")
+	}
+
+	lock := PartialLock{ID: *data.LockID, LockedNodes: data.LockedNodes}
+	session.partialLocksLock.Lock()
+	if session.partialLocks == nil {
+		session.partialLocks = make(map[uint32]PartialLock)
+	}
+	session.partialLocks[lock.ID] = lock
+	session.partialLocksLock.Unlock()
+	return &lock, nil
+}
+
+// PartialUnlock releases a lock taken with PartialLock.
+// It fails when the server replies with rpc-errors.
+func (session *Session) PartialUnlock(ctx context.Context, lockID uint32) error {
+	if err := session.rpcOK(ctx, "partial-unlock", message.NewPartialUnlock(lockID)); err != nil {
+		return err
+	}
+	session.partialLocksLock.Lock()
+	delete(session.partialLocks, lockID)
+	session.partialLocksLock.Unlock()
+	return nil
+}
+
+// PartialLocks returns the partial locks held by the session, by lock-id.
+func (session *Session) PartialLocks() []PartialLock {
+	session.partialLocksLock.Lock()
+	defer session.partialLocksLock.Unlock()
+	locks := make([]PartialLock, 0, len(session.partialLocks))
+	for _, lock := range session.partialLocks {
+		locks = append(locks, lock)
+	}
+	sort.Slice(locks, func(i, j int) bool { return locks[i].ID < locks[j].ID })
+	return locks
+}
+
+// releasePartialLocks releases the held partial locks, returning the first error.
+func (session *Session) releasePartialLocks(ctx context.Context) error {
+	var first error
+	for _, lock := range session.PartialLocks() {
+		if err := session.PartialUnlock(ctx, lock.ID); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// forgetPartialLocks drops the partial locks, released by the server once the session ended.
+func (session *Session) forgetPartialLocks() {
+	session.partialLocksLock.Lock()
+	defer session.partialLocksLock.Unlock()
+	session.partialLocks = nil
+}
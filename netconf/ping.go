@@ -0,0 +1,119 @@
+/*
+Copyright 2021. Alexis de Talhouët
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package netconf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/openshift-telco/go-netconf-client/netconf/message"
+)
+
+// sshKeepAliveRequest is the global request used to check an SSH connection is alive. Servers not knowing it
+// reply with a failure, which is enough to tell the connection is alive.
+const sshKeepAliveRequest = "keepalive@openssh.com"
+
+// ErrPingNotSupported is returned by transports having no lightweight liveness check.
+var ErrPingNotSupported = errors.New("transport does not support ping")
+
+// Pinger is implemented by transports providing a lightweight liveness check of the connection.
+type Pinger interface {
+	// Ping checks the connection is alive within the timeout and returns the round-trip time.
+	Ping(timeout time.Duration) (time.Duration, error)
+}
+
+// Ping checks the SSH connection is alive using an SSH global request, and returns the round-trip time.
+// It fails when the server does not reply within the timeout, the connection being then closed as dead, which
+// also ends the pending request.
+func (t *TransportSSH) Ping(timeout time.Duration) (time.Duration, error) {
+	if t.sshClient == nil {
+		return 0, fmt.Errorf("no connection to ping")
+	}
+	start := time.Now()
+	replied := make(chan error, 1)
+	go func() {
+		_, _, err := t.sshClient.SendRequest(sshKeepAliveRequest, true, nil)
+		replied <- err
+	}()
+	select {
+	case err := <-replied:
+		if err != nil {
+			return 0, err
+		}
+		return time.Since(start), nil
+	case <-time.After(timeout):
+		_ = t.sshClient.Close()
+		return 0, fmt.Errorf("ping timed out: no reply after %v", timeout)
+	}
+}
+
+// Ping checks the wrapped transport is alive, when it supports it.
+func (t *captureTransport) Ping(timeout time.Duration) (time.Duration, error) {
+	if pinger, ok := t.Transport.(Pinger); ok {
+		return pinger.Ping(timeout)
+	}
+	return 0, ErrPingNotSupported
+}
+
+// Ping checks the session is alive and returns the round-trip time, so pools and monitors can verify it before
+// use. It relies on the transport liveness check when available, and otherwise on a `get` with an empty
+// subtree filter, which selects no data. The timeout, in seconds, bounds either check; a non-positive one uses
+// the session default timeout, see WithRPCTimeout.
+func (session *Session) Ping(timeout int32) (time.Duration, error) {
+	ctx, cancel := session.timeoutContext(timeout)
+	defer cancel()
+	return session.PingContext(ctx)
+}
+
+// PingContext is Ping bounded by the context rather than a timeout. A context without deadline gets the session
+// default timeout, see WithRPCTimeout, as the transport liveness check needs one.
+func (session *Session) PingContext(ctx context.Context) (time.Duration, error) {
+	if session.Closed() {
+		return 0, session.closedError()
+	}
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, session.rpcTimeout)
+		defer cancel()
+	}
+	if pinger, ok := session.Transport.(Pinger); ok {
+		deadline, _ := ctx.Deadline()
+		latency, err := pinger.Ping(time.Until(deadline))
+		if !errors.Is(err, ErrPingNotSupported) {
+			return latency, err
+		}
+	}
+	return session.rpcPing(ctx)
+}
+
+// rpcPing sends a `get` with an empty subtree filter, and returns the round-trip time.
+func (session *Session) rpcPing(ctx context.Context) (time.Duration, error) {
+	get := message.NewGet("", "")
+	get.Get.Filter = &message.Filter{Type: message.FilterTypeSubtree}
+	start := time.Now()
+	reply, err := session.SyncRPCContext(ctx, get)
+	if err != nil {
+		return 0, err
+	}
+	latency := time.Since(start)
+	if err := session.replyErr(reply); err != nil {
+		return latency, fmt.Errorf("ping failed with errors: %w", err)
+	}
+	return latency, nil
+}
@@ -0,0 +1,444 @@
+/*
+Copyright 2021. Alexis de Talhouët
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package netconf
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrPoolClosed is returned by a Pool once closed.
+var ErrPoolClosed = errors.New("netconf session pool closed")
+
+// poolProbeTimeout is the longest a health check waits for a session to respond.
+const poolProbeTimeout = 5 * time.Second
+
+// PoolFactory establishes a session to the device of the pool, completing the hello exchange.
+type PoolFactory func(ctx context.Context) (*Session, error)
+
+// PoolOption allow optional configuration for the session pool.
+type PoolOption func(*Pool)
+
+// WithPoolSize bounds the number of sessions of the pool, idle and checked out; Get waits once it is reached.
+// The default is 4.
+func WithPoolSize(size int) PoolOption {
+	if size < 1 {
+		panic(fmt.Sprintf("provided pool size %d is not supported, expecting a positive number", size))
+	}
+	return func(p *Pool) {
+		p.size = size
+	}
+}
+
+// WithPoolMaxIdle closes the sessions left idle in the pool for longer than the provided duration.
+// Zero, the default, keeps them open.
+func WithPoolMaxIdle(maxIdle time.Duration) PoolOption {
+	return func(p *Pool) {
+		p.maxIdle = maxIdle
+	}
+}
+
+// WithPoolHealthCheck pings the idle sessions at the provided interval, see Session.Ping, closing the ones not
+// responding within the interval, or within 5 seconds when it is longer, so Get does not return a dead session.
+// Zero, the default, disables the health checks.
+func WithPoolHealthCheck(interval time.Duration) PoolOption {
+	return func(p *Pool) {
+		p.healthInterval = interval
+	}
+}
+
+// WithPoolMinHealthy sets the number of healthy sessions, idle or checked out, below which the pool is not
+// ready, see Pool.Ready. Sessions are established on demand, so the pool only becomes ready once used.
+// The default is 0.
+func WithPoolMinHealthy(count int) PoolOption {
+	if count < 0 {
+		panic(fmt.Sprintf("provided minimum of healthy sessions %d is not supported, expecting a non-negative number", count))
+	}
+	return func(p *Pool) {
+		p.minHealthy = count
+	}
+}
+
+// PoolStats holds the counters of a pool.
+type PoolStats struct {
+	// Idle is the number of sessions waiting in the pool.
+	Idle int `json:"idle"`
+	// InUse is the number of sessions checked out.
+	InUse int `json:"inUse"`
+	// Created is the number of sessions established by the pool.
+	Created uint64 `json:"created"`
+	// Evicted is the number of sessions closed by the pool, for being idle too long or failing a health check.
+	Evicted uint64 `json:"evicted"`
+	// LastProbe is the time a session was last established or passed a health check, zero if none did.
+	LastProbe time.Time `json:"lastProbe,omitempty"`
+}
+
+// Pool holds sessions to a single device, serving concurrent requests over several sessions. Sessions are
+// checked out using Get, and checked in using Put once done with. A Pool is safe for concurrent use.
+type Pool struct {
+	factory        PoolFactory
+	size           int
+	maxIdle        time.Duration
+	healthInterval time.Duration
+	minHealthy     int
+	lock           sync.Mutex
+	idle           []*idleSession
+	// open is the number of sessions idle, checked out, being established or pinged
+	open int
+	// pinged is the number of idle sessions being health checked
+	pinged int
+	// probeErr is the error of the latest session establishment or health check, nil if it succeeded
+	probeErr error
+	changed  chan struct{}
+	stats    PoolStats
+	closed   bool
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// idleSession is a session waiting in the pool.
+type idleSession struct {
+	session *Session
+	since   time.Time
+}
+
+// NewPool creates a pool establishing its sessions using factory, on demand.
+func NewPool(factory PoolFactory, options ...PoolOption) *Pool {
+	p := &Pool{
+		factory: factory,
+		size:    4,
+		changed: make(chan struct{}),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	for _, opt := range options {
+		opt(p)
+	}
+	go p.maintain()
+	return p
+}
+
+// Get checks out a session, reusing an idle one when there is, establishing a new one otherwise, and waiting
+// for a session to be checked in once the pool size is reached. The session must be checked in using Put.
+func (p *Pool) Get(ctx context.Context) (*Session, error) {
+	for {
+		p.lock.Lock()
+		if p.closed {
+			p.lock.Unlock()
+			return nil, ErrPoolClosed
+		}
+		if session := p.takeIdle(); session != nil {
+			p.lock.Unlock()
+			return session, nil
+		}
+		if p.open < p.size {
+			p.open++
+			p.lock.Unlock()
+			return p.create(ctx)
+		}
+		changed := p.changed
+		p.lock.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-changed:
+		}
+	}
+}
+
+// takeIdle checks out the most recently used idle session, if any, dropping the closed ones.
+// The lock must be held.
+func (p *Pool) takeIdle() *Session {
+	for len(p.idle) > 0 {
+		last := p.idle[len(p.idle)-1]
+		p.idle = p.idle[:len(p.idle)-1]
+		if last.session.Closed() {
+			p.stats.Evicted++
+			p.open--
+			continue
+		}
+		p.stats.InUse++
+		return last.session
+	}
+	return nil
+}
+
+// create establishes a session counted as open.
+func (p *Pool) create(ctx context.Context) (*Session, error) {
+	session, err := p.factory(ctx)
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.probed(err)
+	if err != nil {
+		p.open--
+		p.signal()
+		return nil, err
+	}
+	p.stats.Created++
+	if p.closed {
+		p.open--
+		p.signal()
+		_ = session.Close()
+		return nil, ErrPoolClosed
+	}
+	p.stats.InUse++
+	return session, nil
+}
+
+// Put checks in a session returned by Get. A closed session is dropped, making room for a new one.
+func (p *Pool) Put(session *Session) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.stats.InUse--
+	if p.closed || session.Closed() {
+		p.open--
+		_ = session.Close()
+	} else {
+		p.idle = append(p.idle, &idleSession{session: session, since: time.Now()})
+	}
+	p.signal()
+}
+
+// Do checks out a session, runs the operation with it and checks it in.
+func (p *Pool) Do(ctx context.Context, operation func(*Session) error) error {
+	session, err := p.Get(ctx)
+	if err != nil {
+		return err
+	}
+	defer p.Put(session)
+	return operation(session)
+}
+
+// probed records the outcome of a session establishment or health check. The lock must be held.
+func (p *Pool) probed(err error) {
+	p.probeErr = err
+	if err == nil {
+		p.stats.LastProbe = time.Now()
+	}
+}
+
+// Healthy returns nil when the pool is open, and the latest session establishment or health check succeeded.
+// It is meant for liveness probes, see HealthHandler.
+func (p *Pool) Healthy() error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.healthy()
+}
+
+// healthy implements Healthy. The lock must be held.
+func (p *Pool) healthy() error {
+	if p.closed {
+		return ErrPoolClosed
+	}
+	if p.probeErr != nil {
+		return fmt.Errorf("latest probe failed: %w", p.probeErr)
+	}
+	return nil
+}
+
+// Ready returns nil when the pool is healthy, and holds at least the minimum number of healthy sessions, see
+// WithPoolMinHealthy. It is meant for readiness probes, see ReadyHandler.
+func (p *Pool) Ready() error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if err := p.healthy(); err != nil {
+		return err
+	}
+	if healthy := len(p.idle) + p.pinged + p.stats.InUse; healthy < p.minHealthy {
+		return fmt.Errorf("%d healthy sessions, below the minimum of %d", healthy, p.minHealthy)
+	}
+	return nil
+}
+
+// HealthHandler returns an http.Handler serving the liveness of the pool, see Healthy: it answers 200 when
+// healthy, 503 otherwise, with the pool stats as JSON.
+func (p *Pool) HealthHandler() http.Handler {
+	return p.probeHandler(p.Healthy)
+}
+
+// ReadyHandler returns an http.Handler serving the readiness of the pool, see Ready: it answers 200 when
+// ready, 503 otherwise, with the pool stats as JSON.
+func (p *Pool) ReadyHandler() http.Handler {
+	return p.probeHandler(p.Ready)
+}
+
+// poolProbe is the body served by the probe handlers.
+type poolProbe struct {
+	Status string    `json:"status"`
+	Error  string    `json:"error,omitempty"`
+	Stats  PoolStats `json:"stats"`
+}
+
+// probeHandler serves the outcome of the probe.
+func (p *Pool) probeHandler(probe func() error) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := poolProbe{Status: "ok", Stats: p.Stats()}
+		code := http.StatusOK
+		if err := probe(); err != nil {
+			body.Status = "unavailable"
+			body.Error = err.Error()
+			code = http.StatusServiceUnavailable
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		_ = json.NewEncoder(w).Encode(body)
+	})
+}
+
+// Stats returns a snapshot of the counters of the pool.
+func (p *Pool) Stats() PoolStats {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	stats := p.stats
+	stats.Idle = len(p.idle)
+	return stats
+}
+
+// Close closes the idle sessions and stops the health checks. The sessions checked out are closed when
+// checked in.
+func (p *Pool) Close() error {
+	p.lock.Lock()
+	if p.closed {
+		p.lock.Unlock()
+		return ErrPoolClosed
+	}
+	p.closed = true
+	p.signal()
+	p.lock.Unlock()
+
+	// wait for the health checks to return the pinged sessions
+	close(p.stop)
+	<-p.done
+	p.lock.Lock()
+	idle := p.idle
+	p.idle = nil
+	p.open -= len(idle)
+	p.lock.Unlock()
+
+	var errs []error
+	for _, i := range idle {
+		if err := i.session.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// signal wakes up the Get calls waiting for a session. The lock must be held.
+func (p *Pool) signal() {
+	close(p.changed)
+	p.changed = make(chan struct{})
+}
+
+// maintain evicts the idle sessions left unused for too long or failing a health check, until the pool
+// is closed.
+func (p *Pool) maintain() {
+	defer close(p.done)
+	interval := p.healthInterval
+	if p.maxIdle > 0 && (interval == 0 || p.maxIdle < interval) {
+		interval = p.maxIdle
+	}
+	if interval == 0 {
+		<-p.stop
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	lastCheck := time.Now()
+	for {
+		select {
+		case <-p.stop:
+			return
+		case now := <-ticker.C:
+			check := p.healthInterval > 0 && now.Sub(lastCheck) >= p.healthInterval
+			if check {
+				lastCheck = now
+			}
+			p.evict(now, check)
+		}
+	}
+}
+
+// evict closes the idle sessions unused since before maxIdle, and the ones failing a health check when check
+// is set. Sessions are pinged while out of the idle list, so they cannot be checked out meanwhile.
+func (p *Pool) evict(now time.Time, check bool) {
+	p.lock.Lock()
+	var kept, expired, pinged []*idleSession
+	for _, i := range p.idle {
+		switch {
+		case i.session.Closed() || (p.maxIdle > 0 && now.Sub(i.since) >= p.maxIdle):
+			expired = append(expired, i)
+		case check:
+			pinged = append(pinged, i)
+		default:
+			kept = append(kept, i)
+		}
+	}
+	p.idle = kept
+	p.pinged += len(pinged)
+	p.open -= len(expired)
+	p.stats.Evicted += uint64(len(expired))
+	if len(expired) != 0 {
+		p.signal()
+	}
+	p.lock.Unlock()
+
+	for _, i := range expired {
+		_ = i.session.Close()
+	}
+
+	// the sessions are pinged concurrently, each within the probe timeout, so a dead device does not hold the
+	// others out of the idle list
+	ctx, cancel := context.WithTimeout(context.Background(), p.probeTimeout())
+	defer cancel()
+	var wg sync.WaitGroup
+	for _, i := range pinged {
+		wg.Add(1)
+		go func(i *idleSession) {
+			defer wg.Done()
+			_, err := i.session.PingContext(ctx)
+			p.lock.Lock()
+			p.pinged--
+			p.probed(err)
+			if err != nil {
+				p.open--
+				p.stats.Evicted++
+			} else {
+				p.idle = append(p.idle, i)
+			}
+			p.signal()
+			p.lock.Unlock()
+			if err != nil {
+				_ = i.session.Close()
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+// probeTimeout returns how long a health check waits for a session to respond: the health check interval, up
+// to poolProbeTimeout.
+func (p *Pool) probeTimeout() time.Duration {
+	return min(p.healthInterval, poolProbeTimeout)
+}
@@ -0,0 +1,167 @@
+/*
+Copyright 2021. Alexis de Talhouët
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package netconf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/openshift-telco/go-netconf-client/netconf/message"
+)
+
+// ErrReconnectNotSupported is returned by Reconnect when the session does not know how to reach the server again.
+var ErrReconnectNotSupported = errors.New("session cannot be reconnected, see WithRedial")
+
+// WithRedial sets how Reconnect opens a new transport to the server. The session factories dialing a target,
+// e.g. NewSessionFromSSHConfig, set it to dial the same target again.
+func WithRedial(dial func() (Transport, error)) SessionOption {
+	return func(s *Session) {
+		s.redial = dial
+	}
+}
+
+// Reconnect replaces a dropped, or closed, session with a new one to the same server: the current transport is
+// closed, a new one is opened, see WithRedial, and the hello messages are exchanged again, advertising the same
+// capabilities. The Listener is kept, so the registered callbacks, e.g. the notification handlers, receive the
+// messages of the new session. As the server knows nothing of the previous session, the reinit function, if not
+// nil, is called once the session is established to restore its state, e.g. to create the notification
+// subscriptions again.
+//
+// The RPCs pending on the previous session fail with ErrSessionClosed; Errors must be called again to receive
+// the errors of the new session. Reconnect must not be called concurrently with itself.
+func (session *Session) Reconnect(reinit func(*Session) error) error {
+	return session.ReconnectContext(context.Background(), reinit)
+}
+
+// ReconnectContext is Reconnect bounded by the context: once it is done, the new transport is closed, or
+// abandoned while it is being dialed, and the context error is returned. The session then stays closed.
+// The context does not apply to the reinit function.
+func (session *Session) ReconnectContext(ctx context.Context, reinit func(*Session) error) error {
+	if session.redial == nil {
+		return ErrReconnectNotSupported
+	}
+
+	// stop receiving the messages of the current session
+	_ = session.Close()
+	if session.listening.Load() {
+		select {
+		case <-session.stoppedChan():
+		case <-time.After(session.rpcTimeout):
+			return fmt.Errorf("fail to reconnect: timeout while waiting for the session to stop")
+		case <-ctx.Done():
+			return fmt.Errorf("fail to reconnect: %w", ctx.Err())
+		}
+	}
+
+	t, err := session.redialContext(ctx)
+	if err != nil {
+		return fmt.Errorf("fail to reconnect: %w", err)
+	}
+	session.reset(session.setupTransport(t))
+
+	stop := context.AfterFunc(ctx, func() { _ = session.Close() })
+	err = session.receiveServerHello()
+	if err == nil {
+		err = session.SendHello(&message.Hello{Capabilities: session.helloCapabilities})
+	}
+	if !stop() {
+		err = ctx.Err()
+	}
+	if err != nil {
+		_ = session.Close()
+		return fmt.Errorf("fail to reconnect: %w", err)
+	}
+	if session.manualListen && session.started {
+		session.start()
+	}
+	session.logger.Info("session reconnected", session.logArgs()...)
+
+	if reinit != nil {
+		if err := reinit(session); err != nil {
+			return fmt.Errorf("fail to reinitialize reconnected session: %w", err)
+		}
+	}
+	return nil
+}
+
+// redialContext opens a new transport to the server, see WithRedial, giving up once the context is done. The
+// transport dialed after that is closed.
+func (session *Session) redialContext(ctx context.Context) (Transport, error) {
+	type result struct {
+		t   Transport
+		err error
+	}
+	results := make(chan result, 1)
+	go func() {
+		t, err := session.redial()
+		results <- result{t, err}
+	}()
+	select {
+	case r := <-results:
+		return r.t, r.err
+	case <-ctx.Done():
+		go func() {
+			if late := <-results; late.err == nil {
+				_ = late.t.Close()
+			}
+		}()
+		return nil, ctx.Err()
+	}
+}
+
+// reset prepares a stopped session for a new transport.
+func (session *Session) reset(t Transport) {
+	session.closeLock.Lock()
+	session.IsClosed = false
+	session.closed = false
+	session.closeHooks = nil
+	session.closeCause = nil
+	session.closeNotified = false
+	session.resetInFlight()
+	session.stopped = make(chan struct{})
+	session.listenDone = make(chan struct{})
+	session.subscription = nil
+	session.closeLock.Unlock()
+
+	session.errLock.Lock()
+	session.errs = make(chan error, errorsBuffer)
+	session.errsClosed = false
+	session.errLock.Unlock()
+
+	session.sendLock.Lock()
+	session.Transport = t
+	session.sendLock.Unlock()
+
+	session.closing.Store(false)
+	session.closeSessionID.Store(nil)
+	session.listening.Store(false)
+	session.framingVersion.Store("")
+	session.serverHello.Store(nil)
+	session.clientHello.Store(nil)
+	session.notificationStream.Store(false)
+	session.forgetPartialLocks()
+	session.resetState()
+}
+
+// stoppedChan returns the channel closed once the session stops receiving messages.
+func (session *Session) stoppedChan() <-chan struct{} {
+	session.closeLock.Lock()
+	defer session.closeLock.Unlock()
+	return session.stopped
+}
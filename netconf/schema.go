@@ -0,0 +1,135 @@
+/*
+Copyright 2021. Alexis de Talhouët
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package netconf
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/openshift-telco/go-netconf-client/netconf/message"
+)
+
+// schemasFilter selects the schemas listed by the NETCONF monitoring data.
+const schemasFilter = `<netconf-state xmlns="` + message.NetconfMonitoringXmlns + `"><schemas/></netconf-state>`
+
+// Schema is a schema the server provides, as listed by the NETCONF monitoring data, see RFC 6022.
+type Schema struct {
+	Identifier string `xml:"identifier"`
+	Version    string `xml:"version"`
+	// Format is the schema language, e.g. message.SchemaFormatYang.
+	Format    string   `xml:"format"`
+	Namespace string   `xml:"namespace"`
+	Location  []string `xml:"location"`
+}
+
+// ListSchemas returns the schemas the server provides, as listed by the NETCONF monitoring data.
+func (session *Session) ListSchemas(ctx context.Context) ([]Schema, error) {
+	reply, err := session.SyncRPCContext(ctx, message.NewGet(message.FilterTypeSubtree, schemasFilter))
+	if err != nil {
+		return nil, err
+	}
+	if err := session.replyErr(reply); err != nil {
+		return nil, fmt.Errorf("get failed with errors: %w", err)
+	}
+
+	var data struct {
+		Schemas []Schema `xml:"data>netconf-state>schemas>schema"`
+	}
+	if err := xml.Unmarshal([]byte(reply.RawReply), &data); err != nil {
+		return nil, fmt.Errorf("fail to decode schemas: %w", err)
+	}
+	for i := range data.Schemas {
+		data.Schemas[i].Format = identityName(data.Schemas[i].Format)
+	}
+	return data.Schemas, nil
+}
+
+// GetSchema retrieves the content of a schema, see message.NewGetSchema.
+func (session *Session) GetSchema(ctx context.Context, identifier string, version string, format string) (string, error) {
+	reply, err := session.SyncRPCContext(ctx, message.NewGetSchema(identifier, version, format))
+	if err != nil {
+		return "", err
+	}
+	if err := session.replyErr(reply); err != nil {
+		return "", fmt.Errorf("get-schema failed with errors: %w", err)
+	}
+
+	var data struct {
+		Data *struct {
+			Text  string `xml:",chardata"`
+			Inner string `xml:",innerxml"`
+		} `xml:"data"`
+	}
+	if err := xml.Unmarshal([]byte(reply.RawReply), &data); err != nil {
+		return "", fmt.Errorf("fail to decode schema %s: %w", identifier, err)
+	}
+	if data.Data == nil {
+		return "", message.ErrNoData
+	}
+	// YIN schemas are XML documents, YANG ones text
+	if strings.TrimSpace(data.Data.Text) == "" {
+		return data.Data.Inner, nil
+	}
+	return data.Data.Text, nil
+}
+
+// DownloadSchemas retrieves all the schemas listed by the server and writes them in the directory, created if
+// needed, e.g. to feed model-driven tooling. Each schema is written to <identifier>[@<version>].<format>, and the
+// paths of the written files are returned. It stops on the first schema failing to be retrieved or written.
+func (session *Session) DownloadSchemas(ctx context.Context, dir string) ([]string, error) {
+	schemas, err := session.ListSchemas(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	for _, schema := range schemas {
+		content, err := session.GetSchema(ctx, schema.Identifier, schema.Version, schema.Format)
+		if err != nil {
+			return paths, err
+		}
+		name := schema.Identifier
+		if schema.Version != "" {
+			name += "@" + schema.Version
+		}
+		if schema.Format != "" {
+			name += "." + schema.Format
+		}
+		path := filepath.Join(dir, filepath.Base(name))
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			return paths, err
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// identityName returns the identity without its module prefix, e.g. yang for ncm:yang.
+func identityName(identity string) string {
+	identity = strings.TrimSpace(identity)
+	if i := strings.LastIndex(identity, ":"); i >= 0 {
+		return identity[i+1:]
+	}
+	return identity
+}
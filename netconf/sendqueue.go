@@ -0,0 +1,85 @@
+/*
+Copyright 2021. Alexis de Talhouët
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package netconf
+
+import (
+	"fmt"
+	"sync"
+)
+
+// WithSendQueueLimit bounds the number of messages queued for sending, including the one being written. Once
+// reached, the RPCs block until the queue has room, pushing back on the callers rather than piling up messages.
+// Zero, the default, means no limit.
+func WithSendQueueLimit(limit int) SessionOption {
+	if limit < 0 {
+		panic(fmt.Sprintf("provided send queue limit %d is not supported, expecting a non-negative number", limit))
+	}
+	return func(s *Session) {
+		s.sendQueue.limit = limit
+	}
+}
+
+// SendQueueDepth returns the number of messages queued for sending, including the one being written.
+func (session *Session) SendQueueDepth() int {
+	return session.sendQueue.depth()
+}
+
+// sendQueue orders the outbound messages of a session first come, first served, so concurrent callers write
+// their frames one at a time, in the order they called.
+type sendQueue struct {
+	lock sync.Mutex
+	cond *sync.Cond
+	// next is the ticket of the next queued message, and serving the one of the message allowed to be written
+	next    uint64
+	serving uint64
+	limit   int
+}
+
+// init prepares the queue, once the session options are applied.
+func (q *sendQueue) init() {
+	q.cond = sync.NewCond(&q.lock)
+}
+
+// enter queues a message, waiting for the queue to have room, and returns once it is the message turn to be
+// written. Every enter must be followed by a leave.
+func (q *sendQueue) enter() {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	for q.limit > 0 && int(q.next-q.serving) >= q.limit {
+		q.cond.Wait()
+	}
+	ticket := q.next
+	q.next++
+	for q.serving != ticket {
+		q.cond.Wait()
+	}
+}
+
+// leave gives the turn to the next queued message.
+func (q *sendQueue) leave() {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	q.serving++
+	q.cond.Broadcast()
+}
+
+// depth returns the number of queued messages, including the one being written.
+func (q *sendQueue) depth() int {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return int(q.next - q.serving)
+}
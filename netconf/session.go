@@ -9,16 +9,31 @@
 package netconf
 
 import (
+	"bytes"
 	"context"
 	"encoding/xml"
+	"errors"
+	"fmt"
 	"io"
 	"log/slog"
-	"regexp"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/openshift-telco/go-netconf-client/netconf/message"
 )
 
+// defaultRPCTimeout is the timeout of SyncRPC and AsyncRPC unless configured with WithRPCTimeout.
+const defaultRPCTimeout = 30 * time.Second
+
+// defaultHelloTimeout bounds each half of the hello exchange unless configured with WithHelloTimeout,
+// WithSendHelloTimeout or WithReceiveHelloTimeout.
+const defaultHelloTimeout = 30 * time.Second
+
+// expiryInterval is how often the AsyncRPC callbacks are checked for timeouts.
+const expiryInterval = 100 * time.Millisecond
+
 // DefaultCapabilities sets the default capabilities of the client library.
 var DefaultCapabilities = []string{
 	message.NetconfVersion10,
@@ -38,49 +53,491 @@ type Logger interface {
 type SessionOption func(*Session)
 
 // Session represents a NETCONF sessions with a remote NETCONF server.
+//
+// Once the hello exchange completed, a session is safe for concurrent use:
+//   - the RPC methods may be called from multiple goroutines, the messages being sent one at a time;
+//   - replies, notifications and errors are received by a single goroutine, which runs the callbacks unless
+//     an executor is set using WithCallbackExecutor;
+//   - Close may be called at any time, from any goroutine; the pending RPCs then fail with a *SessionClosedError,
+//     matching ErrSessionClosed, once the session stops receiving messages, which is dispatched to the
+//     CloseEventHandler registration and to the OnClose hooks.
+//
+// The exported fields are kept for compatibility and must not be modified once the session is created;
+// use Closed rather than IsClosed from other goroutines.
 type Session struct {
-	Transport                   Transport
-	SessionID                   int
-	Capabilities                []string
-	IsClosed                    bool
-	Listener                    *Dispatcher
-	IsNotificationStreamCreated bool
-	logger                      Logger
-}
-
-// NewSession creates a new NETCONF session using the provided transport layer.
-func NewSession(t Transport, options ...SessionOption) *Session {
-	s := new(Session)
+	Transport            Transport
+	Capabilities         []string
+	IsClosed             bool
+	Listener             *Dispatcher
+	logger               Logger
+	logFields            []any
+	capture              io.Writer
+	framingRecovery      bool
+	maxMessageSize       int
+	framingAutoDetect    bool
+	forcedFramingVersion string
+	baseVersion          string
+	framingVersion       atomic.Value
+	serverHello          atomic.Pointer[[]byte]
+	clientHello          atomic.Pointer[[]byte]
+	sessionID            atomic.Uint32
+	advertiseVersion     bool
+	dialOptions          []DialOption
+	supportBundles       *supportBundles
+	executor             Executor
+	callbacks            *callbackRunner
+	rpcTimeout           time.Duration
+	sendLock             sync.Mutex
+	sendQueue            sendQueue
+	recentErrors         recentErrors
+	activity             activity
+	idlePeriod           time.Duration
+	userIdleHooks        []IdleHook
+	partialLocksLock     sync.Mutex
+	partialLocks         map[uint32]PartialLock
+	closing              atomic.Bool
+	closeSessionID       atomic.Pointer[string]
+	closeLock            sync.Mutex
+	closeHooks           []func()
+	closeCause           error
+	closeNotified        bool
+	closed               bool
+	stopped              chan struct{}
+	clientCapabilities   []string
+	features             NegotiatedFeatures
+	errLock              sync.Mutex
+	errs                 chan error
+	errsClosed           bool
+	stateLock            sync.Mutex
+	state                SessionState
+	stateHooks           []StateChangeHook
+	userCloseHooks       []CloseHook
+	userErrorHooks       []ErrorHook
+	dispatcher           *Dispatcher
+	helloSendTimeout     time.Duration
+	helloReceiveTimeout  time.Duration
+	keepaliveInterval    time.Duration
+	keepaliveMaxMissed   int
+	redial               func() (Transport, error)
+	helloCapabilities    []string
+	listening            atomic.Bool
+	notificationStream   atomic.Bool
+	errorPolicy          ErrorPolicy
+	listenDone           chan struct{}
+	listenCtx            context.Context
+	manualListen         bool
+	started              bool
+	metrics              sessionMetrics
+	inFlight             chan struct{}
+	inFlightPolicy       InFlightPolicy
+	interleavePolicy     InterleavePolicy
+	subscription         chan struct{}
+	messageIDs           message.MessageIDGenerator
+	warningPolicy        WarningPolicy
+	optionErr            error
+}
+
+// NewSession creates a new NETCONF session using the provided transport layer, receiving the server hello.
+// When the hello cannot be received or parsed, or an option is invalid, the transport is closed and an error is
+// returned.
+func NewSession(t Transport, options ...SessionOption) (*Session, error) {
+	s := &Session{stopped: make(chan struct{}), listenDone: make(chan struct{}), errs: make(chan error, errorsBuffer)}
 	for _, opt := range options {
 		opt(s)
 	}
+	if s.optionErr != nil {
+		_ = t.Close()
+		return nil, s.optionErr
+	}
+	s.sendQueue.init()
 
 	if s.logger == nil {
 		s.logger = slog.New(slog.NewJSONHandler(io.Discard, nil))
 	}
+	if s.rpcTimeout <= 0 {
+		s.rpcTimeout = defaultRPCTimeout
+	}
+	if s.helloSendTimeout == 0 {
+		s.helloSendTimeout = defaultHelloTimeout
+	}
+	if s.helloReceiveTimeout == 0 {
+		s.helloReceiveTimeout = defaultHelloTimeout
+	}
+
+	s.Transport = s.setupTransport(t)
+
+	if err := s.receiveServerHello(); err != nil {
+		return nil, err
+	}
+
+	s.Listener = s.dispatcher
+	if s.Listener == nil {
+		s.Listener = NewDispatcher()
+	}
+	s.callbacks = newCallbackRunner(s.executor, s.logger, s.logArgs)
+
+	return s, nil
+}
+
+// setupTransport configures the transport following the session options, returning the transport to use.
+func (session *Session) setupTransport(t Transport) Transport {
+	if recoverer, ok := t.(interface{ SetFramingRecovery(bool) }); ok {
+		recoverer.SetFramingRecovery(session.framingRecovery)
+	}
+	if limiter, ok := t.(interface{ SetMaxMessageSize(int) }); ok {
+		limiter.SetMaxMessageSize(session.maxMessageSize)
+	}
+	if detector, ok := t.(interface {
+		SetFramingAutoDetect(bool, func(string, string))
+	}); ok {
+		detector.SetFramingAutoDetect(session.framingAutoDetect, func(from string, to string) {
+			session.framingVersion.Store(to)
+			session.logger.Warn("server framing does not match the negotiated version, switching framing", session.logArgs(
+				"negotiated", from,
+				"detected", to,
+			)...)
+		})
+	}
+	if session.capture != nil {
+		t = NewCaptureTransport(t, session.capture)
+	}
+	return t
+}
+
+// receiveServerHello receives and validates the server hello, failing the session on error.
+func (session *Session) receiveServerHello() error {
+	serverHello, err := session.receiveHelloTimeout()
+	if err != nil {
+		session.logger.Error("failed to receive server hello", session.logArgs("err", err)...)
+		session.fail(err)
+		return fmt.Errorf("fail to receive server hello: %w", err)
+	}
+	if err := validateHello(serverHello); err != nil {
+		session.logger.Error("invalid server hello", session.logArgs("err", err)...)
+		session.fail(err)
+		return err
+	}
+	session.sessionID.Store(serverHello.SessionID)
+	session.Capabilities = serverHello.Capabilities
+	session.features = ParseFeatures(serverHello.Capabilities)
+	return nil
+}
+
+// receiveHelloTimeout receives the server hello, giving up after the receive hello timeout, if any.
+// On timeout, the transport is closed as the server is not behaving as a NETCONF server.
+func (session *Session) receiveHelloTimeout() (*message.Hello, error) {
+	if session.helloReceiveTimeout <= 0 {
+		return session.ReceiveHello()
+	}
+
+	type result struct {
+		hello *message.Hello
+		err   error
+	}
+	received := make(chan result, 1)
+	go func() {
+		hello, err := session.ReceiveHello()
+		received <- result{hello, err}
+	}()
+
+	select {
+	case r := <-received:
+		return r.hello, r.err
+	case <-time.After(session.helloReceiveTimeout):
+		err := &HelloTimeoutError{Side: HelloReceive, Timeout: session.helloReceiveTimeout}
+		session.fail(err)
+		return new(message.Hello), err
+	}
+}
+
+// sendHelloTimeout sends the client hello, giving up after the send hello timeout, if any.
+// On timeout, the transport is closed as the server is not reading the messages.
+func (session *Session) sendHelloTimeout(hello []byte) error {
+	if session.helloSendTimeout <= 0 {
+		return session.send(hello)
+	}
+
+	sent := make(chan error, 1)
+	go func() {
+		sent <- session.send(hello)
+	}()
+
+	select {
+	case err := <-sent:
+		return err
+	case <-time.After(session.helloSendTimeout):
+		err := &HelloTimeoutError{Side: HelloSend, Timeout: session.helloSendTimeout}
+		session.fail(err)
+		return err
+	}
+}
+
+// WithCapabilities sets the capabilities advertised by SendHello when the provided hello has none.
+// It defaults to DefaultCapabilities.
+func WithCapabilities(capabilities ...string) SessionOption {
+	return func(s *Session) {
+		s.clientCapabilities = capabilities
+	}
+}
+
+// WithMessageIDGenerator sets the generator of the message-id of the RPCs sent by the session, replacing the
+// id they were created with, see message.NewCounterMessageID and message.NewPrefixMessageID. By default, the
+// RPCs are sent with the id they were created with.
+func WithMessageIDGenerator(generator message.MessageIDGenerator) SessionOption {
+	return func(s *Session) {
+		s.messageIDs = generator
+	}
+}
+
+// WithLogger sets the logger of the session. It discards the logs by default.
+func WithLogger(logger Logger) SessionOption {
+	return func(s *Session) {
+		s.logger = logger
+	}
+}
+
+// WithDispatcher sets the dispatcher of the session callbacks, e.g. to share registrations across sessions.
+// Each session still runs its callbacks with its own executor and logger, and only waits for its own callbacks
+// when closing. The CloseEventHandler and ErrorEventHandler registrations of a shared dispatcher receive the
+// events of all its sessions; use OnClose and Errors to follow a given session. See NewDispatcher.
+func WithDispatcher(dispatcher *Dispatcher) SessionOption {
+	return func(s *Session) {
+		s.dispatcher = dispatcher
+	}
+}
+
+// WithHelloTimeout bounds the time NewSession waits for the server hello, and the time SendHello takes to send
+// the client hello, so a port which is not a NETCONF server, or a hung device, fails the session in a bounded
+// time. It defaults to 30 seconds; a non-positive timeout waits indefinitely.
+// See WithSendHelloTimeout and WithReceiveHelloTimeout to bound each half differently.
+func WithHelloTimeout(timeout time.Duration) SessionOption {
+	return func(s *Session) {
+		WithSendHelloTimeout(timeout)(s)
+		WithReceiveHelloTimeout(timeout)(s)
+	}
+}
+
+// WithSendHelloTimeout bounds the time SendHello takes to send the client hello, the server not reading it
+// pointing to a broken NETCONF stack. It defaults to 30 seconds; a non-positive timeout waits indefinitely.
+func WithSendHelloTimeout(timeout time.Duration) SessionOption {
+	if timeout <= 0 {
+		timeout = -1
+	}
+	return func(s *Session) {
+		s.helloSendTimeout = timeout
+	}
+}
+
+// WithReceiveHelloTimeout bounds the time NewSession waits for the server hello, e.g. leaving more time to
+// slow devices. It defaults to 30 seconds; a non-positive timeout waits indefinitely.
+func WithReceiveHelloTimeout(timeout time.Duration) SessionOption {
+	if timeout <= 0 {
+		timeout = -1
+	}
+	return func(s *Session) {
+		s.helloReceiveTimeout = timeout
+	}
+}
+
+// HelloSide is the half of the hello exchange a HelloTimeoutError timed out on.
+type HelloSide string
 
-	s.Transport = t
+const (
+	// HelloSend is the sending of the client hello.
+	HelloSend HelloSide = "send"
+	// HelloReceive is the receiving of the server hello.
+	HelloReceive HelloSide = "receive"
+)
 
-	// Receive server Hello message
-	serverHello, _ := s.ReceiveHello()
-	s.SessionID = serverHello.SessionID
-	s.Capabilities = serverHello.Capabilities
+// HelloTimeoutError is returned by NewSession and SendHello when a half of the hello exchange timed out.
+// It matches context.DeadlineExceeded.
+type HelloTimeoutError struct {
+	Side    HelloSide
+	Timeout time.Duration
+}
 
-	s.Listener = &Dispatcher{}
-	s.Listener.init()
+// Error generates a string representation of the hello timeout error
+func (e *HelloTimeoutError) Error() string {
+	if e.Side == HelloSend {
+		return fmt.Sprintf("timeout after %s sending the client hello", e.Timeout)
+	}
+	return fmt.Sprintf("timeout after %s waiting for the server hello", e.Timeout)
+}
 
-	return s
+// Unwrap returns context.DeadlineExceeded
+func (e *HelloTimeoutError) Unwrap() error {
+	return context.DeadlineExceeded
 }
 
 // WithSessionLogger set the session logger provided in the session option.
+//
+// Deprecated: use WithLogger.
 func WithSessionLogger(logger Logger) SessionOption {
 	return func(s *Session) {
 		s.logger = logger
 	}
 }
 
+// WithRPCTimeout sets the timeout used by SyncRPC and AsyncRPCTimeout when called with a non-positive timeout,
+// and by AsyncRPC. It defaults to 30 seconds.
+func WithRPCTimeout(timeout time.Duration) SessionOption {
+	return func(s *Session) {
+		s.rpcTimeout = timeout
+	}
+}
+
+// WithCallbackExecutor runs the RPC reply, notification and error callbacks through the provided executor,
+// rather than in the goroutine receiving the messages. A panicking callback panics the submitted task, for the
+// executor to handle.
+func WithCallbackExecutor(executor Executor) SessionOption {
+	return func(s *Session) {
+		s.executor = executor
+	}
+}
+
+// WithLogFields adds contextual fields, such as the device name, to every log line emitted by the session.
+// Fields are provided as alternating keys and values, like for the Logger methods.
+func WithLogFields(args ...any) SessionOption {
+	return func(s *Session) {
+		s.logFields = append(s.logFields, args...)
+	}
+}
+
+// WithFramingRecovery makes the transport drop a message with malformed chunked framing and resynchronize on
+// the next message, instead of failing the session.
+func WithFramingRecovery() SessionOption {
+	return func(s *Session) {
+		s.framingRecovery = true
+	}
+}
+
+// WithFramingVersion forces the framing of the messages following the hello exchange, FramingVersion10 or
+// FramingVersion11, regardless of the capabilities advertised by the server. The client hello advertises the
+// matching base capability only, so the server is asked to use the same framing.
+// This accommodates servers advertising base:1.1 while implementing it incorrectly.
+// NewSession fails when the version is not one of them.
+func WithFramingVersion(version string) SessionOption {
+	return func(s *Session) {
+		if version != FramingVersion10 && version != FramingVersion11 {
+			s.invalidOption(fmt.Errorf("provided framing version %q is not supported, expecting %q or %q",
+				version, FramingVersion10, FramingVersion11))
+			return
+		}
+		s.forcedFramingVersion = version
+	}
+}
+
+// SessionID returns the session-id assigned by the server in its hello, defined by RFC 6241 as a non-zero
+// 32-bit unsigned integer. It is zero until the server hello is received.
+func (session *Session) SessionID() uint32 {
+	return session.sessionID.Load()
+}
+
+// ServerHello returns the hello received from the server, as is, nil until it is received.
+func (session *Session) ServerHello() []byte {
+	if hello := session.serverHello.Load(); hello != nil {
+		return *hello
+	}
+	return nil
+}
+
+// ClientHello returns the hello sent to the server by SendHello, nil until it is sent.
+func (session *Session) ClientHello() []byte {
+	if hello := session.clientHello.Load(); hello != nil {
+		return *hello
+	}
+	return nil
+}
+
+// WithBaseVersion makes the client hello advertise the provided base capability only, message.NetconfVersion10
+// or message.NetconfVersion11, rather than both, as some servers behave differently depending on what the client
+// advertises. The framing follows, as negotiated. SendHello fails when the server does not advertise it, and
+// NewSession when the capability is not one of them.
+func WithBaseVersion(capability string) SessionOption {
+	return func(s *Session) {
+		if capability != message.NetconfVersion10 && capability != message.NetconfVersion11 {
+			s.invalidOption(fmt.Errorf("provided base capability %q is not supported, expecting %q or %q",
+				capability, message.NetconfVersion10, message.NetconfVersion11))
+			return
+		}
+		s.baseVersion = capability
+	}
+}
+
+// invalidOption records the error of an invalid option, returned by NewSession.
+func (session *Session) invalidOption(err error) {
+	session.optionErr = errors.Join(session.optionErr, err)
+}
+
+// FramingVersion returns the framing used by the session, FramingVersion10 or FramingVersion11, once the hello
+// exchange is done, including a switch made by WithFramingAutoDetect. It is empty before.
+func (session *Session) FramingVersion() string {
+	version, _ := session.framingVersion.Load().(string)
+	return version
+}
+
+// WithFramingAutoDetect makes the transport detect the framing actually used by the server on the first message
+// received after the hello exchange, and switch to it when it does not match the negotiated version.
+func WithFramingAutoDetect() SessionOption {
+	return func(s *Session) {
+		s.framingAutoDetect = true
+	}
+}
+
+// WithMaxMessageSize caps the size, in bytes, of the messages the session accepts. A larger message is
+// discarded as it is read, and a MessageTooLargeError is dispatched to the ErrorEventHandler registration.
+// A size of zero, the default, means no limit.
+func WithMaxMessageSize(size int) SessionOption {
+	return func(s *Session) {
+		s.maxMessageSize = size
+	}
+}
+
+// logArgs prepends the session contextual fields to the provided log arguments.
+func (session *Session) logArgs(args ...any) []any {
+	fields := make([]any, 0, len(session.logFields)+len(args)+2)
+	fields = append(fields, session.logFields...)
+	fields = append(fields, "session-id", session.SessionID())
+	return append(fields, args...)
+}
+
 // SendHello send the initial message through NETCONF to advertise supported capability.
+// When the hello has no capabilities, the ones set with WithCapabilities are advertised.
 func (session *Session) SendHello(hello *message.Hello) error {
+	if hello.SessionID != 0 {
+		return &ProtocolError{Reason: fmt.Sprintf("client hello carries session-id %d, which only the server assigns", hello.SessionID)}
+	}
+	if len(hello.Capabilities) == 0 {
+		capabilities := session.clientCapabilities
+		if len(capabilities) == 0 {
+			capabilities = DefaultCapabilities
+		}
+		hello = &message.Hello{Capabilities: capabilities, SessionID: hello.SessionID}
+	}
+	if session.advertiseVersion {
+		capabilities := appendMissing(hello.Capabilities, VersionCapability())
+		hello = &message.Hello{Capabilities: capabilities, SessionID: hello.SessionID}
+	}
+	if session.baseVersion != "" {
+		if !hasCapability(session.Capabilities, session.baseVersion) {
+			err := fmt.Errorf("server does not advertise %s, the only base version advertised by the client", session.baseVersion)
+			session.fail(err)
+			return err
+		}
+		capabilities := appendMissing(removeCapability(hello.Capabilities, baseCapabilityPrefix), session.baseVersion)
+		hello = &message.Hello{Capabilities: capabilities, SessionID: hello.SessionID}
+	}
+	switch session.forcedFramingVersion {
+	case FramingVersion10:
+		capabilities := appendMissing(removeCapability(hello.Capabilities, message.NetconfVersion11), message.NetconfVersion10)
+		hello = &message.Hello{Capabilities: capabilities, SessionID: hello.SessionID}
+	case FramingVersion11:
+		capabilities := appendMissing(hello.Capabilities, message.NetconfVersion11)
+		hello = &message.Hello{Capabilities: capabilities, SessionID: hello.SessionID}
+	}
+
+	session.helloCapabilities = hello.Capabilities
+
 	val, err := xml.Marshal(hello)
 	if err != nil {
 		return err
@@ -88,29 +545,86 @@ func (session *Session) SendHello(hello *message.Hello) error {
 
 	header := []byte(xml.Header)
 	val = append(header, val...)
-	err = session.Transport.Send(val)
+	session.clientHello.Store(&val)
+	err = session.sendHelloTimeout(val)
 
 	// Set Transport version after sending hello-message,
 	// so the hello-message is sent using netconf:1.0 framing
-	session.Transport.SetVersion("v1.0")
-	for _, capability := range session.Capabilities {
-		if strings.Contains(capability, message.NetconfVersion11) {
-			session.Transport.SetVersion("v1.1")
-			break
-		}
+	version := FramingVersion10
+	if hasCapability(hello.Capabilities, message.NetconfVersion11) &&
+		hasCapability(session.Capabilities, message.NetconfVersion11) {
+		version = FramingVersion11
 	}
+	if session.forcedFramingVersion != "" {
+		version = session.forcedFramingVersion
+	}
+	session.framingVersion.Store(version)
+	session.Transport.SetVersion(version)
 
-	// FIXME shouldn't be in SendHello function
 	// Once the hello-message exchange is done, start listening to incoming messages
-	session.listen()
+	if !session.manualListen {
+		session.start()
+	}
+	if err != nil {
+		session.setState(StateFailed)
+	} else {
+		session.setState(StateEstablished)
+	}
 
 	return err
 }
 
+// InvalidHelloError is returned by NewSession when the server hello does not comply with RFC 6241, which requires
+// a session-id and at least one base capability.
+type InvalidHelloError struct {
+	Reason string
+	Hello  *message.Hello
+}
+
+// Error generates a string representation of the hello error
+func (e *InvalidHelloError) Error() string {
+	return "invalid server hello: " + e.Reason
+}
+
+// validateHello checks the server hello carries a session-id and a supported base capability.
+func validateHello(hello *message.Hello) error {
+	if hello.SessionID == 0 {
+		return &InvalidHelloError{Reason: "missing session-id", Hello: hello}
+	}
+	if !hasCapability(hello.Capabilities, message.NetconfVersion10) && !hasCapability(hello.Capabilities, message.NetconfVersion11) {
+		return &InvalidHelloError{Reason: fmt.Sprintf("no supported base capability in %v", hello.Capabilities), Hello: hello}
+	}
+	return nil
+}
+
+// removeCapability returns the capabilities without the provided one.
+func removeCapability(capabilities []string, capability string) []string {
+	var result []string
+	for _, c := range capabilities {
+		if !strings.Contains(c, capability) {
+			result = append(result, c)
+		}
+	}
+	return result
+}
+
+// hasCapability tells whether the capability is part of the provided ones.
+func hasCapability(capabilities []string, capability string) bool {
+	for _, c := range capabilities {
+		if strings.Contains(c, capability) {
+			return true
+		}
+	}
+	return false
+}
+
 // ReceiveHello is the first message received when connecting to a NETCONF server.
 // It provides the supported capabilities of the server.
 func (session *Session) ReceiveHello() (*message.Hello, error) {
+	session.closeLock.Lock()
 	session.IsClosed = false
+	session.closeLock.Unlock()
+	session.closing.Store(false)
 
 	hello := new(message.Hello)
 
@@ -118,6 +632,8 @@ func (session *Session) ReceiveHello() (*message.Hello, error) {
 	if err != nil {
 		return hello, err
 	}
+	session.activity.record(&session.activity.received)
+	session.serverHello.Store(&val)
 
 	err = xml.Unmarshal(val, hello)
 	return hello, err
@@ -125,71 +641,371 @@ func (session *Session) ReceiveHello() (*message.Hello, error) {
 
 // Close is used to close and end a session
 func (session *Session) Close() error {
+	session.closeLock.Lock()
+	session.IsClosed = true
+	session.closeLock.Unlock()
+	session.closing.Store(true)
+	if session.State() == StateConnecting {
+		// no listen goroutine to stop
+		session.setState(StateClosed)
+	}
+	err := session.Transport.Close()
+	if !session.listening.Load() {
+		session.notifyClose()
+	}
+	return err
+}
+
+// CloseGracefully ends the session as defined by RFC 6241: it sends a `close-session` and waits for its reply,
+// then waits for the invoked callbacks to return and for the session to stop receiving messages, and only then
+// closes the transport. The timeout, in seconds, bounds the whole sequence; a non-positive one uses the session
+// default timeout, see WithRPCTimeout.
+// The partial locks held, see PartialLock, are released first.
+// The transport is closed even when the server does not reply, in which case the error is returned.
+func (session *Session) CloseGracefully(timeout int32) error {
+	ctx, cancel := session.timeoutContext(timeout)
+	defer cancel()
+	return session.CloseGracefullyContext(ctx)
+}
+
+// CloseGracefullyContext is CloseGracefully bounded by the context rather than a timeout.
+func (session *Session) CloseGracefullyContext(ctx context.Context) error {
+	// the partial locks are released while the session still receives their replies
+	if !session.closing.Load() {
+		if err := session.releasePartialLocks(ctx); err != nil {
+			session.logger.Warn("failed to release partial locks", session.logArgs("err", err)...)
+		}
+	}
+
+	if session.closing.Swap(true) {
+		return ErrSessionClosed
+	}
+	session.setState(StateDraining)
+	session.closeLock.Lock()
 	session.IsClosed = true
-	return session.Transport.Close()
+	session.closeLock.Unlock()
+
+	// the listen goroutine stops once the reply, the last message of the session, is processed
+	rpc := message.NewCloseSession()
+	session.assignMessageID(rpc)
+	id := rpc.GetMessageID()
+	session.closeSessionID.Store(&id)
+	reply, err := session.syncRPC(ctx, rpc)
+	if errors.Is(err, context.DeadlineExceeded) {
+		err = fmt.Errorf("timeout while executing request: %w", err)
+	}
+	if err == nil {
+		if replyErr := session.replyErr(reply); replyErr != nil {
+			err = fmt.Errorf("close-session failed with errors: %w", replyErr)
+		}
+	}
+	if err != nil {
+		session.closeSessionID.Store(nil)
+		err = fmt.Errorf("fail to close session: %w", err)
+	}
+	if err == nil {
+		// the session stops once the invoked callbacks returned
+		select {
+		case <-session.stoppedChan():
+		case <-ctx.Done():
+			err = fmt.Errorf("fail to close session: timeout while waiting for the pending callbacks: %w", ctx.Err())
+		}
+	}
+
+	if closeErr := session.Transport.Close(); err == nil && closeErr != nil && !errors.Is(closeErr, io.EOF) {
+		err = closeErr
+	}
+	return err
+}
+
+// Closed tells whether the session was closed. Unlike IsClosed, it is safe to call from any goroutine.
+func (session *Session) Closed() bool {
+	return session.closing.Load()
+}
+
+// NotificationStreamCreated tells whether a notification stream was created by CreateNotificationStream and not
+// completed yet. It is safe to call from any goroutine.
+func (session *Session) NotificationStreamCreated() bool {
+	return session.notificationStream.Load()
+}
+
+// TransportStats returns the counters of the session transport, or zero values when the transport
+// does not report any.
+func (session *Session) TransportStats() TransportStats {
+	if reporter, ok := session.Transport.(StatsReporter); ok {
+		return reporter.Stats()
+	}
+	return TransportStats{}
+}
+
+// send writes a message on the transport, one at a time and in the order they are sent, so concurrent messages
+// do not interleave, see sendQueue.
+func (session *Session) send(data []byte) error {
+	select {
+	case <-session.stoppedChan():
+		return session.closedError()
+	default:
+	}
+	session.sendQueue.enter()
+	defer session.sendQueue.leave()
+	session.sendLock.Lock()
+	defer session.sendLock.Unlock()
+	if err := session.Transport.Send(data); err != nil {
+		return err
+	}
+	session.activity.record(&session.activity.sent)
+	return nil
+}
+
+// receiving tells whether the listen goroutine keeps receiving messages: until the session is closed, or
+// until the reply to the close-session is received when closing gracefully.
+func (session *Session) receiving() bool {
+	return !session.closing.Load() || session.closeSessionID.Load() != nil
 }
 
 // Listen starts a goroutine that listen to incoming messages and dispatch them as they are processed.
 func (session *Session) listen() {
+	session.listening.Store(true)
+	session.closeLock.Lock()
+	done := session.listenDone
+	session.closeLock.Unlock()
 	go func() {
-		for ok := true; ok; ok = !session.IsClosed {
-			rawXML, err := session.Transport.Receive()
-			if err != nil {
-				// What should we do here?
-				continue
+		defer close(done)
+
+		// dropped reports the error of a message and tells whether the error policy keeps the session open
+		consecutive := 0
+		dropped := func(err error) bool {
+			session.reportError(err)
+			consecutive++
+			if !session.errorPolicy.fails(consecutive) {
+				return true
 			}
-			var rawReply = string(rawXML)
-			isRpcReply, err := regexp.MatchString(message.RpcReplyRegex, rawReply)
+			policyErr := &ErrorPolicyError{Consecutive: consecutive, Err: err}
+			session.logger.Error("closing session on receive errors", session.logArgs("err", policyErr)...)
+			session.fail(policyErr)
+			return false
+		}
+
+		for ok := true; ok; ok = session.receiving() {
+			received, err := session.receive()
 			if err != nil {
-				session.logger.Error("failed to match RPCReply",
-					"rawReply", rawReply,
+				var tooLargeErr *MessageTooLargeError
+				if errors.As(err, &tooLargeErr) {
+					session.logger.Error("dropped message exceeding the maximum size", session.logArgs(
+						"err", err,
+					)...)
+					if dropped(err) {
+						continue
+					}
+					break
+				}
+				var framingErr *FramingError
+				if errors.As(err, &framingErr) {
+					if framingErr.Recovered {
+						session.logger.Warn("dropped message with invalid framing", session.logArgs(
+							"err", err,
+						)...)
+						if dropped(err) {
+							continue
+						}
+						break
+					}
+					session.logger.Error("closing session on invalid framing", session.logArgs(
+						"err", err,
+					)...)
+					session.reportError(err)
+					session.fail(err)
+					break
+				}
+				if session.closing.Load() {
+					break
+				}
+				if errors.Is(err, io.EOF) {
+					session.reportError(&ReceiveError{Err: err})
+					// the server closed the session, e.g. on a kill-session from another client
+					session.logger.Warn("closing session closed by the server", session.logArgs()...)
+					session.setCloseCause(err)
+					_ = session.Close()
+					break
+				}
+				session.logger.Error("failed to receive message", session.logArgs(
 					"err", err,
-				)
-				continue
+				)...)
+				if dropped(&ReceiveError{Err: err}) {
+					continue
+				}
+				break
 			}
+			session.activity.record(&session.activity.received)
+			rawXML := received.raw
 
-			if isRpcReply {
-				rpcReply, err := message.NewRPCReply(rawXML)
+			if received.root == "rpc-reply" {
+				rpcReply, err := received.reply, received.err
 				if err != nil {
-					session.logger.Error("failed to marshall message into an RPCReply",
+					session.logger.Error("failed to marshall message into an RPCReply", session.logArgs(
 						"err", err,
-					)
-					continue
+					)...)
+					if dropped(&ReceiveError{Message: rawXML, Err: err}) {
+						continue
+					}
+					break
+				}
+				if id := session.closeSessionID.Load(); id != nil && *id == rpcReply.MessageID {
+					session.closeSessionID.Store(nil)
+				}
+				consecutive = 0
+				if !session.Listener.dispatch(session.callbacks, rpcReply.MessageID, EventTypeRPCReply, rpcReply) {
+					reason := fmt.Sprintf("rpc-reply message-id %q matches no outstanding request", rpcReply.MessageID)
+					if rpcReply.MessageID == "" {
+						reason = "rpc-reply without message-id"
+					}
+					session.logger.Warn("dropped unexpected rpc-reply", session.logArgs("reason", reason)...)
+					session.reportError(&ProtocolError{Reason: reason, Message: rawXML})
 				}
-				session.Listener.Dispatch(rpcReply.MessageID, 0, rpcReply)
 				continue
 			}
 
-			isNotification, err := regexp.MatchString(message.NotificationMessageRegex, rawReply)
-			if err != nil {
-				session.logger.Error("failed to match notification",
-					"rawReply", rawReply,
-					"err", err,
-				)
-				continue
-			}
-			if isNotification {
-				notification, err := message.NewNotification(rawXML)
+			if received.root == "notification" {
+				notification, err := received.notification, received.err
 				if err != nil {
-					session.logger.Error("failed to marshall message into an Notification",
+					session.logger.Error("failed to marshall message into an Notification", session.logArgs(
 						"err", err,
-					)
-					continue
+					)...)
+					if dropped(&ReceiveError{Message: rawXML, Err: err}) {
+						continue
+					}
+					break
+				}
+				consecutive = 0
+				session.metrics.notified()
+				if isNotificationComplete(notification) {
+					session.subscriptionCompleted()
 				}
 				// In case we are using straight create-subscription, there is no way to discern who is the owner
 				// of the received notification, hence we use a default handler.
 				if notification.GetSubscriptionID() == "" {
-					session.Listener.Dispatch(message.NetconfNotificationStreamHandler, 1, notification)
+					session.Listener.dispatch(session.callbacks, message.NetconfNotificationStreamHandler, EventTypeNotification, notification)
 				} else {
-					session.Listener.Dispatch(notification.GetSubscriptionID(), 1, notification)
+					session.Listener.dispatch(session.callbacks, notification.GetSubscriptionID(), EventTypeNotification, notification)
 				}
 				continue
 			}
 
-			session.logger.Error("unknown received message",
+			session.logger.Error("unknown received message", session.logArgs(
 				"rawXML", rawXML,
-			)
+			)...)
+			if !dropped(&ReceiveError{Message: rawXML, Err: ErrUnknownMessage}) {
+				break
+			}
 		}
-		session.logger.Info("exit receiving loop")
+		session.logger.Info("exit receiving loop", session.logArgs()...)
+		session.setState(StateClosed)
+		session.Listener.dispatch(session.callbacks, CloseEventHandler, EventTypeClose, session.closedError())
+		// the callbacks run by an executor may still deliver the last replies
+		session.callbacks.wait()
+		session.runCloseHooks()
+		session.notifyClose()
 	}()
 }
+
+// receivedMessage is a message received by the session.
+type receivedMessage struct {
+	// raw is the whole message
+	raw []byte
+	// root is the local name of the root element, empty when the message is not XML
+	root string
+	// reply or notification is the decoded message, according to its root element
+	reply        *message.RPCReply
+	notification *message.Notification
+	// err is why the message could not be decoded
+	err error
+}
+
+// receive waits for the next message, and decodes the rpc-reply and notification messages as they stream in
+// from the transport frame, keeping their raw XML. The error reports a failure of the transport, e.g. a
+// FramingError, while the decoding errors are reported by the received message.
+func (session *Session) receive() (*receivedMessage, error) {
+	frame, err := ReceiveFrame(session.Transport)
+	if err != nil {
+		return nil, err
+	}
+	var raw bytes.Buffer
+	decoder := xml.NewDecoder(io.TeeReader(frame, &raw))
+	// rawXML reads the rest of the message, reporting the transport errors met on the way
+	rawXML := func() ([]byte, error) {
+		if _, err := io.Copy(&raw, frame); err != nil {
+			return nil, err
+		}
+		return raw.Bytes(), nil
+	}
+
+	received := &receivedMessage{}
+	for received.root == "" {
+		token, err := decoder.Token()
+		if err != nil {
+			break
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		received.root = start.Name.Local
+		switch received.root {
+		case "rpc-reply":
+			received.reply, received.err = message.DecodeRPCReply(decoder, &start, rawXML)
+		case "notification":
+			received.notification, received.err = message.DecodeNotification(decoder, &start, rawXML)
+		}
+	}
+
+	if received.raw, err = rawXML(); err != nil {
+		return nil, err
+	}
+	return received, nil
+}
+
+// onClose registers a function called once the session stops receiving messages.
+// It is called right away if the session already stopped.
+func (session *Session) onClose(hook func()) {
+	session.closeLock.Lock()
+	if !session.closed {
+		session.closeHooks = append(session.closeHooks, hook)
+		session.closeLock.Unlock()
+		return
+	}
+	session.closeLock.Unlock()
+	hook()
+}
+
+// setCloseCause records why the session is closed, unless a cause is already known.
+func (session *Session) setCloseCause(err error) {
+	session.closeLock.Lock()
+	defer session.closeLock.Unlock()
+	if session.closeCause == nil {
+		session.closeCause = err
+	}
+}
+
+// closedError returns the error of the RPCs sent once the session stopped receiving messages.
+func (session *Session) closedError() error {
+	session.closeLock.Lock()
+	defer session.closeLock.Unlock()
+	return &SessionClosedError{SessionID: session.SessionID(), Err: session.closeCause}
+}
+
+// runCloseHooks calls the functions registered with onClose.
+func (session *Session) runCloseHooks() {
+	session.closeLock.Lock()
+	if !session.closed && session.stopped != nil {
+		close(session.stopped)
+	}
+	session.closed = true
+	hooks := session.closeHooks
+	session.closeHooks = nil
+	session.closeLock.Unlock()
+
+	for _, hook := range hooks {
+		hook()
+	}
+	session.closeErrors()
+}
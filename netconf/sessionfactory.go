@@ -5,29 +5,55 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/openshift-telco/go-netconf-client/netconf/message"
 	"golang.org/x/crypto/ssh"
 )
 
 // NewSessionFromSSHConfig established a NETCONF session connecting to the target using ssh client configuration.
 func NewSessionFromSSHConfig(target string, config *ssh.ClientConfig, options ...SessionOption) (*Session, error) {
-	t, err := DialSSH(target, config)
+	t, err := DialSSH(target, config, dialOptionsFrom(options)...)
 	if err != nil {
 		return nil, fmt.Errorf("DialSSHTimeout: %w", err)
 	}
 
-	s := NewSession(t, options...)
+	redial := func() (Transport, error) { return DialSSH(target, config, dialOptionsFrom(options)...) }
+	s, err := NewSession(t, withDevice(target, withRedial(redial, options))...)
+	if err != nil {
+		return nil, fmt.Errorf("NewSession: %w", err)
+	}
 
 	return s, nil
 }
 
 // NewSessionFromSSHConfigTimeout established a NETCONF session connecting to the target using ssh client configuration with timeout.
 func NewSessionFromSSHConfigTimeout(ctx context.Context, target string, config *ssh.ClientConfig, timeout time.Duration, options ...SessionOption) (*Session, error) {
-	t, err := DialSSHTimeout(target, config, timeout)
+	t, err := DialSSHTimeout(target, config, timeout, dialOptionsFrom(options)...)
 	if err != nil {
 		return nil, fmt.Errorf("DialSSHTimeout: %w", err)
 	}
 
-	s := NewSession(t, options...)
+	redial := func() (Transport, error) { return DialSSHTimeout(target, config, timeout, dialOptionsFrom(options)...) }
+	s, err := NewSession(t, withDevice(target, withRedial(redial, options))...)
+	if err != nil {
+		return nil, fmt.Errorf("NewSession: %w", err)
+	}
+
+	return s, nil
+}
+
+// NewSessionFromSSHConfigMulti established a NETCONF session connecting to the first reachable of the candidate
+// targets using ssh client configuration. See DialSSHMulti for how the targets are tried.
+func NewSessionFromSSHConfigMulti(targets []string, config *ssh.ClientConfig, timeout time.Duration, options ...SessionOption) (*Session, error) {
+	t, err := DialSSHMulti(targets, config, timeout, dialOptionsFrom(options)...)
+	if err != nil {
+		return nil, fmt.Errorf("DialSSHMulti: %w", err)
+	}
+
+	redial := func() (Transport, error) { return DialSSHMulti(targets, config, timeout, dialOptionsFrom(options)...) }
+	s, err := NewSession(t, withDevice(t.sshClient.RemoteAddr().String(), withRedial(redial, options))...)
+	if err != nil {
+		return nil, fmt.Errorf("NewSession: %w", err)
+	}
 
 	return s, nil
 }
@@ -39,7 +65,36 @@ func NewSessionFromSSHClient(ctx context.Context, client *ssh.Client, options ..
 		return nil, fmt.Errorf("NoDialSSH: %w", err)
 	}
 
-	s := NewSession(t, options...)
+	s, err := NewSession(t, withDevice(client.RemoteAddr().String(), options)...)
+	if err != nil {
+		return nil, fmt.Errorf("NewSession: %w", err)
+	}
 
 	return s, nil
 }
+
+// Connect established a NETCONF session connecting to the target using ssh client configuration, and completes
+// the hello exchange: the server hello is validated by NewSession, and the client hello, advertising the
+// capabilities set with WithCapabilities, is sent. The returned session is ready for RPCs.
+func Connect(target string, config *ssh.ClientConfig, options ...SessionOption) (*Session, error) {
+	s, err := NewSessionFromSSHConfig(target, config, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.SendHello(&message.Hello{}); err != nil {
+		_ = s.Close()
+		return nil, fmt.Errorf("SendHello: %w", err)
+	}
+	return s, nil
+}
+
+// withRedial prepends the redial option to the provided options, so it can be overridden.
+func withRedial(dial func() (Transport, error), options []SessionOption) []SessionOption {
+	return append([]SessionOption{WithRedial(dial)}, options...)
+}
+
+// withDevice prepends the device log field to the provided options, so every log line identifies the device.
+func withDevice(device string, options []SessionOption) []SessionOption {
+	return append([]SessionOption{WithLogFields("device", device)}, options...)
+}
@@ -0,0 +1,190 @@
+/*
+Copyright 2021. Alexis de Talhouët
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package netconf
+
+// SessionState is a step of the session lifecycle.
+type SessionState int32
+
+const (
+	// StateConnecting is the state of a session until the hello messages are exchanged.
+	StateConnecting SessionState = iota
+	// StateEstablished is the state of a session ready for RPCs.
+	StateEstablished
+	// StateDraining is the state of a session closing gracefully, see CloseGracefully.
+	StateDraining
+	// StateClosed is the state of a session closed by the client, or by the server, which stopped receiving messages.
+	StateClosed
+	// StateFailed is the state of a session closed on an error: failed hello exchange, invalid framing or missed
+	// keepalive replies.
+	StateFailed
+)
+
+// String returns the name of the state.
+func (s SessionState) String() string {
+	switch s {
+	case StateConnecting:
+		return "connecting"
+	case StateEstablished:
+		return "established"
+	case StateDraining:
+		return "draining"
+	case StateClosed:
+		return "closed"
+	case StateFailed:
+		return "failed"
+	}
+	return "unknown"
+}
+
+// terminal tells whether the session can no longer change state.
+func (s SessionState) terminal() bool {
+	return s == StateClosed || s == StateFailed
+}
+
+// StateChangeHook is called upon each transition of the session state.
+type StateChangeHook func(session *Session, from SessionState, to SessionState)
+
+// WithStateChangeHook registers a hook called upon each transition of the session state, starting with the
+// ones happening while the session is created, see OnStateChange.
+func WithStateChangeHook(hook StateChangeHook) SessionOption {
+	return func(s *Session) {
+		s.stateHooks = append(s.stateHooks, hook)
+	}
+}
+
+// State returns the current state of the session.
+func (session *Session) State() SessionState {
+	session.stateLock.Lock()
+	defer session.stateLock.Unlock()
+	return session.state
+}
+
+// OnStateChange registers a hook called upon each transition of the session state. Hooks are called in the
+// goroutine causing the transition, in the order they are registered, and must not block.
+func (session *Session) OnStateChange(hook StateChangeHook) {
+	session.stateLock.Lock()
+	defer session.stateLock.Unlock()
+	session.stateHooks = append(session.stateHooks, hook)
+}
+
+// CloseHook is called once the session stopped receiving messages, with the cause of the close: nil when the
+// session is closed by the client, io.EOF when the server ends it, or the error having failed it.
+type CloseHook func(session *Session, err error)
+
+// ErrorHook is called upon an unrecoverable error, before the session is closed because of it.
+type ErrorHook func(session *Session, err error)
+
+// WithCloseHook registers a hook called once the session is closed, see OnClose.
+func WithCloseHook(hook CloseHook) SessionOption {
+	return func(s *Session) {
+		s.userCloseHooks = append(s.userCloseHooks, hook)
+	}
+}
+
+// WithErrorHook registers a hook called upon unrecoverable errors, including the ones happening while the
+// session is created, see OnError.
+func WithErrorHook(hook ErrorHook) SessionOption {
+	return func(s *Session) {
+		s.userErrorHooks = append(s.userErrorHooks, hook)
+	}
+}
+
+// OnClose registers a hook called once the session is closed, gracefully or not, after the pending RPCs failed
+// and the invoked callbacks returned. It allows releasing the resources tied to the session.
+// Hooks are kept upon Reconnect, and called again when the new connection is closed.
+func (session *Session) OnClose(hook CloseHook) {
+	session.stateLock.Lock()
+	defer session.stateLock.Unlock()
+	session.userCloseHooks = append(session.userCloseHooks, hook)
+}
+
+// OnError registers a hook called upon unrecoverable errors: failed hello exchange, invalid framing or missed
+// keepalive replies. The session is closed right after the hooks return, and the close hooks are then called
+// with the same error. Hooks are kept upon Reconnect.
+func (session *Session) OnError(hook ErrorHook) {
+	session.stateLock.Lock()
+	defer session.stateLock.Unlock()
+	session.userErrorHooks = append(session.userErrorHooks, hook)
+}
+
+// setState transitions the session to the provided state, unless it already reached a terminal one.
+func (session *Session) setState(state SessionState) {
+	session.stateLock.Lock()
+	from := session.state
+	if from == state || from.terminal() {
+		session.stateLock.Unlock()
+		return
+	}
+	session.state = state
+	hooks := append([]StateChangeHook(nil), session.stateHooks...)
+	session.stateLock.Unlock()
+
+	session.logger.Info("session state changed", session.logArgs("from", from.String(), "to", state.String())...)
+	for _, hook := range hooks {
+		hook(session, from, state)
+	}
+}
+
+// resetState transitions a stopped session back to StateConnecting, as it is reconnected.
+func (session *Session) resetState() {
+	session.stateLock.Lock()
+	from := session.state
+	session.state = StateConnecting
+	hooks := append([]StateChangeHook(nil), session.stateHooks...)
+	session.stateLock.Unlock()
+
+	if from == StateConnecting {
+		return
+	}
+	session.logger.Info("session state changed", session.logArgs("from", from.String(), "to", StateConnecting.String())...)
+	for _, hook := range hooks {
+		hook(session, from, StateConnecting)
+	}
+}
+
+// fail closes the session on an error, transitioning it to StateFailed.
+func (session *Session) fail(err error) {
+	session.setCloseCause(err)
+	session.recentErrors.record(err)
+	session.stateLock.Lock()
+	hooks := append([]ErrorHook(nil), session.userErrorHooks...)
+	session.stateLock.Unlock()
+	for _, hook := range hooks {
+		hook(session, err)
+	}
+	session.setState(StateFailed)
+	_ = session.Close()
+}
+
+// notifyClose calls the hooks registered with OnClose, once per connection.
+func (session *Session) notifyClose() {
+	session.closeLock.Lock()
+	if session.closeNotified {
+		session.closeLock.Unlock()
+		return
+	}
+	session.closeNotified = true
+	cause := session.closeCause
+	session.closeLock.Unlock()
+
+	session.stateLock.Lock()
+	hooks := append([]CloseHook(nil), session.userCloseHooks...)
+	session.stateLock.Unlock()
+	for _, hook := range hooks {
+		hook(session, cause)
+	}
+}
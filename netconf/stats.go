@@ -0,0 +1,151 @@
+/*
+Copyright 2021. Alexis de Talhouët
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package netconf
+
+import (
+	"sync"
+	"time"
+)
+
+// OperationStats holds the counters of the RPCs of a given operation, e.g. get-config.
+type OperationStats struct {
+	// Sent is the number of RPCs sent.
+	Sent uint64
+	// Replied is the number of replies received, including the ones carrying rpc-errors.
+	Replied uint64
+	// Errors is the number of replies carrying rpc-errors.
+	Errors uint64
+	// Failures is the number of RPCs which did not get a reply: timeouts and closed sessions.
+	Failures uint64
+	// TotalLatency is the cumulated time between sending the RPCs and receiving their reply.
+	TotalLatency time.Duration
+	// MaxLatency is the longest time between sending an RPC and receiving its reply.
+	MaxLatency time.Duration
+	// LastLatency is the latency of the latest reply.
+	LastLatency time.Duration
+}
+
+// AverageLatency returns the mean time between sending the RPCs and receiving their reply.
+func (s OperationStats) AverageLatency() time.Duration {
+	if s.Replied == 0 {
+		return 0
+	}
+	return s.TotalLatency / time.Duration(s.Replied)
+}
+
+// SessionStats is a snapshot of the counters of a session, since it was created.
+type SessionStats struct {
+	// RPCsSent is the number of RPCs sent.
+	RPCsSent uint64
+	// RepliesReceived is the number of replies received to the RPCs sent.
+	RepliesReceived uint64
+	// RPCErrors is the number of replies carrying rpc-errors.
+	RPCErrors uint64
+	// RPCFailures is the number of RPCs which could not be sent, or did not get a reply.
+	RPCFailures uint64
+	// NotificationsReceived is the number of notifications received.
+	NotificationsReceived uint64
+	// ReceiveErrors is the number of errors raised while receiving messages, see Errors.
+	ReceiveErrors uint64
+	// Operations holds the counters by operation name.
+	Operations map[string]OperationStats
+	// Transport holds the counters of the transport, see TransportStats.
+	Transport TransportStats
+}
+
+// sessionMetrics holds the counters of a session, safe for concurrent updates.
+type sessionMetrics struct {
+	lock       sync.Mutex
+	stats      SessionStats
+	operations map[string]*OperationStats
+}
+
+// Stats returns a snapshot of the counters of the session.
+func (session *Session) Stats() SessionStats {
+	m := &session.metrics
+	m.lock.Lock()
+	stats := m.stats
+	stats.Operations = make(map[string]OperationStats, len(m.operations))
+	for name, operation := range m.operations {
+		stats.Operations[name] = *operation
+	}
+	m.lock.Unlock()
+
+	stats.Transport = session.TransportStats()
+	return stats
+}
+
+// operation returns the counters of the named operation. The lock must be held.
+func (m *sessionMetrics) operation(name string) *OperationStats {
+	if m.operations == nil {
+		m.operations = make(map[string]*OperationStats)
+	}
+	operation, ok := m.operations[name]
+	if !ok {
+		operation = &OperationStats{}
+		m.operations[name] = operation
+	}
+	return operation
+}
+
+// sent records an RPC sent.
+func (m *sessionMetrics) sent(name string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.stats.RPCsSent++
+	m.operation(name).Sent++
+}
+
+// replied records the reply to an RPC, received after the provided latency.
+func (m *sessionMetrics) replied(name string, latency time.Duration, withErrors bool) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	operation := m.operation(name)
+	m.stats.RepliesReceived++
+	operation.Replied++
+	if withErrors {
+		m.stats.RPCErrors++
+		operation.Errors++
+	}
+	operation.TotalLatency += latency
+	operation.LastLatency = latency
+	if latency > operation.MaxLatency {
+		operation.MaxLatency = latency
+	}
+}
+
+// failed records an RPC which could not be sent, or did not get a reply.
+func (m *sessionMetrics) failed(name string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.stats.RPCFailures++
+	m.operation(name).Failures++
+}
+
+// notified records a notification received.
+func (m *sessionMetrics) notified() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.stats.NotificationsReceived++
+}
+
+// receiveFailed records an error raised while receiving messages.
+func (m *sessionMetrics) receiveFailed() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.stats.ReceiveErrors++
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2021. Alexis de Talhouët
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package netconf
+
+import (
+	"encoding/xml"
+	"sync"
+
+	"github.com/openshift-telco/go-netconf-client/netconf/message"
+)
+
+// SubscribeChan creates a notification stream subscription, see Session.CreateNotificationStream, and delivers
+// each received notification, decoded into T, on the returned channel. Notifications failing to decode are
+// logged and dropped.
+// The channel is closed when the subscription ends, i.e. upon notificationComplete or when the session is
+// closed, once all the decoded notifications are delivered. It must be drained, as the delivery blocks the
+// reception of the subsequent messages of the session.
+// Upon notificationComplete, the notification stream registration is removed, so a new subscription can be
+// created once the channel is closed.
+func SubscribeChan[T any](
+	session *Session, stream string, decoder func(*message.Notification) (T, error),
+) (<-chan T, error) {
+	out := make(chan T)
+	var lock sync.Mutex
+	var pending sync.WaitGroup
+	closed := false
+
+	end := func() {
+		lock.Lock()
+		defer lock.Unlock()
+		if closed {
+			return
+		}
+		closed = true
+		go func() {
+			pending.Wait()
+			close(out)
+		}()
+	}
+
+	callback := func(event Event) {
+		notification := event.Notification()
+		if isNotificationComplete(notification) {
+			session.Listener.Remove(message.NetconfNotificationStreamHandler)
+			session.notificationStream.Store(false)
+			end()
+			return
+		}
+		value, err := decoder(notification)
+		if err != nil {
+			session.logger.Error("failed to decode notification", session.logArgs(
+				"stream", stream,
+				"err", err,
+			)...)
+			return
+		}
+
+		lock.Lock()
+		if closed {
+			lock.Unlock()
+			return
+		}
+		pending.Add(1)
+		lock.Unlock()
+		defer pending.Done()
+		out <- value
+	}
+
+	if err := session.CreateNotificationStream(0, "", "", stream, callback); err != nil {
+		return nil, err
+	}
+	session.onClose(end)
+	return out, nil
+}
+
+// XMLNotificationDecoder returns a decoder unmarshalling the whole notification message into T, for use with
+// SubscribeChan.
+func XMLNotificationDecoder[T any]() func(*message.Notification) (T, error) {
+	return func(notification *message.Notification) (T, error) {
+		var value T
+		err := xml.Unmarshal([]byte(notification.RawReply), &value)
+		return value, err
+	}
+}
@@ -0,0 +1,124 @@
+/*
+Copyright 2021. Alexis de Talhouët
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package netconf
+
+import (
+	"sync"
+	"time"
+
+	"github.com/openshift-telco/go-netconf-client/netconf/message"
+)
+
+// SupportBundle gathers everything needed to reproduce a failed RPC: what was sent, what was received, when,
+// and the state of the session at that time. It is meant to be attached to bug reports, hence the JSON tags.
+type SupportBundle struct {
+	MessageID string             `json:"messageId"`
+	Operation string             `json:"operation"`
+	Request   string             `json:"request"`
+	RawReply  string             `json:"rawReply,omitempty"`
+	Errors    []message.RPCError `json:"errors,omitempty"`
+	Failure   string             `json:"failure,omitempty"`
+	SentAt    time.Time          `json:"sentAt"`
+	ReplyAt   time.Time          `json:"replyAt,omitempty"`
+	Duration  time.Duration      `json:"duration"`
+	Session   SessionSnapshot    `json:"session"`
+}
+
+// SessionSnapshot is the state of the session when a SupportBundle was captured.
+type SessionSnapshot struct {
+	SessionID    uint32         `json:"sessionId"`
+	Capabilities []string       `json:"capabilities"`
+	IsClosed     bool           `json:"isClosed"`
+	Transport    TransportStats `json:"transport"`
+	Version      string         `json:"version"`
+}
+
+// supportBundles keeps the most recent bundles of a session.
+type supportBundles struct {
+	lock    sync.Mutex
+	size    int
+	bundles []SupportBundle
+}
+
+// WithSupportBundles captures a SupportBundle for each failed SyncRPC, i.e. failing to send, timing out or
+// replying with rpc-error, keeping the size most recent ones. See Session.SupportBundles.
+func WithSupportBundles(size int) SessionOption {
+	return func(s *Session) {
+		s.supportBundles = &supportBundles{size: size}
+	}
+}
+
+// SupportBundles returns the captured bundles, oldest first. It returns nil unless WithSupportBundles is used.
+func (session *Session) SupportBundles() []SupportBundle {
+	if session.supportBundles == nil {
+		return nil
+	}
+	session.supportBundles.lock.Lock()
+	defer session.supportBundles.lock.Unlock()
+	return append([]SupportBundle(nil), session.supportBundles.bundles...)
+}
+
+// SupportBundle returns the captured bundle of the RPC with the provided message-id, if any.
+func (session *Session) SupportBundle(messageID string) (SupportBundle, bool) {
+	for _, bundle := range session.SupportBundles() {
+		if bundle.MessageID == messageID {
+			return bundle, true
+		}
+	}
+	return SupportBundle{}, false
+}
+
+// recordFailure captures a SupportBundle for the failed RPC when enabled.
+// reply is nil when no reply was received, and failure is nil when the server replied with rpc-error.
+func (session *Session) recordFailure(
+	operation message.RPCMethod, request []byte, sentAt time.Time, reply *message.RPCReply, failure error,
+) {
+	if session.supportBundles == nil || session.supportBundles.size <= 0 {
+		return
+	}
+
+	bundle := SupportBundle{
+		MessageID: operation.GetMessageID(),
+		Operation: operationName(request),
+		Request:   string(request),
+		SentAt:    sentAt,
+		Duration:  time.Since(sentAt),
+		Session: SessionSnapshot{
+			SessionID:    session.SessionID(),
+			Capabilities: append([]string(nil), session.Capabilities...),
+			IsClosed:     session.Closed(),
+			Transport:    session.TransportStats(),
+			Version:      Version(),
+		},
+	}
+	if reply != nil {
+		bundle.RawReply = reply.RawReply
+		bundle.Errors = reply.Errors
+		bundle.ReplyAt = sentAt.Add(bundle.Duration)
+	}
+	if failure != nil {
+		bundle.Failure = failure.Error()
+	}
+
+	bundles := session.supportBundles
+	bundles.lock.Lock()
+	defer bundles.lock.Unlock()
+	bundles.bundles = append(bundles.bundles, bundle)
+	if len(bundles.bundles) > bundles.size {
+		bundles.bundles = bundles.bundles[len(bundles.bundles)-bundles.size:]
+	}
+}
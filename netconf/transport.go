@@ -16,6 +16,8 @@ import (
 	"io"
 	"regexp"
 	"strconv"
+	"sync/atomic"
+	"time"
 )
 
 const (
@@ -24,6 +26,13 @@ const (
 	msgSeparatorV11 = "\n##\n"
 )
 
+const (
+	// FramingVersion10 is the transport version using the end-of-message framing of NETCONF 1.0
+	FramingVersion10 = "v1.0"
+	// FramingVersion11 is the transport version using the chunked framing of NETCONF 1.1
+	FramingVersion11 = "v1.1"
+)
+
 // Transport interface defines what characteristics make up a NETCONF transport
 // layer object.
 type Transport interface {
@@ -33,14 +42,127 @@ type Transport interface {
 	SetVersion(version string)
 }
 
+// TransportStats holds the counters of a transport.
+type TransportStats struct {
+	// BytesSent is the number of bytes written, framing included.
+	BytesSent uint64
+	// BytesReceived is the number of bytes read, framing included.
+	BytesReceived uint64
+	// FramesSent is the number of NETCONF messages sent.
+	FramesSent uint64
+	// FramesReceived is the number of NETCONF messages received.
+	FramesReceived uint64
+	// FramingErrors is the number of received messages that could not be decoded.
+	FramingErrors uint64
+	// LastActivity is the time of the latest successful read or write, zero if none occurred.
+	LastActivity time.Time
+}
+
+// StatsReporter is implemented by transports exposing their counters.
+type StatsReporter interface {
+	Stats() TransportStats
+}
+
+// transportCounters holds the transport counters, safe for concurrent updates.
+type transportCounters struct {
+	bytesSent      atomic.Uint64
+	bytesReceived  atomic.Uint64
+	framesSent     atomic.Uint64
+	framesReceived atomic.Uint64
+	framingErrors  atomic.Uint64
+	lastActivity   atomic.Int64
+}
+
 type transportBasicIO struct {
 	io.ReadWriteCloser
-	//new add
-	version string
+	// version is the framing version, read by Send while the receive goroutine may switch it, see detectFraming
+	version  atomic.Value
+	counters transportCounters
+	reader   *bufio.Reader
+	recovery bool
+	broken   error
+	maxSize  int
+	// framing auto-detection, see SetFramingAutoDetect
+	autoDetect bool
+	detected   atomic.Bool
+	onSwitch   func(from string, to string)
+	// frame is the message being received, see ReceiveFrame
+	frame *frameReader
+}
+
+// countingReader counts the bytes read from the underlying reader.
+type countingReader struct {
+	reader   io.Reader
+	counters *transportCounters
+}
+
+func (r *countingReader) Read(b []byte) (int, error) {
+	n, err := r.reader.Read(b)
+	if n > 0 {
+		r.counters.bytesReceived.Add(uint64(n))
+		r.counters.lastActivity.Store(time.Now().UnixNano())
+	}
+	return n, err
+}
+
+// Stats returns a snapshot of the transport counters.
+func (t *transportBasicIO) Stats() TransportStats {
+	stats := TransportStats{
+		BytesSent:      t.counters.bytesSent.Load(),
+		BytesReceived:  t.counters.bytesReceived.Load(),
+		FramesSent:     t.counters.framesSent.Load(),
+		FramesReceived: t.counters.framesReceived.Load(),
+		FramingErrors:  t.counters.framingErrors.Load(),
+	}
+	if last := t.counters.lastActivity.Load(); last != 0 {
+		stats.LastActivity = time.Unix(0, last)
+	}
+	return stats
 }
 
 func (t *transportBasicIO) SetVersion(version string) {
-	t.version = version
+	t.version.Store(version)
+	t.detected.Store(false)
+}
+
+// framingVersion returns the framing version, empty until SetVersion is called.
+func (t *transportBasicIO) framingVersion() string {
+	version, _ := t.version.Load().(string)
+	return version
+}
+
+// SetFramingAutoDetect enables the detection of the framing actually used by the server on the first message
+// received after the version is set. When it does not match the negotiated version, the transport switches to
+// the detected framing, for both directions, and calls onSwitch if not nil.
+// This accommodates servers advertising base:1.1 while still using the end-of-message separator.
+func (t *transportBasicIO) SetFramingAutoDetect(enabled bool, onSwitch func(from string, to string)) {
+	t.autoDetect = enabled
+	t.onSwitch = onSwitch
+}
+
+// detectFraming peeks at the beginning of the next message to find out the framing it uses.
+func (t *transportBasicIO) detectFraming() error {
+	start, err := t.bufferedReader().Peek(2)
+	if err != nil {
+		return err
+	}
+	t.detected.Store(true)
+
+	detected := "v1.0"
+	if string(start) == "\n#" {
+		detected = "v1.1"
+	}
+	current := t.framingVersion()
+	if current != "v1.1" {
+		current = "v1.0"
+	}
+	if detected != current {
+		t.version.Store(detected)
+		if t.onSwitch != nil {
+			t.onSwitch(current, detected)
+		}
+	}
+	return nil
 }
 
 // Send a well formatted NETCONF rpc message as a slice of bytes adding on the
@@ -48,42 +170,120 @@ func (t *transportBasicIO) SetVersion(version string) {
 func (t *transportBasicIO) Send(data []byte) error {
 	var separator []byte
 	var dataInfo []byte
-	if t.version == "v1.1" {
+	version := t.framingVersion()
+	if version == "v1.1" {
 		separator = append(separator, []byte(msgSeparatorV11)...)
 	} else {
 		separator = append(separator, []byte(msgSeparator)...)
 	}
 
-	if t.version == "v1.1" {
+	if version == "v1.1" {
 		header := fmt.Sprintf("\n#%d\n", len(string(data)))
 		dataInfo = append(dataInfo, header...)
 	}
 	dataInfo = append(dataInfo, data...)
 	dataInfo = append(dataInfo, separator...)
-	_, err := t.Write(dataInfo)
+	n, err := t.Write(dataInfo)
+	if n > 0 {
+		t.counters.bytesSent.Add(uint64(n))
+		t.counters.lastActivity.Store(time.Now().UnixNano())
+	}
+	if err == nil {
+		t.counters.framesSent.Add(1)
+	}
 
 	return err
 }
 
+// Receive waits for the next message and returns its content, with the transport framing removed.
 func (t *transportBasicIO) Receive() ([]byte, error) {
-	var separator []byte
-	if t.version == "v1.1" {
-		separator = append(separator, []byte(msgSeparatorV11)...)
-		// NOTES: This is not clever at all
-		// you are reading the O-RU response content once with WaitForBytes, and then you read it again to get rid of
-		// the #<chunk-size> pieces. Using Chunked would be enough, but if you pass in the t.ReadWriteCloser to the
-		// splitChunked function it gets stuck when doing the Read of the last piece of the NETCONF message.
-		// This will need to be addressed in the future.
-		// Also, splitChunked function comes from the andaru/netconf library, with just a slight modification to make it
-		// work.
-		b, err := t.WaitForBytes(separator)
+	return readFrame(t.ReceiveFrame())
+}
+
+// SetFramingRecovery enables or disables the recovery from chunked framing errors.
+// When enabled, a malformed chunk makes the transport drop the message and resynchronize on the next
+// end-of-chunks marker. When disabled, the transport fails all subsequent receptions with the FramingError.
+func (t *transportBasicIO) SetFramingRecovery(enabled bool) {
+	t.recovery = enabled
+}
+
+// SetMaxMessageSize caps the size, in bytes, of a received message. A larger message is discarded up to its
+// end, and a MessageTooLargeError is returned. A size of zero means no limit.
+func (t *transportBasicIO) SetMaxMessageSize(size int) {
+	t.maxSize = size
+}
+
+// bufferedReader returns the reader used to decode incoming messages, creating it on first use.
+// All reads must go through it, as it may hold bytes belonging to the next message.
+func (t *transportBasicIO) bufferedReader() *bufio.Reader {
+	if t.reader == nil {
+		t.reader = bufio.NewReader(&countingReader{reader: t.ReadWriteCloser, counters: &t.counters})
+	}
+	return t.reader
+}
+
+// readChunkHeader reads a chunk header, returning the chunk size, or zero for the end-of-chunks marker.
+func readChunkHeader(r *bufio.Reader) (uint64, error) {
+	prefix := make([]byte, 3)
+	if _, err := io.ReadFull(r, prefix); err != nil {
+		return 0, err
+	}
+	if prefix[0] != '\n' || prefix[1] != '#' {
+		return 0, &FramingError{Err: ErrBadChunk, Reason: fmt.Sprintf("unexpected chunk header start %q", prefix)}
+	}
+	if prefix[2] == '#' {
+		end, err := r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		if end != '\n' {
+			return 0, &FramingError{Err: ErrBadChunk, Reason: fmt.Sprintf("unexpected end-of-chunks byte %q", end)}
+		}
+		return 0, nil
+	}
+	if prefix[2] < '1' || prefix[2] > '9' {
+		return 0, &FramingError{Err: ErrBadChunk, Reason: fmt.Sprintf("invalid chunk size start %q", prefix[2])}
+	}
+
+	digits := []byte{prefix[2]}
+	for {
+		b, err := r.ReadByte()
 		if err != nil {
-			return nil, err
+			return 0, err
+		}
+		if b == '\n' {
+			break
+		}
+		// chunk-size is at most 4294967295, that is 10 digits
+		if b < '0' || b > '9' || len(digits) == 10 {
+			return 0, &FramingError{Err: ErrBadChunk, Reason: fmt.Sprintf("invalid chunk size %q", append(digits, b))}
+		}
+		digits = append(digits, b)
+	}
+	size, err := strconv.ParseUint(string(digits), 10, 32)
+	if err != nil {
+		return 0, &FramingError{Err: ErrBadChunk, Reason: fmt.Sprintf("invalid chunk size %q", digits)}
+	}
+	return size, nil
+}
+
+// skipToEndOfChunks discards the incoming bytes up to, and including, the next end-of-chunks marker.
+func (t *transportBasicIO) skipToEndOfChunks() error {
+	r := t.bufferedReader()
+	var window []byte
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return err
+		}
+		window = append(window, b)
+		if len(window) > len(msgSeparatorV11) {
+			window = window[1:]
+		}
+		if string(window) == msgSeparatorV11 {
+			return nil
 		}
-		return t.Chunked(b)
 	}
-	separator = append(separator, []byte(msgSeparator)...)
-	return t.WaitForBytes(separator)
 }
 
 func (t *transportBasicIO) Writeln(b []byte) (int, error) {
@@ -198,6 +398,41 @@ func SplitChunked(endOfMessage func()) bufio.SplitFunc {
 // ErrBadChunk indicates a chunked framing protocol error occurred
 var ErrBadChunk = errors.New("bad chunk")
 
+// MessageTooLargeError is returned when a received message exceeds the configured maximum size.
+// The message is discarded, and the transport remains usable for the subsequent messages.
+type MessageTooLargeError struct {
+	// Limit is the configured maximum size, in bytes.
+	Limit int
+	// Size is the size of the discarded message, in bytes.
+	Size int
+}
+
+// Error generates a string representation of the error
+func (e *MessageTooLargeError) Error() string {
+	return fmt.Sprintf("netconf message of %d bytes exceeds the maximum size of %d bytes", e.Size, e.Limit)
+}
+
+// FramingError is returned when a received message does not follow the negotiated framing.
+type FramingError struct {
+	// Err is the underlying framing error, e.g. ErrBadChunk.
+	Err error
+	// Reason describes what was wrong in the received bytes.
+	Reason string
+	// Recovered tells whether the transport dropped the message and resynchronized on the next
+	// message boundary, in which case subsequent messages can still be received.
+	Recovered bool
+}
+
+// Error generates a string representation of the framing error
+func (e *FramingError) Error() string {
+	return fmt.Sprintf("netconf framing error: %s: %s", e.Err, e.Reason)
+}
+
+// Unwrap returns the underlying framing error
+func (e *FramingError) Unwrap() error {
+	return e.Err
+}
+
 func (t *transportBasicIO) Chunked(b []byte) ([]byte, error) {
 	rdr := bytes.NewReader(b)
 	scanner := bufio.NewScanner(rdr)
@@ -209,6 +444,9 @@ func (t *transportBasicIO) Chunked(b []byte) ([]byte, error) {
 	for scanner.Scan() {
 		got = append(got, scanner.Bytes()...)
 	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
 	return got, nil
 }
 
@@ -218,7 +456,7 @@ func (t *transportBasicIO) WaitForFunc(f func([]byte) (int, error)) ([]byte, err
 
 	pos := 0
 	for {
-		n, err := t.Read(buf[pos : pos+(len(buf)/2)])
+		n, err := t.bufferedReader().Read(buf[pos : pos+(len(buf)/2)])
 		if err != nil {
 			if err != io.EOF {
 				return nil, err
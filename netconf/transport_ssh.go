@@ -9,11 +9,16 @@
 package netconf
 
 import (
+	"context"
 	"crypto/x509"
 	"encoding/pem"
+	"errors"
 	"fmt"
+	"io"
 	"net"
+	"net/netip"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
@@ -26,6 +31,8 @@ const (
 	sshDefaultPort = 830
 	// sshNetconfSubsystem sets the SSH subsystem to NETCONF
 	sshNetconfSubsystem = "netconf"
+	// happyEyeballsDelay is the delay between two connection attempts of DialSSHMulti
+	happyEyeballsDelay = 250 * time.Millisecond
 )
 
 // TransportSSH maintains the information necessary to communicate with the
@@ -34,6 +41,32 @@ type TransportSSH struct {
 	transportBasicIO
 	sshClient  *ssh.Client
 	sshSession *ssh.Session
+	reopened   bool
+}
+
+// Receive receives a message from the netconf subsystem.
+func (t *TransportSSH) Receive() ([]byte, error) {
+	return readFrame(t.ReceiveFrame())
+}
+
+// ReceiveFrame waits for the next message of the netconf subsystem, see FrameReceiver.
+//
+// Some devices occasionally drop the first channel of a connection: when the channel is closed before
+// any byte of the server hello is received, the netconf subsystem is re-opened once on a new channel
+// before surfacing the error.
+func (t *TransportSSH) ReceiveFrame() (io.Reader, error) {
+	frame, err := t.transportBasicIO.ReceiveFrame()
+	if err == io.EOF && !t.reopened && t.sshClient != nil && t.counters.bytesReceived.Load() == 0 {
+		t.reopened = true
+		if t.sshSession != nil {
+			_ = t.sshSession.Close()
+		}
+		if setupErr := t.setupSession(); setupErr != nil {
+			return nil, fmt.Errorf("fail to re-open netconf subsystem after EOF: %w", setupErr)
+		}
+		return t.transportBasicIO.ReceiveFrame()
+	}
+	return frame, err
 }
 
 // Close closes an existing SSH session and socket if they exist.
@@ -72,17 +105,25 @@ func (t *TransportSSH) Close() error {
 // config takes a ssh.ClientConfig connection. See documentation for
 // go.crypto/ssh for documentation.  There is a helper function SSHConfigPassword
 // thar returns a ssh.ClientConfig for simple username/password authentication
-func (t *TransportSSH) Dial(target string, config *ssh.ClientConfig) error {
-	if !strings.Contains(target, ":") {
-		target = fmt.Sprintf("%s:%d", target, sshDefaultPort)
-	}
-
-	var err error
+//
+// options allow customizing the connection, e.g. its source address.
+func (t *TransportSSH) Dial(target string, config *ssh.ClientConfig, options ...DialOption) error {
+	target = withDefaultPort(target)
 
-	t.sshClient, err = ssh.Dial("tcp", target, config)
+	dialer, err := newDialer(target, config.Timeout, options)
+	if err != nil {
+		return err
+	}
+	conn, err := dialer.Dial("tcp", target)
 	if err != nil {
 		return err
 	}
+	c, channel, reqs, err := ssh.NewClientConn(conn, target, config)
+	if err != nil {
+		_ = conn.Close()
+		return err
+	}
+	t.sshClient = ssh.NewClient(c, channel, reqs)
 
 	err = t.setupSession()
 	return err
@@ -90,9 +131,9 @@ func (t *TransportSSH) Dial(target string, config *ssh.ClientConfig) error {
 
 // DialSSH creates a new SSH Transport.
 // See TransportSSH.Dial for arguments.
-func DialSSH(target string, config *ssh.ClientConfig) (*TransportSSH, error) {
+func DialSSH(target string, config *ssh.ClientConfig, options ...DialOption) (*TransportSSH, error) {
 	t := new(TransportSSH)
-	err := t.Dial(target, config)
+	err := t.Dial(target, config, options...)
 	if err != nil {
 		err := t.Close()
 		if err != nil {
@@ -106,8 +147,13 @@ func DialSSH(target string, config *ssh.ClientConfig) (*TransportSSH, error) {
 // DialSSHTimeout creates a new SSH Transport with timeout.
 // See TransportSSH.Dial for arguments.
 // The timeout value is used for both connection establishment and Read/Write operations.
-func DialSSHTimeout(target string, config *ssh.ClientConfig, timeout time.Duration) (*TransportSSH, error) {
-	bareConn, err := net.DialTimeout("tcp", target, timeout)
+func DialSSHTimeout(target string, config *ssh.ClientConfig, timeout time.Duration, options ...DialOption) (*TransportSSH, error) {
+	target = withDefaultPort(target)
+	dialer, err := newDialer(target, timeout, options)
+	if err != nil {
+		return nil, err
+	}
+	bareConn, err := dialer.Dial("tcp", target)
 	if err != nil {
 		return nil, err
 	}
@@ -138,6 +184,179 @@ func DialSSHTimeout(target string, config *ssh.ClientConfig, timeout time.Durati
 	return t, nil
 }
 
+// DialSSHMulti creates a new SSH Transport to the first reachable of the candidate targets, for devices
+// with redundant management interfaces.
+// Each target follows the TransportSSH.Dial format, and a hostname resolving to several addresses yields one
+// candidate per address. Attempts are raced following the happy eyeballs principle: they are started in order,
+// a new one every 250ms or as soon as the previous one failed, and the first established transport wins while
+// the others are closed.
+// The timeout applies to each connection attempt.
+func DialSSHMulti(targets []string, config *ssh.ClientConfig, timeout time.Duration, options ...DialOption) (*TransportSSH, error) {
+	return DialSSHMultiContext(context.Background(), targets, config, timeout, options...)
+}
+
+// DialSSHMultiContext is DialSSHMulti with a context cancelling the resolution of the targets and the
+// connection attempts in progress.
+func DialSSHMultiContext(
+	ctx context.Context, targets []string, config *ssh.ClientConfig, timeout time.Duration, options ...DialOption,
+) (*TransportSSH, error) {
+	addresses, err := resolveTargets(ctx, targets)
+	if err != nil {
+		return nil, err
+	}
+
+	type result struct {
+		t   *TransportSSH
+		err error
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	results := make(chan result, len(addresses))
+	next, pending := 0, 0
+	start := func() {
+		address := addresses[next]
+		next++
+		pending++
+		go func() {
+			t, err := dialSSHContext(ctx, address, config, timeout, options)
+			results <- result{t, err}
+		}()
+	}
+
+	start()
+	timer := time.NewTimer(happyEyeballsDelay)
+	defer timer.Stop()
+	// closeLate closes the transports of the attempts still in progress
+	closeLate := func(pending int) {
+		for ; pending > 0; pending-- {
+			if late := <-results; late.err == nil {
+				_ = late.t.Close()
+			}
+		}
+	}
+	var errs []error
+	for pending > 0 {
+		select {
+		case r := <-results:
+			pending--
+			if r.err == nil {
+				go closeLate(pending)
+				return r.t, nil
+			}
+			errs = append(errs, r.err)
+			if next < len(addresses) {
+				start()
+				// the timer may have fired while the attempt was failing
+				if !timer.Stop() {
+					select {
+					case <-timer.C:
+					default:
+					}
+				}
+				timer.Reset(happyEyeballsDelay)
+			}
+		case <-timer.C:
+			if next < len(addresses) {
+				start()
+				timer.Reset(happyEyeballsDelay)
+			}
+		case <-ctx.Done():
+			go closeLate(pending)
+			return nil, ctx.Err()
+		}
+	}
+	return nil, fmt.Errorf("all connection attempts failed: %w", errors.Join(errs...))
+}
+
+// dialSSHContext creates a new SSH Transport to the address, the TCP connection and the SSH handshake being
+// cancelled with the context.
+func dialSSHContext(
+	ctx context.Context, address string, config *ssh.ClientConfig, timeout time.Duration, options []DialOption,
+) (*TransportSSH, error) {
+	dialer, err := newDialer(address, timeout, options)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	t, err := connToTransport(conn, config)
+	if !stop() {
+		if err == nil {
+			_ = t.Close()
+		}
+		return nil, ctx.Err()
+	}
+	if err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("%s: %w", address, err)
+	}
+	return t, nil
+}
+
+// resolveTargets returns the addresses to try for the targets, hostnames being resolved to all their addresses.
+// Resolved addresses alternate between IPv6 and IPv4 so both families get tried early.
+func resolveTargets(ctx context.Context, targets []string) ([]string, error) {
+	var addresses []string
+	for _, target := range targets {
+		host, port, err := net.SplitHostPort(withDefaultPort(target))
+		if err != nil {
+			return nil, err
+		}
+		if isIPLiteral(host) {
+			addresses = append(addresses, net.JoinHostPort(host, port))
+			continue
+		}
+
+		ips, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		var v6, v4 []string
+		for _, ip := range ips {
+			if ip.IP.To4() == nil {
+				v6 = append(v6, net.JoinHostPort(ip.String(), port))
+			} else {
+				v4 = append(v4, net.JoinHostPort(ip.String(), port))
+			}
+		}
+		for len(v6) > 0 || len(v4) > 0 {
+			if len(v6) > 0 {
+				addresses = append(addresses, v6[0])
+				v6 = v6[1:]
+			}
+			if len(v4) > 0 {
+				addresses = append(addresses, v4[0])
+				v4 = v4[1:]
+			}
+		}
+	}
+	if len(addresses) == 0 {
+		return nil, fmt.Errorf("no target to dial")
+	}
+	return addresses, nil
+}
+
+// withDefaultPort appends the default NETCONF over SSH port to the target when it has none.
+// The target is either a host name, an IPv4 address, or an IPv6 address, optionally with a zone identifier as
+// required by link-local addresses, e.g. `fe80::1%eth0`. IPv6 addresses are bracketed when followed by a port,
+// e.g. `[fe80::1%eth0]:830`; an unbracketed IPv6 address is considered to have no port.
+func withDefaultPort(target string) string {
+	if _, _, err := net.SplitHostPort(target); err == nil {
+		return target
+	}
+	host := strings.TrimSuffix(strings.TrimPrefix(target, "["), "]")
+	return net.JoinHostPort(host, strconv.Itoa(sshDefaultPort))
+}
+
+// isIPLiteral tells whether the host is an IP address, including IPv6 addresses with a zone identifier.
+func isIPLiteral(host string) bool {
+	_, err := netip.ParseAddr(host)
+	return err == nil
+}
+
 // NoDialSSH - create a new TransportSSH from given ssh Client.
 func NoDialSSH(sshClient *ssh.Client) (*TransportSSH, error) {
 	t := new(TransportSSH)
@@ -153,10 +372,131 @@ func NoDialSSH(sshClient *ssh.Client) (*TransportSSH, error) {
 	return t, nil
 }
 
+var (
+	// sshDefaultCiphers and sshDefaultKeyExchanges mirror the defaults of golang.org/x/crypto/ssh,
+	// which are used when no algorithm is configured, but are not exported.
+	sshDefaultCiphers = []string{
+		"aes128-gcm@openssh.com", "chacha20-poly1305@openssh.com", "aes128-ctr", "aes192-ctr", "aes256-ctr",
+	}
+	sshDefaultKeyExchanges = []string{
+		"curve25519-sha256@libssh.org", "ecdh-sha2-nistp256", "ecdh-sha2-nistp384", "ecdh-sha2-nistp521",
+		"diffie-hellman-group14-sha1",
+	}
+	// sshLegacyCiphers and sshLegacyKeyExchanges are weak algorithms still required by old devices.
+	sshLegacyCiphers      = []string{"aes128-cbc", "3des-cbc"}
+	sshLegacyKeyExchanges = []string{"diffie-hellman-group1-sha1", "diffie-hellman-group-exchange-sha1"}
+	sshLegacyHostKeyAlgos = []string{ssh.CertAlgoRSAv01, ssh.CertAlgoDSAv01, ssh.KeyAlgoRSA, ssh.KeyAlgoDSA}
+	// sshDefaultHostKeyAlgos mirrors the host key algorithms supported by golang.org/x/crypto/ssh, without the
+	// SHA-1 based ones. The rsa-sha2 names are spelled out as the vendored version has no constant for them.
+	sshDefaultHostKeyAlgos = []string{
+		"rsa-sha2-256-cert-v01@openssh.com", "rsa-sha2-512-cert-v01@openssh.com",
+		ssh.CertAlgoECDSA256v01, ssh.CertAlgoECDSA384v01, ssh.CertAlgoECDSA521v01, ssh.CertAlgoED25519v01,
+		ssh.KeyAlgoECDSA256, ssh.KeyAlgoECDSA384, ssh.KeyAlgoECDSA521,
+		ssh.SigAlgoRSASHA2256, ssh.SigAlgoRSASHA2512, ssh.KeyAlgoED25519,
+	}
+)
+
+// SSHConfigOption customizes an ssh.ClientConfig, see ApplySSHConfigOptions.
+type SSHConfigOption func(*ssh.ClientConfig)
+
+// SSHCiphers sets the ciphers allowed, in preference order.
+func SSHCiphers(ciphers ...string) SSHConfigOption {
+	return func(config *ssh.ClientConfig) {
+		config.Ciphers = ciphers
+	}
+}
+
+// SSHKeyExchanges sets the key exchange algorithms allowed, in preference order.
+func SSHKeyExchanges(kex ...string) SSHConfigOption {
+	return func(config *ssh.ClientConfig) {
+		config.KeyExchanges = kex
+	}
+}
+
+// SSHMACs sets the MAC algorithms allowed, in preference order.
+func SSHMACs(macs ...string) SSHConfigOption {
+	return func(config *ssh.ClientConfig) {
+		config.MACs = macs
+	}
+}
+
+// SSHHostKeyAlgorithms sets the host key algorithms the client accepts, in preference order.
+func SSHHostKeyAlgorithms(algorithms ...string) SSHConfigOption {
+	return func(config *ssh.ClientConfig) {
+		config.HostKeyAlgorithms = algorithms
+	}
+}
+
+// SSHHostKeyCallback sets the callback used to verify the server host key.
+func SSHHostKeyCallback(callback ssh.HostKeyCallback) SSHConfigOption {
+	return func(config *ssh.ClientConfig) {
+		config.HostKeyCallback = callback
+	}
+}
+
+// SSHLegacyAlgorithms appends the legacy ciphers (CBC modes), key exchanges (SHA-1 Diffie-Hellman groups)
+// and host key algorithms (ssh-rsa, ssh-dss, and their certificates) still needed by old devices, after the
+// configured or default ones.
+// These algorithms are weak, only use this option for devices that cannot be upgraded.
+func SSHLegacyAlgorithms() SSHConfigOption {
+	return func(config *ssh.ClientConfig) {
+		if len(config.Ciphers) == 0 {
+			config.Ciphers = sshDefaultCiphers
+		}
+		config.Ciphers = appendMissing(config.Ciphers, sshLegacyCiphers...)
+		if len(config.KeyExchanges) == 0 {
+			config.KeyExchanges = sshDefaultKeyExchanges
+		}
+		config.KeyExchanges = appendMissing(config.KeyExchanges, sshLegacyKeyExchanges...)
+		if len(config.HostKeyAlgorithms) == 0 {
+			config.HostKeyAlgorithms = sshDefaultHostKeyAlgos
+		}
+		config.HostKeyAlgorithms = appendMissing(config.HostKeyAlgorithms, sshLegacyHostKeyAlgos...)
+	}
+}
+
+// ApplySSHConfigOptions applies the options to the provided ssh.ClientConfig.
+func ApplySSHConfigOptions(config *ssh.ClientConfig, options ...SSHConfigOption) *ssh.ClientConfig {
+	for _, option := range options {
+		option(config)
+	}
+	return config
+}
+
+// appendMissing returns a copy of the list to which the values not already present are appended.
+func appendMissing(list []string, values ...string) []string {
+	result := append([]string{}, list...)
+	for _, value := range values {
+		found := false
+		for _, existing := range result {
+			if existing == value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			result = append(result, value)
+		}
+	}
+	return result
+}
+
+// SSHConfigPassword is a convenience function that takes a username and password
+// and returns a new ssh.ClientConfig setup to pass credentials to DialSSH
+func SSHConfigPassword(user string, password string, options ...SSHConfigOption) *ssh.ClientConfig {
+	config := &ssh.ClientConfig{
+		User: user,
+		Auth: []ssh.AuthMethod{
+			ssh.Password(password),
+		},
+	}
+	return ApplySSHConfigOptions(config, options...)
+}
+
 // SSHConfigPubKeyFile is a convenience function that takes a username, private key
 // and passphrase and returns a new ssh.ClientConfig setup to pass credentials
 // to DialSSH
-func SSHConfigPubKeyFile(user string, file string, passphrase string) (*ssh.ClientConfig, error) {
+func SSHConfigPubKeyFile(user string, file string, passphrase string, options ...SSHConfigOption) (*ssh.ClientConfig, error) {
 	buf, err := os.ReadFile(file)
 	if err != nil {
 		return nil, err
@@ -181,12 +521,13 @@ func SSHConfigPubKeyFile(user string, file string, passphrase string) (*ssh.Clie
 	if err != nil {
 		return nil, err
 	}
-	return &ssh.ClientConfig{
+	config := &ssh.ClientConfig{
 		User: user,
 		Auth: []ssh.AuthMethod{
 			ssh.PublicKeys(key),
 		},
-	}, nil
+	}
+	return ApplySSHConfigOptions(config, options...), nil
 
 }
 
@@ -241,5 +582,7 @@ func (t *TransportSSH) setupSession() error {
 	}
 
 	t.ReadWriteCloser = NewReadWriteCloser(reader, writer)
+	t.reader = nil
+	t.frame = nil
 	return t.sshSession.RequestSubsystem(sshNetconfSubsystem)
 }
@@ -0,0 +1,49 @@
+/*
+Copyright 2021. Alexis de Talhouët
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package netconf
+
+import "golang.org/x/crypto/ssh"
+
+// version is the semantic version of the library, to be bumped on every release.
+const version = "1.0.6"
+
+// Version returns the semantic version of the library.
+func Version() string {
+	return version
+}
+
+// VersionCapability returns the vendor capability advertising the library version,
+// added to the client hello when using WithVersionCapability.
+func VersionCapability() string {
+	return "https://github.com/openshift-telco/go-netconf-client?version=" + version
+}
+
+// WithVersionCapability advertises the library version as a vendor capability in the client hello,
+// so operators can identify the client versions connecting to their devices.
+func WithVersionCapability() SessionOption {
+	return func(s *Session) {
+		s.advertiseVersion = true
+	}
+}
+
+// SSHClientVersion sets the SSH client version string to identify the library and its version,
+// e.g. SSH-2.0-go-netconf-client_1.0.6
+func SSHClientVersion() SSHConfigOption {
+	return func(config *ssh.ClientConfig) {
+		config.ClientVersion = "SSH-2.0-go-netconf-client_" + version
+	}
+}
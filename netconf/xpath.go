@@ -0,0 +1,49 @@
+/*
+Copyright 2021. Alexis de Talhouët
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package netconf
+
+import (
+	"context"
+	"errors"
+
+	"github.com/openshift-telco/go-netconf-client/netconf/message"
+)
+
+// ErrXPathNotSupported is returned by GetXPath and GetConfigXPath when the server does not advertise the :xpath
+// capability.
+var ErrXPathNotSupported = errors.New("server does not support XPath filters")
+
+// GetXPath retrieves the configuration and state data matching the XPath expression, see message.NewXPathFilter.
+// It fails with ErrXPathNotSupported unless the server advertises the :xpath capability.
+func (session *Session) GetXPath(ctx context.Context, selectExpr string, namespaces map[string]string) (*message.RPCReply, error) {
+	if !session.features.XPath {
+		return nil, ErrXPathNotSupported
+	}
+	return session.SyncRPCContext(ctx, message.NewGetXPath(selectExpr, namespaces))
+}
+
+// GetConfigXPath retrieves the configuration of the datastore matching the XPath expression,
+// see message.NewXPathFilter.
+// It fails with ErrXPathNotSupported unless the server advertises the :xpath capability.
+func (session *Session) GetConfigXPath(
+	ctx context.Context, datastore string, selectExpr string, namespaces map[string]string,
+) (*message.RPCReply, error) {
+	if !session.features.XPath {
+		return nil, ErrXPathNotSupported
+	}
+	return session.SyncRPCContext(ctx, message.NewGetConfigXPath(datastore, selectExpr, namespaces))
+}
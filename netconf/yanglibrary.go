@@ -0,0 +1,147 @@
+/*
+Copyright 2021. Alexis de Talhouët
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package netconf
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"strings"
+
+	"github.com/openshift-telco/go-netconf-client/netconf/message"
+)
+
+// YangLibraryXmlns is the XMLNS of the YANG library module, see RFC 8525 and RFC 7895.
+const YangLibraryXmlns = "urn:ietf:params:xml:ns:yang:ietf-yang-library"
+
+// yangLibraryFilter selects both the RFC 8525 and the legacy RFC 7895 YANG library data.
+const yangLibraryFilter = `<yang-library xmlns="` + YangLibraryXmlns + `"/><modules-state xmlns="` + YangLibraryXmlns + `"/>`
+
+// YangLibrary is the YANG library of a server, telling the modules it implements.
+// Servers supporting NMDA report module sets, schemas and datastores, see RFC 8525, while older servers report
+// the flat module list of RFC 7895, kept in Modules. Servers may report both.
+type YangLibrary struct {
+	ContentID  string          `xml:"yang-library>content-id"`
+	ModuleSets []YangModuleSet `xml:"yang-library>module-set"`
+	Schemas    []YangSchema    `xml:"yang-library>schema"`
+	Datastores []YangDatastore `xml:"yang-library>datastore"`
+	// ModuleSetID and Modules are the RFC 7895 data.
+	ModuleSetID string       `xml:"modules-state>module-set-id"`
+	Modules     []YangModule `xml:"modules-state>module"`
+}
+
+// YangModuleSet is a set of modules, see RFC 8525.
+type YangModuleSet struct {
+	Name              string       `xml:"name"`
+	Modules           []YangModule `xml:"module"`
+	ImportOnlyModules []YangModule `xml:"import-only-module"`
+}
+
+// YangSchema is the union of module sets making the schema of datastores, see RFC 8525.
+type YangSchema struct {
+	Name       string   `xml:"name"`
+	ModuleSets []string `xml:"module-set"`
+}
+
+// YangDatastore tells the schema of a datastore, see RFC 8525.
+type YangDatastore struct {
+	// Name is the datastore identity, without its module prefix, e.g. running or operational.
+	Name   string `xml:"name"`
+	Schema string `xml:"schema"`
+}
+
+// YangModule is a module, or submodule, of the YANG library.
+type YangModule struct {
+	Name      string   `xml:"name"`
+	Revision  string   `xml:"revision"`
+	Namespace string   `xml:"namespace"`
+	Location  []string `xml:"location"`
+	Features  []string `xml:"feature"`
+	// Deviations are the modules deviating this one.
+	Deviations []YangDeviation `xml:"deviation"`
+	Submodules []YangModule    `xml:"submodule"`
+	// Schema and ConformanceType are the RFC 7895 data, the conformance type being implement or import.
+	Schema          string `xml:"schema"`
+	ConformanceType string `xml:"conformance-type"`
+}
+
+// YangDeviation is a module deviating another one. RFC 8525 only gives its name, while RFC 7895 also gives its
+// revision.
+type YangDeviation struct {
+	Name     string
+	Revision string
+}
+
+// UnmarshalXML decodes both the RFC 8525 deviation, a module name, and the RFC 7895 one, a name and a revision.
+func (d *YangDeviation) UnmarshalXML(decoder *xml.Decoder, start xml.StartElement) error {
+	var deviation struct {
+		Text     string `xml:",chardata"`
+		Name     string `xml:"name"`
+		Revision string `xml:"revision"`
+	}
+	if err := decoder.DecodeElement(&deviation, &start); err != nil {
+		return err
+	}
+	d.Name, d.Revision = deviation.Name, deviation.Revision
+	if d.Name == "" {
+		d.Name = strings.TrimSpace(deviation.Text)
+	}
+	return nil
+}
+
+// YangLibrary retrieves the YANG library of the server.
+func (session *Session) YangLibrary(ctx context.Context) (*YangLibrary, error) {
+	reply, err := session.SyncRPCContext(ctx, message.NewGet(message.FilterTypeSubtree, yangLibraryFilter))
+	if err != nil {
+		return nil, err
+	}
+	if err := session.replyErr(reply); err != nil {
+		return nil, fmt.Errorf("get failed with errors: %w", err)
+	}
+
+	var data struct {
+		Library *YangLibrary `xml:"data"`
+	}
+	if err := xml.Unmarshal([]byte(reply.RawReply), &data); err != nil {
+		return nil, fmt.Errorf("fail to decode yang library: %w", err)
+	}
+	if data.Library == nil {
+		return nil, message.ErrNoData
+	}
+	for i := range data.Library.Datastores {
+		data.Library.Datastores[i].Name = identityName(data.Library.Datastores[i].Name)
+	}
+	return data.Library, nil
+}
+
+// Module returns the module with the provided name, looked up in the module sets, then in the RFC 7895 modules.
+// Import-only modules are not considered, as they are not implemented.
+func (library *YangLibrary) Module(name string) (YangModule, bool) {
+	for _, set := range library.ModuleSets {
+		for _, module := range set.Modules {
+			if module.Name == name {
+				return module, true
+			}
+		}
+	}
+	for _, module := range library.Modules {
+		if module.Name == name && module.ConformanceType != "import" {
+			return module, true
+		}
+	}
+	return YangModule{}, false
+}
@@ -2,6 +2,7 @@ package tests
 
 import (
 	"encoding/xml"
+	"errors"
 	"os"
 	"regexp"
 	"testing"
@@ -37,3 +38,184 @@ func TestRPCReply(t *testing.T) {
 		t.Errorf("failed to parse rpc-reply with regex")
 	}
 }
+
+func TestRPCReplyDataDigest(t *testing.T) {
+	first, err := message.NewRPCReply([]byte(`<rpc-reply xmlns="urn:ietf:params:xml:ns:netconf:base:1.0" message-id="1"><data><top xmlns="http://example.com/schema/1.2/config" a="1" b="2"><users/></top></data></rpc-reply>`))
+	if err != nil {
+		t.Fatalf("failed to unmarshal rpc reply: %v", err)
+	}
+	second, err := message.NewRPCReply([]byte("<nc:rpc-reply xmlns:nc=\"urn:ietf:params:xml:ns:netconf:base:1.0\" message-id=\"2\">\n  <nc:data>\n    <ex:top xmlns:ex=\"http://example.com/schema/1.2/config\" b=\"2\" a=\"1\">\n      <ex:users></ex:users>\n    </ex:top>\n  </nc:data>\n</nc:rpc-reply>"))
+	if err != nil {
+		t.Fatalf("failed to unmarshal rpc reply: %v", err)
+	}
+	third, err := message.NewRPCReply([]byte(`<rpc-reply xmlns="urn:ietf:params:xml:ns:netconf:base:1.0" message-id="3"><data><top xmlns="http://example.com/schema/1.2/config"><users>bob</users></top></data></rpc-reply>`))
+	if err != nil {
+		t.Fatalf("failed to unmarshal rpc reply: %v", err)
+	}
+
+	firstDigest, err := first.DataDigest()
+	if err != nil {
+		t.Fatalf("failed to compute digest: %v", err)
+	}
+	secondDigest, err := second.DataDigest()
+	if err != nil {
+		t.Fatalf("failed to compute digest: %v", err)
+	}
+	thirdDigest, err := third.DataDigest()
+	if err != nil {
+		t.Fatalf("failed to compute digest: %v", err)
+	}
+
+	if firstDigest != secondDigest {
+		t.Errorf("expected equivalent data to have the same digest, got %s and %s", firstDigest, secondDigest)
+	}
+	if firstDigest == thirdDigest {
+		t.Errorf("expected different data to have different digests")
+	}
+
+	ok, err := message.NewRPCReply([]byte(`<rpc-reply xmlns="urn:ietf:params:xml:ns:netconf:base:1.0" message-id="4"><ok/></rpc-reply>`))
+	if err != nil {
+		t.Fatalf("failed to unmarshal rpc reply: %v", err)
+	}
+	if _, err := ok.DataDigest(); err != message.ErrNoData {
+		t.Errorf("expected ErrNoData, got %v", err)
+	}
+
+	// only the data element of the rpc-reply is digested
+	nested, err := message.NewRPCReply([]byte(`<rpc-reply xmlns="urn:ietf:params:xml:ns:netconf:base:1.0" message-id="5"><result xmlns="http://example.com/action"><data>1</data></result></rpc-reply>`))
+	if err != nil {
+		t.Fatalf("failed to unmarshal rpc reply: %v", err)
+	}
+	if _, err := nested.DataDigest(); err != message.ErrNoData {
+		t.Errorf("expected ErrNoData for a data element outside of the NETCONF namespace, got %v", err)
+	}
+
+	// whitespace within the text of a leaf is part of its value
+	padded, err := message.NewRPCReply([]byte(`<rpc-reply xmlns="urn:ietf:params:xml:ns:netconf:base:1.0" message-id="6"><data><top xmlns="http://example.com/schema/1.2/config"><users> bob</users></top></data></rpc-reply>`))
+	if err != nil {
+		t.Fatalf("failed to unmarshal rpc reply: %v", err)
+	}
+	paddedDigest, err := padded.DataDigest()
+	if err != nil {
+		t.Fatalf("failed to compute digest: %v", err)
+	}
+	if paddedDigest == thirdDigest {
+		t.Errorf("expected a leaf with different whitespace to have a different digest")
+	}
+	third.RawReply = ""
+	if digest, err := third.DataDigest(); err != nil || digest != thirdDigest {
+		t.Errorf("got digest %s, %v without the raw reply, wanted %s", digest, err, thirdDigest)
+	}
+}
+
+func TestRPCReplyDecode(t *testing.T) {
+	reply, err := message.NewRPCReply([]byte(`<nc:rpc-reply xmlns:nc="urn:ietf:params:xml:ns:netconf:base:1.0" message-id="1"><nc:data>` +
+		`<interfaces xmlns="urn:ietf:params:xml:ns:yang:ietf-interfaces"><interface><name>eth0</name><mtu>1500</mtu></interface>` +
+		`<interface><name>eth1</name><mtu>9000</mtu></interface></interfaces></nc:data></nc:rpc-reply>`))
+	if err != nil {
+		t.Fatalf("failed to unmarshal rpc reply: %v", err)
+	}
+
+	type iface struct {
+		Name string `xml:"name"`
+		MTU  int    `xml:"mtu"`
+	}
+	var data struct {
+		Interfaces []iface `xml:"urn:ietf:params:xml:ns:yang:ietf-interfaces interfaces>interface"`
+	}
+	if err := reply.Decode(&data); err != nil {
+		t.Fatalf("failed to decode data: %v", err)
+	}
+	if len(data.Interfaces) != 2 || data.Interfaces[1].Name != "eth1" || data.Interfaces[1].MTU != 9000 {
+		t.Errorf("got interfaces %+v, wanted eth0 and eth1", data.Interfaces)
+	}
+
+	var named struct {
+		XMLName    xml.Name `xml:"urn:ietf:params:xml:ns:yang:ietf-interfaces interfaces"`
+		Interfaces []iface  `xml:"interface"`
+	}
+	if err := reply.Decode(&named); err != nil || len(named.Interfaces) != 2 {
+		t.Errorf("got interfaces %+v and error %v, wanted eth0 and eth1", named.Interfaces, err)
+	}
+
+	var all []iface
+	if err := reply.DecodeAt("interfaces/interface", &all); err != nil {
+		t.Fatalf("failed to decode interfaces: %v", err)
+	}
+	if len(all) != 2 || all[0].Name != "eth0" {
+		t.Errorf("got interfaces %+v, wanted eth0 and eth1", all)
+	}
+	var first iface
+	if err := reply.DecodeAt("/interfaces/interface/", &first); err != nil || first.Name != "eth0" {
+		t.Errorf("got interface %+v and error %v, wanted eth0", first, err)
+	}
+	if err := reply.DecodeAt("interfaces/vlan", &first); err == nil {
+		t.Errorf("expected decoding a missing element to fail")
+	}
+
+	ok, err := message.NewRPCReply([]byte(`<rpc-reply xmlns="urn:ietf:params:xml:ns:netconf:base:1.0" message-id="2"><ok/></rpc-reply>`))
+	if err != nil {
+		t.Fatalf("failed to unmarshal rpc reply: %v", err)
+	}
+	if err := ok.Decode(&data); err != message.ErrNoData {
+		t.Errorf("expected ErrNoData, got %v", err)
+	}
+}
+
+func TestRPCReplyOK(t *testing.T) {
+	ok := []string{
+		`<rpc-reply xmlns="urn:ietf:params:xml:ns:netconf:base:1.0" message-id="1"><ok/></rpc-reply>`,
+		"<nc:rpc-reply xmlns:nc=\"urn:ietf:params:xml:ns:netconf:base:1.0\" message-id=\"1\">\n  <nc:ok>\n  </nc:ok>\n</nc:rpc-reply>",
+		`<rpc-reply xmlns="urn:ietf:params:xml:ns:netconf:base:1.0" message-id="1"><rpc-error><error-type>application</error-type>` +
+			`<error-tag>operation-failed</error-tag><error-severity>warning</error-severity></rpc-error><ok></ok></rpc-reply>`,
+	}
+	for _, raw := range ok {
+		reply, err := message.NewRPCReply([]byte(raw))
+		if err != nil {
+			t.Fatalf("failed to unmarshal rpc reply: %v", err)
+		}
+		if !reply.OK() || !reply.Ok || reply.OkReply == nil {
+			t.Errorf("expected %s to be ok", raw)
+		}
+	}
+
+	notOK := []string{
+		`<rpc-reply xmlns="urn:ietf:params:xml:ns:netconf:base:1.0" message-id="1"><data><ok/></data></rpc-reply>`,
+		`<rpc-reply xmlns="urn:ietf:params:xml:ns:netconf:base:1.0" message-id="1"><rpc-error><error-type>application</error-type>` +
+			`<error-tag>operation-failed</error-tag><error-severity>error</error-severity></rpc-error></rpc-reply>`,
+	}
+	for _, raw := range notOK {
+		reply, err := message.NewRPCReply([]byte(raw))
+		if err != nil {
+			t.Fatalf("failed to unmarshal rpc reply: %v", err)
+		}
+		if reply.OK() || reply.Ok {
+			t.Errorf("expected %s not to be ok", raw)
+		}
+	}
+}
+
+func TestRPCReplyDataAndWarnings(t *testing.T) {
+	reply, err := message.NewRPCReply([]byte(`<rpc-reply xmlns="urn:ietf:params:xml:ns:netconf:base:1.0" message-id="1">` +
+		`<rpc-error><error-type>application</error-type><error-tag>operation-failed</error-tag><error-severity>warning</error-severity>` +
+		`<error-message>node is deprecated</error-message></rpc-error>` +
+		`<data><top xmlns="http://example.com/schema/1.2/config"><name>fred</name></top></data></rpc-reply>`))
+	if err != nil {
+		t.Fatalf("failed to unmarshal rpc reply: %v", err)
+	}
+	if len(reply.Errors) != 1 || len(reply.Warnings()) != 1 || reply.FatalErr() != nil || reply.Err() == nil {
+		t.Errorf("got errors %+v, wanted a single warning", reply.Errors)
+	}
+	var top struct {
+		Name string `xml:"top>name"`
+	}
+	if err := reply.Decode(&top); err != nil || top.Name != "fred" {
+		t.Errorf("got data %+v and error %v, wanted fred", top, err)
+	}
+
+	reply.Errors = append(reply.Errors, message.RPCError{Type: message.ErrorTypeApplication, Tag: message.ErrorTagInvalidValue, Severity: message.ErrorSeverityError})
+	var errs message.RPCErrors
+	if err := reply.FatalErr(); !errors.As(err, &errs) || len(errs) != 1 || errs[0].Tag != message.ErrorTagInvalidValue {
+		t.Errorf("got error %v, wanted the invalid-value error only", err)
+	}
+}
@@ -0,0 +1,51 @@
+package tests
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/openshift-telco/go-netconf-client/netconf"
+)
+
+func TestCaptureAndReplay(t *testing.T) {
+	hello := "<hello xmlns=\"urn:ietf:params:xml:ns:netconf:base:1.0\"><capabilities><capability>urn:ietf:params:netconf:base:1.0</capability></capabilities><session-id>4</session-id></hello>"
+	reply := "<rpc-reply xmlns=\"urn:ietf:params:xml:ns:netconf:base:1.0\" message-id=\"1\"><ok/></rpc-reply>"
+	replay := netconf.NewReplayTransport([]netconf.CaptureRecord{
+		{Direction: netconf.CaptureReceived, Data: hello},
+		{Direction: netconf.CaptureSent, Data: "<rpc/>"},
+		{Direction: netconf.CaptureReceived, Data: reply},
+	})
+
+	var capture bytes.Buffer
+	transport := netconf.NewCaptureTransport(replay, &capture)
+	for _, expected := range []string{hello, reply} {
+		got, err := transport.Receive()
+		if err != nil {
+			t.Fatalf("failed to receive: %v", err)
+		}
+		if string(got) != expected {
+			t.Errorf("got %q, wanted %q", string(got), expected)
+		}
+	}
+	if err := transport.Send([]byte("<rpc/>")); err != nil {
+		t.Fatalf("failed to send: %v", err)
+	}
+	_ = transport.Close()
+
+	records, err := netconf.ReadCapture(&capture)
+	if err != nil {
+		t.Fatalf("failed to read capture: %v", err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("got %d records, wanted 3", len(records))
+	}
+	directions := []netconf.CaptureDirection{netconf.CaptureReceived, netconf.CaptureReceived, netconf.CaptureSent}
+	for i, record := range records {
+		if record.Direction != directions[i] {
+			t.Errorf("record %d: got direction %s, wanted %s", i, record.Direction, directions[i])
+		}
+		if record.Time.IsZero() {
+			t.Errorf("record %d: missing timestamp", i)
+		}
+	}
+}
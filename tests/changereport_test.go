@@ -0,0 +1,177 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/openshift-telco/go-netconf-client/netconf"
+	"github.com/openshift-telco/go-netconf-client/netconf/message"
+	"github.com/openshift-telco/go-netconf-client/netconf/mock"
+)
+
+// snapshotsHandler replies to the successive get-config with the provided data.
+func snapshotsHandler(snapshots ...string) mock.Handler {
+	var lock sync.Mutex
+	return func(messageID string, request []byte) []byte {
+		lock.Lock()
+		defer lock.Unlock()
+		data := snapshots[0]
+		snapshots = snapshots[1:]
+		return mock.Reply(messageID, "<data>"+data+"</data>")
+	}
+}
+
+func TestChangeReport(t *testing.T) {
+	tests := []struct {
+		name   string
+		before string
+		after  string
+		keys   map[string]int
+		want   []netconf.Change
+	}{
+		{
+			name:   "no change",
+			before: "<system><hostname>r1</hostname></system>",
+			after:  "<system><hostname>r1</hostname></system>",
+		},
+		{
+			name:   "modified leaf",
+			before: "<system><hostname>r1</hostname></system>",
+			after:  "<system><hostname>r2</hostname></system>",
+			want: []netconf.Change{
+				{Path: "/system/hostname", Type: netconf.ChangeModified, Before: "r1", After: "r2"},
+			},
+		},
+		{
+			name:   "entry added to a single-entry list",
+			before: "<interfaces><interface><name>eth0</name><mtu>1500</mtu></interface></interfaces>",
+			after:  "<interfaces><interface><name>eth0</name><mtu>1500</mtu></interface><interface><name>eth1</name><mtu>9000</mtu></interface></interfaces>",
+			want: []netconf.Change{
+				{Path: "/interfaces/interface[name='eth1']/mtu", Type: netconf.ChangeAdded, After: "9000"},
+				{Path: "/interfaces/interface[name='eth1']/name", Type: netconf.ChangeAdded, After: "eth1"},
+			},
+		},
+		{
+			name:   "key changed in a single-entry list",
+			before: "<interfaces><interface><name>eth0</name><mtu>1500</mtu></interface></interfaces>",
+			after:  "<interfaces><interface><name>eth1</name><mtu>1500</mtu></interface></interfaces>",
+			keys:   map[string]int{"/interfaces/interface": 1},
+			want: []netconf.Change{
+				{Path: "/interfaces/interface[name='eth0']/mtu", Type: netconf.ChangeRemoved, Before: "1500"},
+				{Path: "/interfaces/interface[name='eth0']/name", Type: netconf.ChangeRemoved, Before: "eth0"},
+				{Path: "/interfaces/interface[name='eth1']/mtu", Type: netconf.ChangeAdded, After: "1500"},
+				{Path: "/interfaces/interface[name='eth1']/name", Type: netconf.ChangeAdded, After: "eth1"},
+			},
+		},
+		{
+			name:   "entry removed from a list",
+			before: "<interfaces><interface><name>eth0</name><mtu>1500</mtu></interface><interface><name>eth1</name><mtu>9000</mtu></interface></interfaces>",
+			after:  "<interfaces><interface><name>eth1</name><mtu>9000</mtu></interface></interfaces>",
+			want: []netconf.Change{
+				{Path: "/interfaces/interface[name='eth0']/mtu", Type: netconf.ChangeRemoved, Before: "1500"},
+				{Path: "/interfaces/interface[name='eth0']/name", Type: netconf.ChangeRemoved, Before: "eth0"},
+			},
+		},
+		{
+			name:   "entries sharing their first leaf",
+			before: "<routes><route><prefix>10.0.0.0/8</prefix><next-hop>192.0.2.1</next-hop><metric>1</metric></route><route><prefix>10.0.0.0/8</prefix><next-hop>192.0.2.2</next-hop><metric>2</metric></route></routes>",
+			after:  "<routes><route><prefix>10.0.0.0/8</prefix><next-hop>192.0.2.1</next-hop><metric>1</metric></route><route><prefix>10.0.0.0/8</prefix><next-hop>192.0.2.2</next-hop><metric>5</metric></route></routes>",
+			want: []netconf.Change{
+				{Path: "/routes/route[prefix='10.0.0.0/8'][next-hop='192.0.2.2']/metric", Type: netconf.ChangeModified, Before: "2", After: "5"},
+			},
+		},
+		{
+			name:   "leaf-list entries",
+			before: "<ntp><server>ntp1</server><server>ntp2</server></ntp>",
+			after:  "<ntp><server>ntp2</server><server>ntp3</server></ntp>",
+			want: []netconf.Change{
+				{Path: "/ntp/server[.='ntp1']", Type: netconf.ChangeRemoved, Before: "ntp1"},
+				{Path: "/ntp/server[.='ntp3']", Type: netconf.ChangeAdded, After: "ntp3"},
+			},
+		},
+		{
+			name:   "duplicate leaf-list entries",
+			before: "<ntp><server>ntp1</server><server>ntp1</server></ntp>",
+			after:  "<ntp><server>ntp1</server></ntp>",
+			want: []netconf.Change{
+				{Path: "/ntp/server[.='ntp1'][2]", Type: netconf.ChangeRemoved, Before: "ntp1"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			session := newMockSession(t, mock.NewTransport(mock.WithHandler(snapshotsHandler(tt.before, tt.after))))
+			defer session.Close()
+
+			report, err := session.NewChangeReport(message.DatastoreRunning, "", 5)
+			if err != nil {
+				t.Fatalf("failed to snapshot before the change: %v", err)
+			}
+			report.Keys = tt.keys
+			if err := report.Complete(5); err != nil {
+				t.Fatalf("failed to snapshot after the change: %v", err)
+			}
+			if !reflect.DeepEqual(report.Changes, tt.want) {
+				t.Errorf("got changes %+v, wanted %+v", report.Changes, tt.want)
+			}
+		})
+	}
+}
+
+func TestChangeReportContext(t *testing.T) {
+	session := newMockSession(t, mock.NewTransport(mock.WithHandler(snapshotsHandler("<system><hostname>r1</hostname></system>", "<system><hostname>r2</hostname></system>"))))
+	defer session.Close()
+
+	report, err := session.NewChangeReportContext(context.Background(), message.DatastoreRunning, "")
+	if err != nil {
+		t.Fatalf("failed to snapshot before the change: %v", err)
+	}
+	if err := report.CompleteContext(context.Background()); err != nil {
+		t.Fatalf("failed to snapshot after the change: %v", err)
+	}
+	want := []netconf.Change{{Path: "/system/hostname", Type: netconf.ChangeModified, Before: "r1", After: "r2"}}
+	if !reflect.DeepEqual(report.Changes, want) {
+		t.Errorf("got changes %+v, wanted %+v", report.Changes, want)
+	}
+
+	silent := newMockSession(t, mock.NewTransport(mock.WithHandler(mock.NoReply)))
+	defer silent.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := silent.NewChangeReportContext(ctx, message.DatastoreRunning, ""); !errors.Is(err, context.Canceled) {
+		t.Errorf("got error %v, wanted the context cancellation", err)
+	}
+}
+
+func TestChangeReportLogged(t *testing.T) {
+	var logs bytes.Buffer
+	session := newMockSession(t,
+		mock.NewTransport(mock.WithHandler(snapshotsHandler("<system><hostname>r1</hostname></system>", "<system><hostname>r2</hostname></system>"))),
+		netconf.WithLogger(slog.New(slog.NewTextHandler(&logs, nil))),
+	)
+	defer session.Close()
+
+	report, err := session.NewChangeReport(message.DatastoreRunning, "<system/>", 5)
+	if err != nil {
+		t.Fatalf("failed to snapshot before the change: %v", err)
+	}
+	if err := report.Complete(5); err != nil {
+		t.Fatalf("failed to snapshot after the change: %v", err)
+	}
+
+	var entry string
+	for _, line := range strings.Split(logs.String(), "\n") {
+		if strings.Contains(line, "msg=\"configuration change\"") {
+			entry = line
+		}
+	}
+	if !strings.Contains(entry, "datastore=running") || !strings.Contains(entry, "/system/hostname") || !strings.Contains(entry, "r2") {
+		t.Errorf("got audit entry %q, wanted the datastore and changes", entry)
+	}
+}
@@ -0,0 +1,120 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/openshift-telco/go-netconf-client/netconf"
+	"github.com/openshift-telco/go-netconf-client/netconf/message"
+	"github.com/openshift-telco/go-netconf-client/netconf/mock"
+)
+
+func TestCloseGracefully(t *testing.T) {
+	transport := mock.NewTransport()
+	var finished atomic.Bool
+	executor := netconf.ExecutorFunc(func(task func()) {
+		go task()
+	})
+	session := newMockSession(t, transport, netconf.WithCallbackExecutor(executor))
+
+	err := session.AsyncRPC(message.NewGetConfig(message.DatastoreRunning, "", ""), func(event netconf.Event) {
+		time.Sleep(100 * time.Millisecond)
+		finished.Store(true)
+	})
+	if err != nil {
+		t.Fatalf("failed to execute rpc: %v", err)
+	}
+
+	if err := session.CloseGracefully(5); err != nil {
+		t.Fatalf("failed to close session: %v", err)
+	}
+	if !finished.Load() {
+		t.Errorf("expected the pending callback to return before the session is closed")
+	}
+	requests := transport.Requests()
+	if last := requests[len(requests)-1]; !bytes.Contains(last, []byte("<close-session>")) {
+		t.Errorf("got last request %s, wanted a close-session", last)
+	}
+	if err := transport.Send([]byte("<rpc/>")); err == nil {
+		t.Errorf("expected the transport to be closed")
+	}
+	if err := session.CloseGracefully(5); !errors.Is(err, netconf.ErrSessionClosed) {
+		t.Errorf("got error %v closing twice, wanted %v", err, netconf.ErrSessionClosed)
+	}
+}
+
+func TestCloseGracefullyNoReply(t *testing.T) {
+	transport := mock.NewTransport(mock.WithHandler(mock.NoReply))
+	session := newMockSession(t, transport)
+
+	if err := session.CloseGracefully(1); err == nil {
+		t.Errorf("expected an error when the server does not reply")
+	}
+	if err := transport.Send([]byte("<rpc/>")); err == nil {
+		t.Errorf("expected the transport to be closed")
+	}
+}
+
+func TestCloseGracefullyContext(t *testing.T) {
+	transport := mock.NewTransport(mock.WithHandler(mock.NoReply))
+	session := newMockSession(t, transport)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := session.CloseGracefullyContext(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got error %v, wanted the context deadline", err)
+	}
+	if err := transport.Send([]byte("<rpc/>")); err == nil {
+		t.Errorf("expected the transport to be closed")
+	}
+}
+
+func TestServerClosedSession(t *testing.T) {
+	transport := mock.NewTransport(mock.WithHandler(mock.NoReply))
+	session := newMockSession(t, transport)
+
+	closed := make(chan netconf.Event, 1)
+	session.Listener.Register(netconf.CloseEventHandler, func(event netconf.Event) {
+		closed <- event
+	})
+
+	errs := make(chan error, 1)
+	go func() {
+		_, err := session.SyncRPC(message.NewGetConfig(message.DatastoreRunning, "", ""), 30)
+		errs <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+	// the server closes the session, as upon a kill-session from another client
+	_ = transport.Close()
+
+	var closedErr *netconf.SessionClosedError
+	select {
+	case err := <-errs:
+		if !errors.As(err, &closedErr) || !errors.Is(err, io.EOF) || !errors.Is(err, netconf.ErrSessionClosed) {
+			t.Errorf("got error %v, wanted a SessionClosedError caused by io.EOF", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("pending RPC not failed once the server closed the session")
+	}
+	select {
+	case event := <-closed:
+		if !errors.As(netconf.EventErr(event), &closedErr) || closedErr.SessionID != 1 {
+			t.Errorf("got close event error %v, wanted a SessionClosedError of session 1", netconf.EventErr(event))
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("close event not dispatched")
+	}
+
+	requests := len(transport.Requests())
+	if _, err := session.SyncRPC(message.NewGetConfig(message.DatastoreRunning, "", ""), 30); !errors.As(err, &closedErr) {
+		t.Errorf("got error %v, wanted a SessionClosedError", err)
+	}
+	if len(transport.Requests()) != requests {
+		t.Errorf("expected no request sent on a closed session")
+	}
+}
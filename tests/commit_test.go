@@ -0,0 +1,159 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openshift-telco/go-netconf-client/netconf"
+	"github.com/openshift-telco/go-netconf-client/netconf/mock"
+)
+
+const confirmedCommitHello = `<hello xmlns="urn:ietf:params:xml:ns:netconf:base:1.0"><capabilities>` +
+	`<capability>urn:ietf:params:netconf:base:1.1</capability>` +
+	`<capability>urn:ietf:params:netconf:capability:candidate:1.0</capability>` +
+	`<capability>urn:ietf:params:netconf:capability:confirmed-commit:1.1</capability>` +
+	`</capabilities><session-id>1</session-id></hello>`
+
+func TestConfirmedCommit(t *testing.T) {
+	transport := mock.NewTransport(mock.WithHello(confirmedCommitHello))
+	session := newMockSession(t, transport)
+	defer session.Close()
+
+	start := time.Now()
+	pending, err := session.ConfirmedCommit(context.Background(), time.Minute, "change-42")
+	if err != nil {
+		t.Fatalf("failed to commit: %v", err)
+	}
+	if pending.PersistID != "change-42" {
+		t.Errorf("got persist-id %s, wanted change-42", pending.PersistID)
+	}
+	if pending.Deadline.Before(start.Add(time.Minute)) || pending.Deadline.After(time.Now().Add(time.Minute)) {
+		t.Errorf("got deadline %v, wanted a minute after the commit", pending.Deadline)
+	}
+	if err := pending.Confirm(context.Background()); err != nil {
+		t.Errorf("failed to confirm commit: %v", err)
+	}
+
+	requests := transport.Requests()
+	if got := string(requests[len(requests)-2]); !strings.Contains(got, "<commit><confirmed></confirmed><confirm-timeout>60</confirm-timeout><persist>change-42</persist></commit>") {
+		t.Errorf("got request %s, wanted the confirmed commit", got)
+	}
+	if got := string(requests[len(requests)-1]); !strings.Contains(got, "<commit><persist-id>change-42</persist-id></commit>") {
+		t.Errorf("got request %s, wanted the confirming commit", got)
+	}
+}
+
+func TestConfirmedCommitCancel(t *testing.T) {
+	transport := mock.NewTransport(mock.WithHello(confirmedCommitHello))
+	session := newMockSession(t, transport)
+	defer session.Close()
+
+	pending, err := session.ConfirmedCommit(context.Background(), 0, "")
+	if err != nil {
+		t.Fatalf("failed to commit: %v", err)
+	}
+	if err := pending.Cancel(context.Background()); err != nil {
+		t.Errorf("failed to cancel commit: %v", err)
+	}
+
+	requests := transport.Requests()
+	if got := string(requests[len(requests)-2]); !strings.Contains(got, "<commit><confirmed></confirmed><confirm-timeout>600</confirm-timeout></commit>") {
+		t.Errorf("got request %s, wanted the confirmed commit with the default timeout", got)
+	}
+	if got := string(requests[len(requests)-1]); !strings.Contains(got, "<cancel-commit></cancel-commit>") {
+		t.Errorf("got request %s, wanted the cancel-commit", got)
+	}
+}
+
+func TestConfirmedCommitNotSupported(t *testing.T) {
+	session := newMockSession(t, mock.NewTransport())
+	defer session.Close()
+
+	if _, err := session.ConfirmedCommit(context.Background(), time.Minute, ""); !errors.Is(err, netconf.ErrConfirmedCommitNotSupported) {
+		t.Errorf("got error %v, wanted %v", err, netconf.ErrConfirmedCommitNotSupported)
+	}
+}
+
+func TestCommitConfirmed(t *testing.T) {
+	transport := mock.NewTransport(mock.WithHello(confirmedCommitHello))
+	session := newMockSession(t, transport)
+	defer session.Close()
+
+	checked := false
+	err := session.CommitConfirmed(context.Background(), time.Minute, func(ctx context.Context) error {
+		if deadline, ok := ctx.Deadline(); !ok || time.Until(deadline) > 48*time.Second {
+			t.Errorf("got deadline %v, wanted the confirm margin to be kept", deadline)
+		}
+		checked = true
+		return nil
+	})
+	if err != nil || !checked {
+		t.Fatalf("got error %v and checked %t, wanted the commit confirmed", err, checked)
+	}
+	requests := transport.Requests()
+	if got := string(requests[len(requests)-2]); !strings.Contains(got, "<confirm-timeout>60</confirm-timeout><persist>") {
+		t.Errorf("got request %s, wanted the persistent confirmed commit", got)
+	}
+	if got := string(requests[len(requests)-1]); !strings.Contains(got, "<commit><persist-id>") {
+		t.Errorf("got request %s, wanted the confirming commit", got)
+	}
+
+	failure := errors.New("device unreachable")
+	err = session.CommitConfirmed(context.Background(), time.Minute, func(ctx context.Context) error {
+		return failure
+	})
+	if !errors.Is(err, failure) {
+		t.Errorf("got error %v, wanted the check failure", err)
+	}
+	requests = transport.Requests()
+	if got := string(requests[len(requests)-1]); !strings.Contains(got, "<cancel-commit") || !strings.Contains(got, "<persist-id>") {
+		t.Errorf("got request %s, wanted the cancel-commit", got)
+	}
+
+	// the check does not return before the margin ahead of the timeout
+	err = session.CommitConfirmed(context.Background(), time.Second, func(ctx context.Context) error {
+		time.Sleep(2 * time.Second)
+		return nil
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got error %v, wanted the check to time out", err)
+	}
+	requests = transport.Requests()
+	if got := string(requests[len(requests)-1]); !strings.Contains(got, "<cancel-commit") {
+		t.Errorf("got request %s, wanted the cancel-commit", got)
+	}
+}
+
+func TestCommitConfirmedReconnect(t *testing.T) {
+	var transports []*mock.Transport
+	redial := func() (netconf.Transport, error) {
+		transport := mock.NewTransport(mock.WithHello(confirmedCommitHello))
+		transports = append(transports, transport)
+		return transport, nil
+	}
+	first, _ := redial()
+	session := newMockSession(t, first.(*mock.Transport), netconf.WithRedial(redial))
+	defer session.Close()
+
+	err := session.CommitConfirmed(context.Background(), time.Minute, func(ctx context.Context) error {
+		// the server hangs up while the change is checked
+		_ = transports[0].Close()
+		for session.State() != netconf.StateClosed {
+			time.Sleep(10 * time.Millisecond)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to confirm the commit once reconnected: %v", err)
+	}
+	if len(transports) != 2 {
+		t.Fatalf("got %d transports, wanted the session reconnected", len(transports))
+	}
+	requests := transports[1].Requests()
+	if got := string(requests[len(requests)-1]); !strings.Contains(got, "<commit><persist-id>") {
+		t.Errorf("got request %s, wanted the confirming commit with the persist-id", got)
+	}
+}
@@ -0,0 +1,244 @@
+package tests
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/openshift-telco/go-netconf-client/netconf"
+	"github.com/openshift-telco/go-netconf-client/netconf/message"
+	"github.com/openshift-telco/go-netconf-client/netconf/mock"
+)
+
+func newMockSession(t *testing.T, transport *mock.Transport, options ...netconf.SessionOption) *netconf.Session {
+	t.Helper()
+	session, err := netconf.NewSession(transport, options...)
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+	if err := session.SendHello(&message.Hello{Capabilities: netconf.DefaultCapabilities}); err != nil {
+		t.Fatalf("failed to send hello: %v", err)
+	}
+	return session
+}
+
+func TestConcurrentSyncRPC(t *testing.T) {
+	session := newMockSession(t, mock.NewTransport(mock.WithHandler(func(messageID string, request []byte) []byte {
+		return mock.Reply(messageID, "<data><id>"+messageID+"</id></data>")
+	})))
+	defer session.Close()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 50)
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rpc := message.NewGetConfig(message.DatastoreRunning, "", "")
+			reply, err := session.SyncRPC(rpc, 5)
+			if err != nil {
+				errs <- err
+				return
+			}
+			if want := "<data><id>" + rpc.GetMessageID() + "</id></data>"; reply.Data != want {
+				errs <- fmt.Errorf("got data %s, wanted %s", reply.Data, want)
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+// overlapTransport records whether Send is called while another Send is in progress.
+type overlapTransport struct {
+	*mock.Transport
+	sending atomic.Int32
+	overlap atomic.Bool
+}
+
+func (t *overlapTransport) Send(data []byte) error {
+	if t.sending.Add(1) > 1 {
+		t.overlap.Store(true)
+	}
+	defer t.sending.Add(-1)
+	time.Sleep(time.Millisecond)
+	return t.Transport.Send(data)
+}
+
+func TestConcurrentAsyncRPC(t *testing.T) {
+	transport := &overlapTransport{Transport: mock.NewTransport()}
+	session, err := netconf.NewSession(transport)
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+	defer session.Close()
+	if err := session.SendHello(&message.Hello{Capabilities: netconf.DefaultCapabilities}); err != nil {
+		t.Fatalf("failed to send hello: %v", err)
+	}
+
+	var sent, replied sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		sent.Add(1)
+		replied.Add(1)
+		go func() {
+			defer sent.Done()
+			err := session.AsyncRPC(message.NewGetConfig(message.DatastoreRunning, "", ""), func(event netconf.Event) {
+				replied.Done()
+			})
+			if err != nil {
+				t.Errorf("failed to execute rpc: %v", err)
+				replied.Done()
+			}
+		}()
+	}
+	sent.Wait()
+	replied.Wait()
+	if transport.overlap.Load() {
+		t.Errorf("expected the messages to be sent one at a time")
+	}
+}
+
+func TestDispatcherStress(t *testing.T) {
+	dispatcher := netconf.NewDispatcher()
+	var wg sync.WaitGroup
+	var lock sync.Mutex
+	received := map[string]int{}
+
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				id := fmt.Sprintf("%d-%d", worker, j)
+				dispatcher.Register(id, func(event netconf.Event) {
+					lock.Lock()
+					received[event.EventID()]++
+					lock.Unlock()
+				})
+				if j%10 == 0 {
+					dispatcher.Remove(id)
+					continue
+				}
+				dispatcher.Dispatch(id, netconf.EventTypeRPCReply, &message.RPCReply{MessageID: id})
+				// a duplicated reply finds no callback
+				dispatcher.Dispatch(id, netconf.EventTypeRPCReply, &message.RPCReply{MessageID: id})
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	done := make(chan struct{})
+	go func() {
+		dispatcher.WaitForMessages()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("dispatcher not idle once all callbacks ran")
+	}
+
+	if len(received) != 20*90 {
+		t.Errorf("got %d callbacks invoked, wanted %d", len(received), 20*90)
+	}
+	for id, count := range received {
+		if count != 1 {
+			t.Errorf("callback %s invoked %d times", id, count)
+		}
+	}
+	if stats := dispatcher.CorrelationStats(); stats.Pending != 0 {
+		t.Errorf("got %d pending callbacks, wanted none", stats.Pending)
+	}
+}
+
+func TestCloseDuringRPC(t *testing.T) {
+	session := newMockSession(t, mock.NewTransport(mock.WithHandler(mock.NoReply)))
+
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		go func() {
+			_, err := session.SyncRPC(message.NewGetConfig(message.DatastoreRunning, "", ""), 30)
+			errs <- err
+		}()
+	}
+	// let the requests be sent before closing
+	time.Sleep(50 * time.Millisecond)
+	go session.Close()
+
+	timeout := time.After(5 * time.Second)
+	for i := 0; i < 10; i++ {
+		select {
+		case err := <-errs:
+			if !errors.Is(err, netconf.ErrSessionClosed) {
+				t.Errorf("got error %v, wanted %v", err, netconf.ErrSessionClosed)
+			}
+		case <-timeout:
+			t.Fatalf("pending RPCs not failed upon close")
+		}
+	}
+	if !session.Closed() {
+		t.Errorf("expected the session to be closed")
+	}
+
+	if _, err := session.SyncRPC(message.NewGetConfig(message.DatastoreRunning, "", ""), 30); err == nil {
+		t.Errorf("expected an RPC on a closed session to fail")
+	}
+}
+
+func TestConcurrentSubscriptions(t *testing.T) {
+	const sessions = 5
+	const notifications = 20
+
+	var wg sync.WaitGroup
+	for i := 0; i < sessions; i++ {
+		transport := mock.NewTransport()
+		session := newMockSession(t, transport)
+		changes, err := netconf.SubscribeChan(session, "NETCONF", netconf.XMLNotificationDecoder[configChange]())
+		if err != nil {
+			t.Fatalf("failed to subscribe: %v", err)
+		}
+
+		wg.Add(2)
+		go func(user string) {
+			defer wg.Done()
+			for j := 0; j < notifications; j++ {
+				transport.Notify("<notification xmlns=\"urn:ietf:params:xml:ns:netconf:notification:1.0\">" +
+					"<eventTime>2021-11-01T10:00:00Z</eventTime><netconf-config-change xmlns=\"urn:ietf:params:xml:ns:yang:ietf-netconf-notifications\">" +
+					"<changed-by><username>" + user + "</username></changed-by></netconf-config-change></notification>")
+			}
+		}(fmt.Sprintf("user%d", i))
+		go func(user string) {
+			defer wg.Done()
+			count := 0
+			for change := range changes {
+				if change.User != user {
+					t.Errorf("got notification for %s on the session of %s", change.User, user)
+				}
+				if count++; count == notifications {
+					// closing the session ends the subscription
+					go session.Close()
+				}
+			}
+			if count != notifications {
+				t.Errorf("got %d notifications for %s, wanted %d", count, user, notifications)
+			}
+		}(fmt.Sprintf("user%d", i))
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatalf("subscriptions not ended")
+	}
+}
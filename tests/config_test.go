@@ -0,0 +1,285 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/openshift-telco/go-netconf-client/netconf"
+	"github.com/openshift-telco/go-netconf-client/netconf/message"
+	"github.com/openshift-telco/go-netconf-client/netconf/mock"
+)
+
+// rpcErrorReply replies with an rpc-error to the requests containing the provided element, and with ok otherwise.
+func rpcErrorReply(element string) mock.Handler {
+	return func(messageID string, request []byte) []byte {
+		if strings.Contains(string(request), element) {
+			return mock.Reply(messageID, "<rpc-error><error-type>application</error-type><error-tag>invalid-value</error-tag>"+
+				"<error-severity>error</error-severity><error-message>invalid configuration</error-message></rpc-error>")
+		}
+		return mock.ReplyOK(messageID, request)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	transport := mock.NewTransport()
+	session := newMockSession(t, transport)
+	defer session.Close()
+
+	if err := session.Validate(context.Background(), message.DatastoreCandidate); err != nil {
+		t.Errorf("failed to validate: %v", err)
+	}
+	if err := session.ValidateConfig(context.Background(), data); err != nil {
+		t.Errorf("failed to validate: %v", err)
+	}
+	requests := transport.Requests()
+	if got := string(requests[len(requests)-2]); !strings.Contains(got, "<validate><source><candidate></candidate></source></validate>") {
+		t.Errorf("got request %s, wanted the validation of the candidate datastore", got)
+	}
+	if got := string(requests[len(requests)-1]); !strings.Contains(got, "<validate><source><config>"+data+"</config></source></validate>") {
+		t.Errorf("got request %s, wanted the validation of the inline configuration", got)
+	}
+}
+
+func TestValidateErrors(t *testing.T) {
+	session := newMockSession(t, mock.NewTransport(mock.WithHandler(rpcErrorReply("<validate>"))))
+	defer session.Close()
+
+	err := session.Validate(context.Background(), message.DatastoreCandidate)
+	if err == nil || !strings.Contains(err.Error(), "invalid configuration") {
+		t.Errorf("got error %v, wanted the rpc-error", err)
+	}
+}
+
+func TestCopyConfig(t *testing.T) {
+	transport := mock.NewTransport()
+	session := newMockSession(t, transport)
+	defer session.Close()
+
+	if err := session.CopyConfig(context.Background(), message.DatastoreStartup, message.DatastoreRunning); err != nil {
+		t.Errorf("failed to copy config: %v", err)
+	}
+	requests := transport.Requests()
+	if got := string(requests[len(requests)-1]); !strings.Contains(got, "<copy-config><target><startup></startup></target><source><running></running></source></copy-config>") {
+		t.Errorf("got request %s, wanted the copy of running to startup", got)
+	}
+}
+
+func TestDiscardChanges(t *testing.T) {
+	transport := mock.NewTransport()
+	session := newMockSession(t, transport)
+	defer session.Close()
+
+	if err := session.DiscardChanges(context.Background()); err != nil {
+		t.Errorf("failed to discard changes: %v", err)
+	}
+	requests := transport.Requests()
+	if got := string(requests[len(requests)-1]); !strings.Contains(got, "<discard-changes></discard-changes>") {
+		t.Errorf("got request %s, wanted discard-changes", got)
+	}
+}
+
+func TestKillSession(t *testing.T) {
+	transport := mock.NewTransport()
+	session := newMockSession(t, transport)
+	defer session.Close()
+
+	if err := session.KillSession(context.Background(), 4); err != nil {
+		t.Errorf("failed to kill session: %v", err)
+	}
+	requests := transport.Requests()
+	if got := string(requests[len(requests)-1]); !strings.Contains(got, "<kill-session><session-id>4</session-id></kill-session>") {
+		t.Errorf("got request %s, wanted the kill-session of session 4", got)
+	}
+
+	if err := session.KillSession(context.Background(), session.SessionID()); err == nil {
+		t.Errorf("expected killing the current session to fail")
+	}
+	if got := len(transport.Requests()); got != len(requests) {
+		t.Errorf("expected no kill-session to be sent for the current session")
+	}
+}
+
+func TestAction(t *testing.T) {
+	output := `<reset-finished-at xmlns="urn:example:interfaces">2021-11-01T10:00:00Z</reset-finished-at>`
+	session := newMockSession(t, mock.NewTransport(mock.WithHandler(func(messageID string, request []byte) []byte {
+		if !strings.Contains(string(request), `<action xmlns="urn:ietf:params:xml:ns:yang:1">`) {
+			return mock.ReplyOK(messageID, request)
+		}
+		return mock.Reply(messageID, output)
+	})))
+	defer session.Close()
+
+	reply, err := session.Action(context.Background(), `<interfaces xmlns="urn:example:interfaces"><interface><name>eth0</name><reset/></interface></interfaces>`)
+	if err != nil {
+		t.Fatalf("failed to invoke action: %v", err)
+	}
+	if reply.Data != output {
+		t.Errorf("got output %s, wanted %s", reply.Data, output)
+	}
+}
+
+func TestEditConfigOptions(t *testing.T) {
+	hello := `<hello xmlns="urn:ietf:params:xml:ns:netconf:base:1.0"><capabilities>` +
+		`<capability>urn:ietf:params:netconf:base:1.1</capability>` +
+		`<capability>urn:ietf:params:netconf:capability:validate:1.1</capability>` +
+		`<capability>urn:ietf:params:netconf:capability:rollback-on-error:1.0</capability>` +
+		`</capabilities><session-id>1</session-id></hello>`
+	transport := mock.NewTransport(mock.WithHello(hello))
+	session := newMockSession(t, transport)
+	defer session.Close()
+
+	edit := message.NewEditConfigWithOptions(message.DatastoreRunning, message.DefaultOperationTypeMerge,
+		message.TestOptionTestThenSet, message.ErrorOptionRollbackOnError, data)
+	if err := session.EditConfig(context.Background(), edit); err != nil {
+		t.Errorf("failed to edit config: %v", err)
+	}
+	requests := transport.Requests()
+	if got := string(requests[len(requests)-1]); !strings.Contains(got, "<default-operation>merge</default-operation>"+
+		"<test-option>test-then-set</test-option><error-option>rollback-on-error</error-option><config>") {
+		t.Errorf("got request %s, wanted the test-option and error-option", got)
+	}
+}
+
+func TestEditConfigOptionsNotSupported(t *testing.T) {
+	session := newMockSession(t, mock.NewTransport())
+	defer session.Close()
+
+	edit := message.NewEditConfigWithOptions(message.DatastoreRunning, message.DefaultOperationTypeMerge,
+		message.TestOptionTestOnly, "", data)
+	if err := session.EditConfig(context.Background(), edit); !errors.Is(err, netconf.ErrTestOptionNotSupported) {
+		t.Errorf("got error %v, wanted %v", err, netconf.ErrTestOptionNotSupported)
+	}
+	edit = message.NewEditConfigWithOptions(message.DatastoreRunning, message.DefaultOperationTypeMerge,
+		"", message.ErrorOptionRollbackOnError, data)
+	if err := session.EditConfig(context.Background(), edit); !errors.Is(err, netconf.ErrRollbackOnErrorNotSupported) {
+		t.Errorf("got error %v, wanted %v", err, netconf.ErrRollbackOnErrorNotSupported)
+	}
+	edit = message.NewEditConfigWithOptions(message.DatastoreRunning, message.DefaultOperationTypeMerge,
+		"", message.ErrorOptionContinueOnError, data)
+	if err := session.EditConfig(context.Background(), edit); err != nil {
+		t.Errorf("failed to edit config: %v", err)
+	}
+
+	didPanic := panics(func() {
+		message.NewEditConfigWithOptions(message.DatastoreRunning, message.DefaultOperationTypeMerge, "test", "", data)
+	})
+	if !didPanic {
+		t.Errorf("expected an invalid test-option to panic")
+	}
+}
+
+func TestConfigURL(t *testing.T) {
+	hello := `<hello xmlns="urn:ietf:params:xml:ns:netconf:base:1.0"><capabilities>` +
+		`<capability>urn:ietf:params:netconf:base:1.1</capability>` +
+		`<capability>urn:ietf:params:netconf:capability:url:1.0?scheme=ftp,https</capability>` +
+		`</capabilities><session-id>1</session-id></hello>`
+	transport := mock.NewTransport(mock.WithHello(hello))
+	session := newMockSession(t, transport)
+	defer session.Close()
+
+	if err := session.CopyConfigFromURL(context.Background(), message.DatastoreRunning, "ftp://server/config.xml"); err != nil {
+		t.Errorf("failed to copy config: %v", err)
+	}
+	requests := transport.Requests()
+	if got := string(requests[len(requests)-1]); !strings.Contains(got, "<copy-config><target><running></running></target><source><url>ftp://server/config.xml</url></source></copy-config>") {
+		t.Errorf("got request %s, wanted the copy of the url to running", got)
+	}
+
+	edit := message.NewEditConfigFromURL(message.DatastoreRunning, message.DefaultOperationTypeMerge, "https://server/config.xml")
+	if err := session.EditConfig(context.Background(), edit); err != nil {
+		t.Errorf("failed to edit config: %v", err)
+	}
+	requests = transport.Requests()
+	if got := string(requests[len(requests)-1]); !strings.Contains(got, "<default-operation>merge</default-operation><url>https://server/config.xml</url></edit-config>") {
+		t.Errorf("got request %s, wanted the edit of the url", got)
+	}
+
+	if err := session.CopyConfigToURL(context.Background(), "file:///backup.xml", message.DatastoreRunning); !errors.Is(err, netconf.ErrURLNotSupported) {
+		t.Errorf("got error %v, wanted %v", err, netconf.ErrURLNotSupported)
+	}
+	didPanic := panics(func() { message.NewCopyConfigFromURL(message.DatastoreRunning, "config.xml") })
+	if !didPanic {
+		t.Errorf("expected a relative url to panic")
+	}
+}
+
+func TestConfigURLNotSupported(t *testing.T) {
+	session := newMockSession(t, mock.NewTransport())
+	defer session.Close()
+
+	if err := session.CopyConfigFromURL(context.Background(), message.DatastoreRunning, "ftp://server/config.xml"); !errors.Is(err, netconf.ErrURLNotSupported) {
+		t.Errorf("got error %v, wanted %v", err, netconf.ErrURLNotSupported)
+	}
+}
+
+func TestRPCErrorAs(t *testing.T) {
+	session := newMockSession(t, mock.NewTransport(mock.WithHandler(rpcErrorReply("<validate>"))))
+	defer session.Close()
+
+	err := session.Validate(context.Background(), message.DatastoreCandidate)
+	var rpcErr *message.RPCError
+	if !errors.As(err, &rpcErr) {
+		t.Fatalf("got error %v, wanted an RPCError", err)
+	}
+	if rpcErr.Tag != message.ErrorTagInvalidValue || rpcErr.Type != message.ErrorTypeApplication || rpcErr.IsWarning() {
+		t.Errorf("got rpc-error %+v, wanted an application invalid-value error", rpcErr)
+	}
+	if !errors.Is(err, &message.RPCError{Tag: message.ErrorTagInvalidValue}) {
+		t.Errorf("expected error %v to be invalid-value", err)
+	}
+	if errors.Is(err, &message.RPCError{Tag: message.ErrorTagLockDenied}) {
+		t.Errorf("expected error %v not to be lock-denied", err)
+	}
+}
+
+func TestGetConfigData(t *testing.T) {
+	config := "\n  <top xmlns=\"http://example.com/schema/1.2/config\">\n    <users><user><name>fred</name></user></users>\n  </top>\n"
+	session := newMockSession(t, mock.NewTransport(mock.WithHandler(mock.ReplyData(config))))
+	defer session.Close()
+
+	data, err := session.GetConfigData(context.Background(), message.DatastoreRunning, "<top/>")
+	if err != nil {
+		t.Fatalf("failed to get config data: %v", err)
+	}
+	if string(data.Raw) != config {
+		t.Errorf("got data %q, wanted %q", data.Raw, config)
+	}
+	if len(data.Nodes) != 1 || data.Nodes[0].XMLName.Space != "http://example.com/schema/1.2/config" {
+		t.Fatalf("got nodes %+v, wanted top", data.Nodes)
+	}
+	if user := data.Nodes[0].Child("users").Child("user"); user == nil || user.Child("name").Text != "fred" || user.Child("mtu") != nil {
+		t.Errorf("got top %+v, wanted the user fred", data.Nodes[0])
+	}
+
+	empty := newMockSession(t, mock.NewTransport(mock.WithHandler(mock.ReplyData(""))))
+	defer empty.Close()
+	if data, err := empty.GetConfigData(context.Background(), message.DatastoreRunning, ""); err != nil || len(data.Raw) != 0 || len(data.Nodes) != 0 {
+		t.Errorf("got data %+v and error %v, wanted no data", data, err)
+	}
+}
+
+func TestWithWarningPolicy(t *testing.T) {
+	warning := func(messageID string, request []byte) []byte {
+		return mock.Reply(messageID, "<rpc-error><error-type>application</error-type><error-tag>operation-failed</error-tag>"+
+			"<error-severity>warning</error-severity><error-message>node is deprecated</error-message></rpc-error>"+
+			`<data><top xmlns="http://example.com/schema/1.2/config"/></data>`)
+	}
+
+	failing := newMockSession(t, mock.NewTransport(mock.WithHandler(warning)))
+	defer failing.Close()
+	if _, err := failing.GetConfigData(context.Background(), message.DatastoreRunning, ""); err == nil || !strings.Contains(err.Error(), "deprecated") {
+		t.Errorf("got error %v, wanted the warning to fail get-config", err)
+	}
+
+	ignoring := newMockSession(t, mock.NewTransport(mock.WithHandler(warning)), netconf.WithWarningPolicy(netconf.WarningsIgnore))
+	defer ignoring.Close()
+	data, err := ignoring.GetConfigData(context.Background(), message.DatastoreRunning, "")
+	if err != nil || len(data.Nodes) != 1 {
+		t.Errorf("got data %+v and error %v, wanted the warning ignored", data, err)
+	}
+	if err := ignoring.Validate(context.Background(), message.DatastoreCandidate); err != nil {
+		t.Errorf("got error %v, wanted the warning ignored", err)
+	}
+}
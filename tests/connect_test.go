@@ -0,0 +1,39 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/openshift-telco/go-netconf-client/netconf"
+	"github.com/openshift-telco/go-netconf-client/netconf/message"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestConnect(t *testing.T) {
+	address := startSSHServer(t, "127.0.0.1:0", errorHandler)
+
+	session, err := netconf.Connect(address, sshClientConfig())
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer session.Close()
+
+	reply, err := session.SyncRPC(message.NewGetConfig(message.DatastoreRunning, "", ""), 5)
+	if err != nil {
+		t.Fatalf("failed to execute rpc: %v", err)
+	}
+	if len(reply.Errors) != 1 {
+		t.Errorf("got errors %v, wanted the server rpc-error", reply.Errors)
+	}
+}
+
+func TestConnectInvalidServerHello(t *testing.T) {
+	address := startSSHServer(t, "127.0.0.1:0", func(channel ssh.Channel) {
+		_, _ = channel.Write([]byte(strings.Replace(serverHello, "<session-id>7</session-id>", "", 1)))
+	})
+
+	_, err := netconf.Connect(address, sshClientConfig())
+	if err == nil || !strings.Contains(err.Error(), "session-id") {
+		t.Errorf("got %v, wanted a missing session-id error", err)
+	}
+}
@@ -0,0 +1,37 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/openshift-telco/go-netconf-client/netconf/correlate"
+)
+
+func TestCorrelator(t *testing.T) {
+	c := correlate.New[string]()
+	c.Register("1", "first", time.Time{})
+	c.Register("2", "second", time.Now().Add(-time.Second))
+	c.Register("stream", "notifications", time.Time{})
+
+	if handler, ok := c.Match("1"); !ok || handler != "first" {
+		t.Errorf("got %q, %v, wanted first", handler, ok)
+	}
+	if _, ok := c.Match("1"); ok {
+		t.Errorf("expected a match to remove the registration")
+	}
+	for i := 0; i < 2; i++ {
+		if handler, ok := c.Peek("stream"); !ok || handler != "notifications" {
+			t.Errorf("got %q, %v, wanted notifications", handler, ok)
+		}
+	}
+
+	expired := c.Timeouts(time.Now())
+	if len(expired) != 1 || expired[0].ID != "2" || expired[0].Handler != "second" {
+		t.Errorf("got expired %v, wanted the second registration", expired)
+	}
+
+	stats := c.Stats()
+	if stats.Registered != 3 || stats.Matched != 1 || stats.Unmatched != 1 || stats.TimedOut != 1 || stats.Pending != 1 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
@@ -0,0 +1,60 @@
+package tests
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openshift-telco/go-netconf-client/netconf"
+	"github.com/openshift-telco/go-netconf-client/netconf/message"
+	"github.com/openshift-telco/go-netconf-client/netconf/mock"
+)
+
+func TestDebugSnapshot(t *testing.T) {
+	transport := mock.NewTransport(mock.WithHandler(mock.NoReply))
+	session := newMockSession(t, transport)
+	defer session.Close()
+
+	rpc := message.NewGetConfig(message.DatastoreRunning, "", "")
+	if err := session.AsyncRPC(rpc, func(event netconf.Event) {}); err != nil {
+		t.Fatalf("failed to execute rpc: %v", err)
+	}
+	transport.Notify("<unknown/>")
+	select {
+	case <-session.Errors():
+	case <-time.After(5 * time.Second):
+		t.Fatalf("unknown message not reported")
+	}
+
+	snapshot := session.DebugSnapshot()
+	if snapshot.SessionID != session.SessionID() {
+		t.Errorf("got session-id %d, wanted %d", snapshot.SessionID, session.SessionID())
+	}
+	if snapshot.State != netconf.StateEstablished.String() {
+		t.Errorf("got state %s, wanted %s", snapshot.State, netconf.StateEstablished)
+	}
+	if len(snapshot.ServerCapabilities) == 0 || len(snapshot.ClientCapabilities) == 0 {
+		t.Errorf("expected the capabilities of both sides, got %v and %v", snapshot.ServerCapabilities, snapshot.ClientCapabilities)
+	}
+	pending := false
+	for _, id := range snapshot.Pending {
+		pending = pending || id == rpc.GetMessageID()
+	}
+	if !pending {
+		t.Errorf("expected the RPC %s to be pending, got %v", rpc.GetMessageID(), snapshot.Pending)
+	}
+	if snapshot.Stats.RPCsSent != 1 {
+		t.Errorf("got %d RPCs sent, wanted 1", snapshot.Stats.RPCsSent)
+	}
+	if len(snapshot.RecentErrors) != 1 || !strings.Contains(snapshot.RecentErrors[0].Error, netconf.ErrUnknownMessage.Error()) {
+		t.Errorf("got recent errors %v, wanted the unknown message", snapshot.RecentErrors)
+	}
+	if snapshot.Subscription.Active {
+		t.Errorf("expected no active subscription")
+	}
+
+	if _, err := json.Marshal(snapshot); err != nil {
+		t.Errorf("failed to encode snapshot: %v", err)
+	}
+}
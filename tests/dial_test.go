@@ -0,0 +1,146 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/openshift-telco/go-netconf-client/netconf"
+	"golang.org/x/crypto/ssh"
+)
+
+// unusedAddress returns an address on which nothing listens.
+func unusedAddress(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	address := listener.Addr().String()
+	_ = listener.Close()
+	return address
+}
+
+func TestNewSessionFromSSHConfigMulti(t *testing.T) {
+	address := startSSHServer(t, "127.0.0.1:0", helloHandler)
+
+	session, err := netconf.NewSessionFromSSHConfigMulti(
+		[]string{unusedAddress(t), address}, sshClientConfig(), time.Second,
+	)
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+	defer session.Close()
+
+	if session.SessionID() != 7 {
+		t.Errorf("got session-id %d, wanted 7", session.SessionID())
+	}
+}
+
+func TestDialSSHMultiAllFailing(t *testing.T) {
+	_, err := netconf.DialSSHMulti([]string{unusedAddress(t), unusedAddress(t)}, sshClientConfig(), time.Second)
+	if err == nil {
+		t.Errorf("expected dialing unreachable targets to fail")
+	}
+}
+
+func TestDialSSHMultiContextCancelled(t *testing.T) {
+	// the server accepts the connections but never completes the SSH handshake
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err = netconf.DialSSHMultiContext(ctx, []string{listener.Addr().String()}, sshClientConfig(), time.Minute)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got error %v, wanted the context deadline", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("dial returned after %v, wanted it cancelled with the context", elapsed)
+	}
+}
+
+func TestDialSSHSourceAddress(t *testing.T) {
+	address := startSSHServer(t, "127.0.0.1:0", helloHandler)
+
+	transport, err := netconf.DialSSH(address, sshClientConfig(), netconf.WithSourceAddress("127.0.0.1"))
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	defer transport.Close()
+
+	_, err = netconf.DialSSH(address, sshClientConfig(), netconf.WithSourceAddress("not-an-ip"))
+	if err == nil {
+		t.Errorf("expected dialing with an invalid source address to fail")
+	}
+}
+
+func TestDialSSHIPv6Zone(t *testing.T) {
+	listener, err := net.Listen("tcp", "[::1]:0")
+	if err != nil {
+		t.Skipf("IPv6 loopback not available: %v", err)
+	}
+	_ = listener.Close()
+
+	zone := ""
+	interfaces, _ := net.Interfaces()
+	for _, iface := range interfaces {
+		if iface.Flags&net.FlagLoopback != 0 {
+			zone = iface.Name
+		}
+	}
+	if zone == "" {
+		t.Skip("no loopback interface found")
+	}
+
+	address := startSSHServer(t, "[::1]:0", helloHandler)
+	_, port, _ := net.SplitHostPort(address)
+
+	// zone identifiers are passed through as for link-local addresses, e.g. fe80::1%eth0
+	transport, err := netconf.DialSSH(
+		net.JoinHostPort("::1%"+zone, port), sshClientConfig(), netconf.WithSourceAddress("::1%"+zone),
+	)
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	_ = transport.Close()
+}
+
+func TestReopenSubsystemOnEOFBeforeHello(t *testing.T) {
+	var channels atomic.Int32
+	address := startSSHServer(t, "127.0.0.1:0", func(channel ssh.Channel) {
+		if channels.Add(1) == 1 {
+			_ = channel.Close()
+			return
+		}
+		helloHandler(channel)
+	})
+
+	session, err := netconf.NewSessionFromSSHConfig(address, sshClientConfig())
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+	defer session.Close()
+
+	if session.SessionID() != 7 {
+		t.Errorf("got session-id %d, wanted 7", session.SessionID())
+	}
+	if got := channels.Load(); got != 2 {
+		t.Errorf("got %d channels, wanted 2", got)
+	}
+}
@@ -0,0 +1,77 @@
+package tests
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/openshift-telco/go-netconf-client/netconf"
+	"github.com/openshift-telco/go-netconf-client/netconf/message"
+	"github.com/openshift-telco/go-netconf-client/netconf/mock"
+)
+
+const testNotification = "<notification xmlns=\"urn:ietf:params:xml:ns:netconf:notification:1.0\">" +
+	"<eventTime>2021-11-01T10:00:00Z</eventTime></notification>"
+
+func TestErrorPolicy(t *testing.T) {
+	for name, test := range map[string]struct {
+		policy   netconf.ErrorPolicy
+		messages []string
+		failed   bool
+	}{
+		"continue":          {policy: netconf.ContinueOnError, messages: []string{"<a/>", "<b/>", "<c/>"}},
+		"fail fast":         {policy: netconf.FailFast, messages: []string{"<a/>"}, failed: true},
+		"below threshold":   {policy: netconf.FailAfter(3), messages: []string{"<a/>", "<b/>", testNotification, "<c/>", "<d/>"}},
+		"reached threshold": {policy: netconf.FailAfter(3), messages: []string{"<a/>", testNotification, "<b/>", "<c/>", "<d/>"}, failed: true},
+	} {
+		transport := mock.NewTransport()
+		session := newMockSession(t, transport, netconf.WithErrorPolicy(test.policy))
+		closed := make(chan error, 1)
+		session.OnClose(func(s *netconf.Session, err error) {
+			closed <- err
+		})
+		notified := make(chan struct{}, len(test.messages))
+		session.Listener.Register(message.NetconfNotificationStreamHandler, func(event netconf.Event) {
+			notified <- struct{}{}
+		})
+		for _, m := range test.messages {
+			transport.Notify(m)
+		}
+
+		if test.failed {
+			select {
+			case err := <-closed:
+				var policyErr *netconf.ErrorPolicyError
+				if !errors.As(err, &policyErr) || !errors.Is(err, netconf.ErrUnknownMessage) {
+					t.Errorf("%s: got close cause %v, wanted an ErrorPolicyError", name, err)
+				}
+				if session.State() != netconf.StateFailed {
+					t.Errorf("%s: got state %s, wanted %s", name, session.State(), netconf.StateFailed)
+				}
+			case <-time.After(5 * time.Second):
+				t.Errorf("%s: session not failed by the error policy", name)
+			}
+			continue
+		}
+
+		// the session is still receiving messages
+		transport.Notify(testNotification)
+		want := 1
+		for _, m := range test.messages {
+			if m == testNotification {
+				want++
+			}
+		}
+		for i := 0; i < want; i++ {
+			select {
+			case <-notified:
+			case <-time.After(5 * time.Second):
+				t.Fatalf("%s: session stopped receiving messages", name)
+			}
+		}
+		if session.Closed() {
+			t.Errorf("%s: expected the session to stay open", name)
+		}
+		_ = session.Close()
+	}
+}
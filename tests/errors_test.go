@@ -0,0 +1,94 @@
+package tests
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openshift-telco/go-netconf-client/netconf"
+	"github.com/openshift-telco/go-netconf-client/netconf/message"
+	"github.com/openshift-telco/go-netconf-client/netconf/mock"
+)
+
+func TestSessionErrors(t *testing.T) {
+	transport := mock.NewTransport()
+	session := newMockSession(t, transport)
+
+	transport.Notify("<unexpected/>")
+	transport.Notify("<rpc-reply message-id=\"1\"><data></rpc-reply>")
+	// the server hangs up
+	_ = transport.Close()
+
+	var errs []error
+	timeout := time.After(5 * time.Second)
+	for done := false; !done; {
+		select {
+		case err, ok := <-session.Errors():
+			if !ok {
+				done = true
+				break
+			}
+			errs = append(errs, err)
+		case <-timeout:
+			t.Fatalf("errors channel not closed once the session stopped")
+		}
+	}
+
+	if len(errs) != 3 {
+		t.Fatalf("got errors %v, wanted 3", errs)
+	}
+	var receiveErr *netconf.ReceiveError
+	if !errors.As(errs[0], &receiveErr) || !errors.Is(errs[0], netconf.ErrUnknownMessage) || string(receiveErr.Message) != "<unexpected/>" {
+		t.Errorf("got error %v, wanted an unknown message error", errs[0])
+	}
+	if !errors.As(errs[1], &receiveErr) || receiveErr.Message == nil {
+		t.Errorf("got error %v, wanted a decoding error", errs[1])
+	}
+	if !errors.As(errs[2], &receiveErr) || !errors.Is(errs[2], io.EOF) {
+		t.Errorf("got error %v, wanted io.EOF", errs[2])
+	}
+	if state := session.State(); state != netconf.StateClosed {
+		t.Errorf("got state %s, wanted %s", state, netconf.StateClosed)
+	}
+}
+
+func TestUnexpectedReply(t *testing.T) {
+	transport := mock.NewTransport()
+	session := newMockSession(t, transport)
+	defer session.Close()
+
+	transport.Notify("<rpc-reply xmlns=\"urn:ietf:params:xml:ns:netconf:base:1.0\" message-id=\"unknown\"><ok/></rpc-reply>")
+	transport.Notify("<rpc-reply xmlns=\"urn:ietf:params:xml:ns:netconf:base:1.0\"><ok/></rpc-reply>")
+
+	for _, want := range []string{"\"unknown\" matches no outstanding request", "without message-id"} {
+		select {
+		case err := <-session.Errors():
+			var protocolErr *netconf.ProtocolError
+			if !errors.As(err, &protocolErr) || !strings.Contains(protocolErr.Reason, want) || protocolErr.Message == nil {
+				t.Errorf("got error %v, wanted a ProtocolError for a reply %s", err, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("unexpected reply not reported")
+		}
+	}
+}
+
+func TestClientHelloSessionID(t *testing.T) {
+	transport := mock.NewTransport()
+	session, err := netconf.NewSession(transport)
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+	defer session.Close()
+
+	err = session.SendHello(&message.Hello{Capabilities: netconf.DefaultCapabilities, SessionID: 4})
+	var protocolErr *netconf.ProtocolError
+	if !errors.As(err, &protocolErr) {
+		t.Errorf("got error %v, wanted a ProtocolError", err)
+	}
+	if len(transport.Requests()) != 0 {
+		t.Errorf("expected the invalid hello not to be sent")
+	}
+}
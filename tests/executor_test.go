@@ -0,0 +1,169 @@
+package tests
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/openshift-telco/go-netconf-client/netconf"
+	"github.com/openshift-telco/go-netconf-client/netconf/message"
+)
+
+func TestCallbackExecutor(t *testing.T) {
+	var submitted atomic.Int32
+	executor := netconf.ExecutorFunc(func(task func()) {
+		submitted.Add(1)
+		go task()
+	})
+
+	session, err := netconf.NewSession(newEchoTransport(), netconf.WithCallbackExecutor(executor))
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+	defer session.Close()
+	if err := session.SendHello(&message.Hello{Capabilities: netconf.DefaultCapabilities}); err != nil {
+		t.Fatalf("failed to send hello: %v", err)
+	}
+
+	reply, err := session.SyncRPC(message.NewGetConfig(message.DatastoreRunning, "", ""), 5)
+	if err != nil {
+		t.Fatalf("failed to execute rpc: %v", err)
+	}
+	if len(reply.Errors) != 0 {
+		t.Errorf("unexpected errors: %v", reply.Errors)
+	}
+	if got := submitted.Load(); got != 1 {
+		t.Errorf("got %d submitted callbacks, wanted 1", got)
+	}
+}
+
+func TestWaitForMessages(t *testing.T) {
+	executor := netconf.ExecutorFunc(func(task func()) {
+		go func() {
+			time.Sleep(20 * time.Millisecond)
+			task()
+		}()
+	})
+
+	session, err := netconf.NewSession(newEchoTransport(), netconf.WithCallbackExecutor(executor))
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+	defer session.Close()
+	if err := session.SendHello(&message.Hello{Capabilities: netconf.DefaultCapabilities}); err != nil {
+		t.Fatalf("failed to send hello: %v", err)
+	}
+
+	var processed atomic.Int32
+	for i := 0; i < 3; i++ {
+		err := session.AsyncRPC(message.NewGetConfig(message.DatastoreRunning, "", ""), func(event netconf.Event) {
+			processed.Add(1)
+		})
+		if err != nil {
+			t.Fatalf("failed to send rpc: %v", err)
+		}
+	}
+
+	session.Listener.WaitForMessages()
+	if got := processed.Load(); got != 3 {
+		t.Errorf("got %d processed replies, wanted 3", got)
+	}
+}
+
+func TestCallbackPanicLogged(t *testing.T) {
+	var logs bytes.Buffer
+	session, err := netconf.NewSession(newEchoTransport(), netconf.WithLogger(slog.New(slog.NewTextHandler(&logs, nil))))
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+	defer session.Close()
+	if err := session.SendHello(&message.Hello{Capabilities: netconf.DefaultCapabilities}); err != nil {
+		t.Fatalf("failed to send hello: %v", err)
+	}
+
+	err = session.AsyncRPC(message.NewGetConfig(message.DatastoreRunning, "", ""), func(event netconf.Event) {
+		panic("boom")
+	})
+	if err != nil {
+		t.Fatalf("failed to send rpc: %v", err)
+	}
+	session.Listener.WaitForMessages()
+
+	if got := session.Listener.Stats()["rpc-reply"].Panics; got != 1 {
+		t.Errorf("got %d panics, wanted 1", got)
+	}
+	if log := logs.String(); !strings.Contains(log, "callback panicked") || !strings.Contains(log, "panic=boom") || !strings.Contains(log, "stack=") {
+		t.Errorf("got logs %q, wanted the panic value and stack", log)
+	}
+	if log := logs.String(); !strings.Contains(log, "session-id=") {
+		t.Errorf("got logs %q, wanted the session fields", log)
+	}
+
+	// the session keeps dispatching the replies
+	if _, err := session.SyncRPC(message.NewGetConfig(message.DatastoreRunning, "", ""), 5); err != nil {
+		t.Errorf("failed to execute rpc after the panic: %v", err)
+	}
+}
+
+func TestDispatchPanicLogged(t *testing.T) {
+	var logs bytes.Buffer
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+
+	dispatcher := netconf.NewDispatcher()
+	dispatcher.Register("stream", func(event netconf.Event) {
+		panic("boom")
+	})
+	dispatcher.Dispatch("stream", netconf.EventTypeNotification, &message.Notification{})
+
+	if got := dispatcher.Stats()["stream"].Panics; got != 1 {
+		t.Errorf("got %d panics, wanted 1", got)
+	}
+	if log := logs.String(); !strings.Contains(log, "callback panicked") || !strings.Contains(log, "panic=boom") {
+		t.Errorf("got logs %q, wanted the panic logged by the default logger", log)
+	}
+}
+
+func TestCallbackPanicExecutor(t *testing.T) {
+	panics := make(chan any, 1)
+	executor := netconf.ExecutorFunc(func(task func()) {
+		go func() {
+			defer func() {
+				panics <- recover()
+			}()
+			task()
+		}()
+	})
+
+	session, err := netconf.NewSession(newEchoTransport(), netconf.WithCallbackExecutor(executor))
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+	defer session.Close()
+	if err := session.SendHello(&message.Hello{Capabilities: netconf.DefaultCapabilities}); err != nil {
+		t.Fatalf("failed to send hello: %v", err)
+	}
+
+	err = session.AsyncRPC(message.NewGetConfig(message.DatastoreRunning, "", ""), func(event netconf.Event) {
+		panic("boom")
+	})
+	if err != nil {
+		t.Fatalf("failed to send rpc: %v", err)
+	}
+
+	select {
+	case recovered := <-panics:
+		if recovered != "boom" {
+			t.Errorf("got %v recovered by the executor, wanted boom", recovered)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("callback not run by the executor")
+	}
+	session.Listener.WaitForMessages()
+	if got := session.Listener.Stats()["rpc-reply"].Panics; got != 1 {
+		t.Errorf("got %d panics, wanted 1", got)
+	}
+}
@@ -0,0 +1,134 @@
+package tests
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/openshift-telco/go-netconf-client/netconf"
+	"github.com/openshift-telco/go-netconf-client/netconf/message"
+	"github.com/openshift-telco/go-netconf-client/netconf/mock"
+)
+
+func TestParseFeatures(t *testing.T) {
+	features := netconf.ParseFeatures([]string{
+		"urn:ietf:params:netconf:base:1.1",
+		"urn:ietf:params:netconf:capability:candidate:1.0",
+		"urn:ietf:params:netconf:capability:confirmed-commit:1.0",
+		"urn:ietf:params:netconf:capability:validate:1.1",
+		"urn:ietf:params:netconf:capability:url:1.0?scheme=file,https",
+		" urn:ietf:params:netconf:capability:xpath:1.0 ",
+		"urn:ietf:params:netconf:capability:notification:1.0",
+		"urn:ietf:params:xml:ns:yang:ietf-netconf-monitoring?module=ietf-netconf-monitoring&revision=2010-10-04",
+	})
+	want := netconf.NegotiatedFeatures{
+		Candidate:       true,
+		ConfirmedCommit: true,
+		Validate:        true,
+		URL:             true,
+		XPath:           true,
+		Notifications:   true,
+		URLSchemes:      []string{"file", "https"},
+	}
+	if !reflect.DeepEqual(features, want) {
+		t.Errorf("got features %+v, wanted %+v", features, want)
+	}
+}
+
+func TestSessionFeatures(t *testing.T) {
+	hello := `<hello xmlns="urn:ietf:params:xml:ns:netconf:base:1.0"><capabilities>` +
+		`<capability>urn:ietf:params:netconf:base:1.0</capability>` +
+		`<capability>urn:ietf:params:netconf:capability:writable-running:1.0</capability>` +
+		`<capability>urn:ietf:params:netconf:capability:rollback-on-error:1.0</capability>` +
+		`<capability>urn:ietf:params:netconf:capability:interleave:1.0</capability>` +
+		`<capability>urn:ietf:params:netconf:capability:startup:1.0</capability>` +
+		`</capabilities><session-id>1</session-id></hello>`
+	session := newMockSession(t, mock.NewTransport(mock.WithHello(hello)))
+	defer session.Close()
+
+	features := session.Features()
+	if !features.WritableRunning || !features.RollbackOnError || !features.Interleave || !features.Startup {
+		t.Errorf("got features %+v, wanted writable-running, rollback-on-error, interleave and startup", features)
+	}
+	if features.Candidate || features.URL {
+		t.Errorf("got features %+v, wanted no candidate nor url", features)
+	}
+}
+
+func TestParseWithDefaultsFeatures(t *testing.T) {
+	features := netconf.ParseFeatures([]string{
+		"urn:ietf:params:netconf:capability:with-defaults:1.0?basic-mode=explicit&also-supported=report-all,trim",
+	})
+	if !features.WithDefaults || features.WithDefaultsBasicMode != message.WithDefaultsExplicit {
+		t.Errorf("got features %+v, wanted with-defaults in explicit basic mode", features)
+	}
+	if want := []string{"explicit", "report-all", "trim"}; !reflect.DeepEqual(features.WithDefaultsModes, want) {
+		t.Errorf("got with-defaults modes %v, wanted %v", features.WithDefaultsModes, want)
+	}
+	if !features.SupportsWithDefaults(message.WithDefaultsTrim) || features.SupportsWithDefaults(message.WithDefaultsReportAllTagged) {
+		t.Errorf("got with-defaults modes %v, wanted trim supported and report-all-tagged not", features.WithDefaultsModes)
+	}
+}
+
+func TestParseCapability(t *testing.T) {
+	capability, err := netconf.ParseCapability(" urn:ietf:params:xml:ns:yang:ietf-interfaces?module=ietf-interfaces&revision=2018-02-20" +
+		"&features=pre-provisioning,arbitrary-names,if-mib&deviations=vendor-interfaces-dev ")
+	if err != nil {
+		t.Fatalf("failed to parse capability: %v", err)
+	}
+	want := netconf.Capability{
+		URI:        "urn:ietf:params:xml:ns:yang:ietf-interfaces",
+		Module:     "ietf-interfaces",
+		Revision:   "2018-02-20",
+		Features:   []string{"arbitrary-names", "if-mib", "pre-provisioning"},
+		Deviations: []string{"vendor-interfaces-dev"},
+	}
+	if !reflect.DeepEqual(capability, want) {
+		t.Errorf("got capability %+v, wanted %+v", capability, want)
+	}
+	if !capability.HasFeature("if-mib") || capability.HasFeature("ethernet") {
+		t.Errorf("got features %v, wanted if-mib and not ethernet", capability.Features)
+	}
+
+	reordered, err := netconf.ParseCapability("urn:ietf:params:xml:ns:yang:ietf-interfaces?revision=2018-02-20&module=ietf-interfaces" +
+		"&deviations=vendor-interfaces-dev&features=if-mib,arbitrary-names,pre-provisioning")
+	if err != nil || !capability.Equal(reordered) {
+		t.Errorf("got capability %+v and error %v, wanted it equal to %+v", reordered, err, capability)
+	}
+	if older, _ := netconf.ParseCapability("urn:ietf:params:xml:ns:yang:ietf-interfaces?module=ietf-interfaces&revision=2014-05-08"); capability.Equal(older) {
+		t.Errorf("expected capabilities with different revisions to differ")
+	}
+	if got := capability.String(); got != "urn:ietf:params:xml:ns:yang:ietf-interfaces?deviations=vendor-interfaces-dev"+
+		"&features=arbitrary-names,if-mib,pre-provisioning&module=ietf-interfaces&revision=2018-02-20" {
+		t.Errorf("got capability string %s", got)
+	}
+
+	url, err := netconf.ParseCapability("urn:ietf:params:netconf:capability:url:1.0?scheme=file,https")
+	if err != nil || url.Name() != "urn:ietf:params:netconf:capability:url" || url.Parameters["scheme"] != "file,https" {
+		t.Errorf("got capability %+v and error %v, wanted url with file and https schemes", url, err)
+	}
+
+	for _, invalid := range []string{"", "?module=foo", "urn:example?module=%zz"} {
+		if _, err := netconf.ParseCapability(invalid); err == nil {
+			t.Errorf("expected capability %q to be invalid", invalid)
+		}
+	}
+}
+
+func TestSessionModuleCapability(t *testing.T) {
+	hello := `<hello xmlns="urn:ietf:params:xml:ns:netconf:base:1.0"><capabilities>` +
+		`<capability>urn:ietf:params:netconf:base:1.0</capability>` +
+		`<capability>urn:ietf:params:xml:ns:yang:ietf-netconf-monitoring?module=ietf-netconf-monitoring&amp;revision=2010-10-04</capability>` +
+		`</capabilities><session-id>1</session-id></hello>`
+	session := newMockSession(t, mock.NewTransport(mock.WithHello(hello)))
+	defer session.Close()
+
+	if got := len(session.ServerCapabilities()); got != 2 {
+		t.Errorf("got %d capabilities, wanted 2", got)
+	}
+	if capability, ok := session.ModuleCapability("ietf-netconf-monitoring"); !ok || capability.Revision != "2010-10-04" {
+		t.Errorf("got capability %+v, wanted ietf-netconf-monitoring revision 2010-10-04", capability)
+	}
+	if _, ok := session.ModuleCapability("ietf-interfaces"); ok {
+		t.Errorf("expected ietf-interfaces not to be advertised")
+	}
+}
@@ -0,0 +1,397 @@
+package tests
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/openshift-telco/go-netconf-client/netconf"
+	"github.com/openshift-telco/go-netconf-client/netconf/message"
+	"github.com/openshift-telco/go-netconf-client/netconf/mock"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestReceiveFrame(t *testing.T) {
+	address := startSSHServer(t, "127.0.0.1:0", func(channel ssh.Channel) {
+		_, _ = channel.Write([]byte(serverHello + "<notification><eventTime>now</eventTime></notification>]]>]]>" + serverHello))
+		_, _ = io.Copy(io.Discard, channel)
+	})
+
+	transport, err := netconf.DialSSH(address, sshClientConfig())
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	defer transport.Close()
+
+	frame, err := netconf.ReceiveFrame(transport)
+	if err != nil {
+		t.Fatalf("failed to receive frame: %v", err)
+	}
+	hello := new(message.Hello)
+	if err := xml.NewDecoder(frame).Decode(hello); err != nil {
+		t.Fatalf("failed to decode hello: %v", err)
+	}
+	if hello.SessionID != 7 {
+		t.Errorf("got session-id %d, wanted 7", hello.SessionID)
+	}
+
+	// only read the first token, the rest of the message is skipped by the next call
+	frame, err = netconf.ReceiveFrame(transport)
+	if err != nil {
+		t.Fatalf("failed to receive frame: %v", err)
+	}
+	token, err := xml.NewDecoder(frame).Token()
+	if start, ok := token.(xml.StartElement); err != nil || !ok || start.Name.Local != "notification" {
+		t.Fatalf("got token %v, %v, wanted notification start element", token, err)
+	}
+
+	raw, err := transport.Receive()
+	if err != nil {
+		t.Fatalf("failed to receive: %v", err)
+	}
+	if string(raw)+"]]>]]>" != serverHello {
+		t.Errorf("got %q, wanted the server hello", raw)
+	}
+}
+
+// mismatchedFramingHandler returns a server handler advertising the capabilities in its hello, then answering
+// every RPC with frame, whatever the framing of the request.
+func mismatchedFramingHandler(capabilities string, frame func(reply string) string) func(channel ssh.Channel) {
+	return func(channel ssh.Channel) {
+		hello := strings.Replace(serverHello, "<capability>urn:ietf:params:netconf:base:1.0</capability>", capabilities, 1)
+		_, _ = channel.Write([]byte(hello))
+
+		var received []byte
+		answered := make(map[string]bool)
+		buf := make([]byte, 4096)
+		for {
+			n, err := channel.Read(buf)
+			if err != nil {
+				return
+			}
+			received = append(received, buf[:n]...)
+			for _, match := range messageIDRegex.FindAllSubmatch(received, -1) {
+				messageID := string(match[1])
+				if answered[messageID] {
+					continue
+				}
+				answered[messageID] = true
+				reply := fmt.Sprintf("<rpc-reply xmlns=\"urn:ietf:params:xml:ns:netconf:base:1.0\" message-id=\"%s\"><ok/></rpc-reply>", messageID)
+				if _, err := channel.Write([]byte(frame(reply))); err != nil {
+					return
+				}
+			}
+		}
+	}
+}
+
+func TestFramingAutoDetect(t *testing.T) {
+	tests := []struct {
+		name         string
+		capabilities string
+		frame        func(reply string) string
+		want         string
+	}{
+		{
+			name:         "1.1 negotiated, 1.0 framing",
+			capabilities: "<capability>urn:ietf:params:netconf:base:1.0</capability><capability>urn:ietf:params:netconf:base:1.1</capability>",
+			frame:        func(reply string) string { return reply + "]]>]]>" },
+			want:         "v1.0",
+		},
+		{
+			name:         "1.0 negotiated, 1.1 framing",
+			capabilities: "<capability>urn:ietf:params:netconf:base:1.0</capability>",
+			frame:        func(reply string) string { return fmt.Sprintf("\n#%d\n%s\n##\n", len(reply), reply) },
+			want:         "v1.1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			address := startSSHServer(t, "127.0.0.1:0", mismatchedFramingHandler(tt.capabilities, tt.frame))
+
+			session, err := netconf.NewSessionFromSSHConfig(address, sshClientConfig(), netconf.WithFramingAutoDetect())
+			if err != nil {
+				t.Fatalf("failed to create session: %v", err)
+			}
+			defer session.Close()
+			if err := session.SendHello(&message.Hello{Capabilities: netconf.DefaultCapabilities}); err != nil {
+				t.Fatalf("failed to send hello: %v", err)
+			}
+
+			// RPCs are sent while the first reply switches the framing
+			var wg sync.WaitGroup
+			errs := make(chan error, 20)
+			for i := 0; i < 20; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					if _, err := session.SyncRPC(message.NewGetConfig(message.DatastoreRunning, "", ""), 5); err != nil {
+						errs <- err
+					}
+				}()
+			}
+			wg.Wait()
+			close(errs)
+			for err := range errs {
+				t.Error(err)
+			}
+
+			if version := session.FramingVersion(); version != tt.want {
+				t.Errorf("got framing version %s, wanted %s", version, tt.want)
+			}
+		})
+	}
+}
+
+// rawStreamHandler writes every piece separately, pausing in between so the client receives them in distinct
+// reads, then closes the channel.
+func rawStreamHandler(pieces []string) func(channel ssh.Channel) {
+	return func(channel ssh.Channel) {
+		defer channel.Close()
+		for _, piece := range pieces {
+			if _, err := channel.Write([]byte(piece)); err != nil {
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+}
+
+// receivedFrame is the outcome of a transport Receive call.
+type receivedFrame struct {
+	data string
+	err  func(err error) bool
+}
+
+func isRecoveredFramingError(err error) bool {
+	var framingErr *netconf.FramingError
+	return errors.As(err, &framingErr) && framingErr.Recovered
+}
+
+func isUnrecoveredFramingError(err error) bool {
+	var framingErr *netconf.FramingError
+	return errors.As(err, &framingErr) && !framingErr.Recovered && errors.Is(err, netconf.ErrBadChunk)
+}
+
+func isUnexpectedEOF(err error) bool { return errors.Is(err, io.ErrUnexpectedEOF) }
+
+func isEOF(err error) bool { return err == io.EOF }
+
+func TestFrameDecoding(t *testing.T) {
+	tests := []struct {
+		name     string
+		version  string
+		recovery bool
+		pieces   []string
+		want     []receivedFrame
+	}{
+		{
+			name:    "single chunk",
+			version: "v1.1",
+			pieces:  []string{"\n#5\n<ok/>\n##\n"},
+			want:    []receivedFrame{{data: "<ok/>"}, {err: isEOF}},
+		},
+		{
+			name:    "multiple chunks",
+			version: "v1.1",
+			pieces:  []string{"\n#4\n<rpc\n#8\n-reply/>\n#1\n \n##\n\n#3\n<a/\n#1\n>\n##\n"},
+			want:    []receivedFrame{{data: "<rpc-reply/> "}, {data: "<a/>"}, {err: isEOF}},
+		},
+		{
+			name:    "chunk header split across reads",
+			version: "v1.1",
+			pieces:  []string{"\n", "#1", "2", "\n<rpc-", "reply/>", "\n#", "#", "\n"},
+			want:    []receivedFrame{{data: "<rpc-reply/>"}, {err: isEOF}},
+		},
+		{
+			name:    "end-of-message split across reads",
+			version: "v1.0",
+			pieces:  []string{"<rpc-reply/>]]", ">]", "]>", "<a>]]></a>]]>]]>"},
+			want:    []receivedFrame{{data: "<rpc-reply/>"}, {data: "<a>]]></a>"}, {err: isEOF}},
+		},
+		{
+			name:     "bad chunk header recovered",
+			version:  "v1.1",
+			recovery: true,
+			pieces:   []string{"\n#x\n<dropped/>\n##\n", "\n#5\n<ok/>\n##\n"},
+			want:     []receivedFrame{{err: isRecoveredFramingError}, {data: "<ok/>"}, {err: isEOF}},
+		},
+		{
+			name:     "chunk size overflow recovered",
+			version:  "v1.1",
+			recovery: true,
+			pieces:   []string{"\n#12345678901\n<dropped/>\n##\n\n#5\n<ok/>\n##\n"},
+			want:     []receivedFrame{{err: isRecoveredFramingError}, {data: "<ok/>"}, {err: isEOF}},
+		},
+		{
+			name:    "bad chunk header without recovery",
+			version: "v1.1",
+			pieces:  []string{"\n#x\n<dropped/>\n##\n", "\n#5\n<ok/>\n##\n"},
+			want:    []receivedFrame{{err: isUnrecoveredFramingError}, {err: isUnrecoveredFramingError}},
+		},
+		{
+			name:    "end-of-chunks without chunk",
+			version: "v1.1",
+			pieces:  []string{"\n##\n"},
+			want:    []receivedFrame{{err: isUnrecoveredFramingError}},
+		},
+		{
+			name:    "truncated chunk",
+			version: "v1.1",
+			pieces:  []string{"\n#12\n<rpc-"},
+			want:    []receivedFrame{{err: isUnexpectedEOF}},
+		},
+		{
+			name:    "truncated chunk header",
+			version: "v1.1",
+			pieces:  []string{"\n#5\n<ok/>\n#"},
+			want:    []receivedFrame{{err: isUnexpectedEOF}},
+		},
+		{
+			name:    "truncated end-of-message",
+			version: "v1.0",
+			pieces:  []string{"<rpc-reply/>]]>]"},
+			want:    []receivedFrame{{err: isUnexpectedEOF}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			address := startSSHServer(t, "127.0.0.1:0", rawStreamHandler(tt.pieces))
+
+			transport, err := netconf.DialSSH(address, sshClientConfig())
+			if err != nil {
+				t.Fatalf("failed to dial: %v", err)
+			}
+			defer transport.Close()
+			transport.SetVersion(tt.version)
+			transport.SetFramingRecovery(tt.recovery)
+
+			for i, want := range tt.want {
+				data, err := transport.Receive()
+				if want.err != nil {
+					if !want.err(err) {
+						t.Fatalf("receive %d: got error %v", i, err)
+					}
+					continue
+				}
+				if err != nil {
+					t.Fatalf("receive %d: failed to receive: %v", i, err)
+				}
+				if string(data) != want.data {
+					t.Errorf("receive %d: got %q, wanted %q", i, data, want.data)
+				}
+			}
+		})
+	}
+}
+
+func TestMaxMessageSize(t *testing.T) {
+	oversize := "<notification xmlns=\"urn:ietf:params:xml:ns:netconf:notification:1.0\"><eventTime>2026-01-01T00:00:00Z</eventTime><data>" +
+		strings.Repeat("x", 1000) + "</data></notification>"
+	tests := []struct {
+		name         string
+		capabilities string
+		frame        func(message string) string
+	}{
+		{
+			name:         "1.0 framing",
+			capabilities: "<capability>urn:ietf:params:netconf:base:1.0</capability>",
+			frame:        func(message string) string { return message + "]]>]]>" },
+		},
+		{
+			name:         "1.1 framing",
+			capabilities: "<capability>urn:ietf:params:netconf:base:1.1</capability>",
+			frame: func(message string) string {
+				// the oversize message spans several chunks
+				var chunks string
+				for len(message) > 300 {
+					chunks += fmt.Sprintf("\n#%d\n%s", 300, message[:300])
+					message = message[300:]
+				}
+				return chunks + fmt.Sprintf("\n#%d\n%s\n##\n", len(message), message)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// every reply is preceded by a message exceeding the maximum size
+			address := startSSHServer(t, "127.0.0.1:0", mismatchedFramingHandler(tt.capabilities, func(reply string) string {
+				return tt.frame(oversize) + tt.frame(reply)
+			}))
+
+			session, err := netconf.NewSessionFromSSHConfig(address, sshClientConfig(), netconf.WithMaxMessageSize(500))
+			if err != nil {
+				t.Fatalf("failed to create session: %v", err)
+			}
+			defer session.Close()
+			failed := make(chan error, 10)
+			session.Listener.Register(netconf.ErrorEventHandler, func(event netconf.Event) {
+				failed <- netconf.EventErr(event)
+			})
+			if err := session.SendHello(&message.Hello{Capabilities: netconf.DefaultCapabilities}); err != nil {
+				t.Fatalf("failed to send hello: %v", err)
+			}
+
+			for i := 0; i < 2; i++ {
+				reply, err := session.SyncRPC(message.NewGetConfig(message.DatastoreRunning, "", ""), 5)
+				if err != nil {
+					t.Fatalf("failed to receive the reply following the oversize message: %v", err)
+				}
+				if !reply.OK() {
+					t.Errorf("got reply %s, wanted ok", reply.RawReply)
+				}
+
+				select {
+				case err := <-failed:
+					var tooLargeErr *netconf.MessageTooLargeError
+					if !errors.As(err, &tooLargeErr) {
+						t.Fatalf("got error %v, wanted a MessageTooLargeError", err)
+					}
+					if tooLargeErr.Limit != 500 || tooLargeErr.Size != len(oversize) {
+						t.Errorf("got limit %d and size %d, wanted 500 and %d", tooLargeErr.Limit, tooLargeErr.Size, len(oversize))
+					}
+				case <-time.After(5 * time.Second):
+					t.Fatalf("no error dispatched for the oversize message")
+				}
+			}
+		})
+	}
+}
+
+func TestSessionDecodesFrames(t *testing.T) {
+	var reply string
+	transport := mock.NewTransport(mock.WithHandler(func(messageID string, request []byte) []byte {
+		reply = fmt.Sprintf("<nc:rpc-reply xmlns:nc=\"urn:ietf:params:xml:ns:netconf:base:1.0\" message-id=%q>"+
+			"<nc:data><system><hostname>r1</hostname></system></nc:data></nc:rpc-reply>", messageID)
+		return []byte(reply)
+	}))
+	session := newMockSession(t, transport)
+	defer session.Close()
+
+	got, err := session.SyncRPC(message.NewGetConfig(message.DatastoreRunning, "", ""), 5)
+	if err != nil {
+		t.Fatalf("failed to get the configuration: %v", err)
+	}
+	if got.RawReply != reply || !strings.Contains(got.Data, "<hostname>r1</hostname>") {
+		t.Errorf("got reply %q with data %q, wanted %q", got.RawReply, got.Data, reply)
+	}
+
+	notifications := make(chan *message.Notification, 1)
+	session.Listener.Register(message.NetconfNotificationStreamHandler, func(event netconf.Event) {
+		notifications <- event.Notification()
+	})
+	transport.Notify(testNotification)
+	select {
+	case notification := <-notifications:
+		if notification.RawReply != testNotification || notification.EventTime != "2021-11-01T10:00:00Z" {
+			t.Errorf("got notification %q at %q, wanted %q", notification.RawReply, notification.EventTime, testNotification)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("notification not dispatched")
+	}
+}
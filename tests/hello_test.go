@@ -0,0 +1,82 @@
+package tests
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/openshift-telco/go-netconf-client/netconf"
+	"github.com/openshift-telco/go-netconf-client/netconf/message"
+	"github.com/openshift-telco/go-netconf-client/netconf/mock"
+)
+
+func TestNewClientHello(t *testing.T) {
+	hello, err := netconf.NewClientHello(netconf.ClientCapabilities{
+		BaseVersions: []string{message.NetconfVersion11},
+		Startup:      true,
+		URLSchemes:   []string{"file", "https"},
+		Vendor:       []string{"http://example.com/netconf/extension?revision=2021-11-01"},
+	})
+	if err != nil {
+		t.Fatalf("failed to build hello: %v", err)
+	}
+	want := []string{
+		message.NetconfVersion11,
+		netconf.CapabilityStartup,
+		netconf.CapabilityURL + "?scheme=file,https",
+		"http://example.com/netconf/extension?revision=2021-11-01",
+	}
+	if !reflect.DeepEqual(hello.Capabilities, want) {
+		t.Errorf("got capabilities %v, wanted %v", hello.Capabilities, want)
+	}
+
+	hello, err = netconf.NewClientHello(netconf.ClientCapabilities{})
+	if err != nil {
+		t.Fatalf("failed to build hello: %v", err)
+	}
+	if !reflect.DeepEqual(hello.Capabilities, netconf.DefaultCapabilities) {
+		t.Errorf("got capabilities %v, wanted the default ones %v", hello.Capabilities, netconf.DefaultCapabilities)
+	}
+}
+
+func TestNewClientHelloInvalid(t *testing.T) {
+	tests := []struct {
+		name         string
+		capabilities netconf.ClientCapabilities
+		reason       string
+	}{
+		{"unknown base version", netconf.ClientCapabilities{BaseVersions: []string{"urn:ietf:params:netconf:base:2.0"}}, "unsupported base version"},
+		{"duplicated base version", netconf.ClientCapabilities{BaseVersions: []string{message.NetconfVersion10, message.NetconfVersion10}}, "duplicated"},
+		{"invalid url scheme", netconf.ClientCapabilities{URLSchemes: []string{"1ftp"}}, "invalid url scheme"},
+		{"relative vendor capability", netconf.ClientCapabilities{Vendor: []string{"extension"}}, "not an absolute URI"},
+		{"vendor base capability", netconf.ClientCapabilities{Vendor: []string{message.NetconfVersion11}}, "base version"},
+		{"duplicated vendor capability", netconf.ClientCapabilities{Startup: true, Vendor: []string{netconf.CapabilityStartup}}, "duplicated"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := netconf.NewClientHello(test.capabilities)
+			if err == nil || !strings.Contains(err.Error(), test.reason) {
+				t.Errorf("got error %v, wanted one about %s", err, test.reason)
+			}
+		})
+	}
+}
+
+func TestSendClientHello(t *testing.T) {
+	transport := mock.NewTransport()
+	session, err := netconf.NewSession(transport)
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+	defer session.Close()
+	hello, err := netconf.NewClientHello(netconf.ClientCapabilities{URLSchemes: []string{"file"}})
+	if err != nil {
+		t.Fatalf("failed to build hello: %v", err)
+	}
+	if err := session.SendHello(hello); err != nil {
+		t.Fatalf("failed to send hello: %v", err)
+	}
+	if sent := string(session.ClientHello()); !strings.Contains(sent, "<capability>"+netconf.CapabilityURL+"?scheme=file</capability>") {
+		t.Errorf("got client hello %s, wanted the url capability", sent)
+	}
+}
@@ -0,0 +1,118 @@
+package tests
+
+import (
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/openshift-telco/go-netconf-client/netconf"
+	"github.com/openshift-telco/go-netconf-client/netconf/mock"
+)
+
+func closeHookChan(session *netconf.Session) <-chan error {
+	closed := make(chan error, 2)
+	session.OnClose(func(s *netconf.Session, err error) {
+		closed <- err
+	})
+	return closed
+}
+
+func TestOnCloseByClient(t *testing.T) {
+	session := newMockSession(t, mock.NewTransport())
+	closed := closeHookChan(session)
+
+	_ = session.Close()
+	_ = session.Close()
+	select {
+	case err := <-closed:
+		if err != nil {
+			t.Errorf("got close cause %v, wanted none", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("close hook not called")
+	}
+	time.Sleep(50 * time.Millisecond)
+	if len(closed) != 0 {
+		t.Errorf("expected the close hook to be called once")
+	}
+}
+
+func TestOnCloseByServerAndReconnect(t *testing.T) {
+	var current *mock.Transport
+	redial := func() (netconf.Transport, error) {
+		current = mock.NewTransport()
+		return current, nil
+	}
+	first, _ := redial()
+	session := newMockSession(t, first.(*mock.Transport), netconf.WithRedial(redial))
+	defer session.Close()
+	closed := closeHookChan(session)
+
+	_ = current.Close()
+	select {
+	case err := <-closed:
+		if !errors.Is(err, io.EOF) {
+			t.Errorf("got close cause %v, wanted %v", err, io.EOF)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("close hook not called once the server hung up")
+	}
+
+	if err := session.Reconnect(nil); err != nil {
+		t.Fatalf("failed to reconnect: %v", err)
+	}
+	_ = current.Close()
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("close hook not kept upon reconnect")
+	}
+}
+
+func TestOnError(t *testing.T) {
+	failures := make(chan error, 1)
+	session := newMockSession(t, mock.NewTransport(mock.WithHandler(mock.NoReply)),
+		netconf.WithKeepalive(20*time.Millisecond, 1),
+		netconf.WithErrorHook(func(s *netconf.Session, err error) {
+			failures <- err
+		}))
+	closed := closeHookChan(session)
+
+	var keepaliveErr *netconf.KeepaliveError
+	select {
+	case err := <-failures:
+		if !errors.As(err, &keepaliveErr) {
+			t.Errorf("got error %v, wanted a KeepaliveError", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("error hook not called")
+	}
+	select {
+	case err := <-closed:
+		if !errors.As(err, &keepaliveErr) {
+			t.Errorf("got close cause %v, wanted a KeepaliveError", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("close hook not called once the session failed")
+	}
+}
+
+func TestErrorHookOnCreation(t *testing.T) {
+	failures := make(chan error, 1)
+	_, err := netconf.NewSession(mock.NewTransport(mock.WithHello("<hello/>")),
+		netconf.WithErrorHook(func(s *netconf.Session, err error) {
+			failures <- err
+		}))
+	if err == nil {
+		t.Fatalf("expected the session creation to fail")
+	}
+	select {
+	case hookErr := <-failures:
+		if hookErr != err && !errors.Is(err, hookErr) {
+			t.Errorf("got hook error %v, wanted %v", hookErr, err)
+		}
+	default:
+		t.Errorf("error hook not called upon the failed creation")
+	}
+}
@@ -0,0 +1,66 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/openshift-telco/go-netconf-client/netconf"
+	"github.com/openshift-telco/go-netconf-client/netconf/message"
+	"github.com/openshift-telco/go-netconf-client/netconf/mock"
+)
+
+func TestLastActivity(t *testing.T) {
+	before := time.Now()
+	session := newMockSession(t, mock.NewTransport())
+	defer session.Close()
+
+	sent, received := session.LastSent(), session.LastReceived()
+	if sent.Before(before) || received.Before(before) {
+		t.Fatalf("expected the hello exchange to be recorded, got sent at %v and received at %v", sent, received)
+	}
+
+	if _, err := session.SyncRPC(message.NewGetConfig(message.DatastoreRunning, "", ""), 5); err != nil {
+		t.Fatalf("failed to execute rpc: %v", err)
+	}
+	if !session.LastSent().After(sent) || !session.LastReceived().After(received) {
+		t.Errorf("expected the RPC and its reply to be recorded")
+	}
+	if idle := session.IdleSince(); !idle.Equal(session.LastReceived()) {
+		t.Errorf("got idle since %v, wanted the reply time %v", idle, session.LastReceived())
+	}
+}
+
+func TestOnIdle(t *testing.T) {
+	idle := make(chan time.Duration, 10)
+	session := newMockSession(t, mock.NewTransport(),
+		netconf.WithIdlePeriod(50*time.Millisecond),
+		netconf.WithIdleHook(func(session *netconf.Session, duration time.Duration) {
+			idle <- duration
+		}),
+	)
+	defer session.Close()
+
+	select {
+	case duration := <-idle:
+		if duration < 50*time.Millisecond {
+			t.Errorf("got hook called after %s, wanted at least the idle period", duration)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("idle hook not called")
+	}
+	// called once per idle stretch
+	select {
+	case <-idle:
+		t.Fatalf("idle hook called again without activity")
+	case <-time.After(150 * time.Millisecond):
+	}
+
+	if _, err := session.SyncRPC(message.NewGetConfig(message.DatastoreRunning, "", ""), 5); err != nil {
+		t.Fatalf("failed to execute rpc: %v", err)
+	}
+	select {
+	case <-idle:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("idle hook not called after the RPC")
+	}
+}
@@ -0,0 +1,95 @@
+package tests
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/openshift-telco/go-netconf-client/netconf"
+	"github.com/openshift-telco/go-netconf-client/netconf/message"
+	"github.com/openshift-telco/go-netconf-client/netconf/mock"
+)
+
+func TestMaxInFlightWait(t *testing.T) {
+	session := newMockSession(t, mock.NewTransport(mock.WithProfile(mock.Profile{Latency: 20 * time.Millisecond})),
+		netconf.WithMaxInFlight(2, netconf.InFlightWait))
+	defer session.Close()
+
+	done := make(chan struct{})
+	exceeded := make(chan int, 1)
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			if n := session.InFlight(); n > 2 {
+				select {
+				case exceeded <- n:
+				default:
+				}
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}()
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := session.SyncRPC(message.NewGetConfig(message.DatastoreRunning, "", ""), 5); err != nil {
+				t.Errorf("failed to execute rpc: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	close(done)
+
+	select {
+	case n := <-exceeded:
+		t.Errorf("got %d outstanding RPCs, wanted at most 2", n)
+	default:
+	}
+	if elapsed := time.Since(start); elapsed < 5*20*time.Millisecond {
+		t.Errorf("got 10 RPCs replied in %v, wanted them sent two at a time", elapsed)
+	}
+	if n := session.InFlight(); n != 0 {
+		t.Errorf("got %d outstanding RPCs once replied, wanted none", n)
+	}
+}
+
+func TestMaxInFlightFail(t *testing.T) {
+	session := newMockSession(t, mock.NewTransport(mock.WithHandler(mock.NoReply)),
+		netconf.WithMaxInFlight(1, netconf.InFlightFail), netconf.WithRPCTimeout(100*time.Millisecond))
+	defer session.Close()
+
+	expired := make(chan netconf.Event, 1)
+	err := session.AsyncRPC(message.NewGetConfig(message.DatastoreRunning, "", ""), func(event netconf.Event) {
+		expired <- event
+	})
+	if err != nil {
+		t.Fatalf("failed to execute rpc: %v", err)
+	}
+	if _, err := session.SyncRPC(message.NewGetConfig(message.DatastoreRunning, "", ""), 0); !errors.Is(err, netconf.ErrTooManyInFlight) {
+		t.Errorf("got error %v, wanted %v", err, netconf.ErrTooManyInFlight)
+	}
+
+	// the timed out RPC releases its slot
+	<-expired
+	if _, err := session.SyncRPC(message.NewGetConfig(message.DatastoreRunning, "", ""), 0); errors.Is(err, netconf.ErrTooManyInFlight) {
+		t.Errorf("expected the slot of the timed out RPC to be released")
+	}
+}
+
+func TestWithMaxInFlightInvalid(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected a non-positive limit to panic")
+		}
+	}()
+	netconf.WithMaxInFlight(0, netconf.InFlightWait)
+}
@@ -0,0 +1,86 @@
+package tests
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/openshift-telco/go-netconf-client/netconf"
+	"github.com/openshift-telco/go-netconf-client/netconf/message"
+	"github.com/openshift-telco/go-netconf-client/netconf/mock"
+)
+
+const interleaveHello = `<hello xmlns="urn:ietf:params:xml:ns:netconf:base:1.0"><capabilities>` +
+	`<capability>urn:ietf:params:netconf:base:1.1</capability>` +
+	`<capability>urn:ietf:params:netconf:capability:interleave:1.0</capability>` +
+	`</capabilities><session-id>1</session-id></hello>`
+
+func subscribe(t *testing.T, session *netconf.Session) {
+	t.Helper()
+	reply, err := session.SyncRPC(message.NewCreateSubscription("", "", ""), 5)
+	if err != nil || len(reply.Errors) != 0 {
+		t.Fatalf("failed to subscribe: %v", err)
+	}
+}
+
+func TestInterleaveReject(t *testing.T) {
+	session := newMockSession(t, mock.NewTransport(), netconf.WithInterleavePolicy(netconf.InterleaveReject))
+	if session.SupportsInterleave() {
+		t.Errorf("expected the server not to support interleave")
+	}
+	if _, err := session.SyncRPC(message.NewGetConfig(message.DatastoreRunning, "", ""), 5); err != nil {
+		t.Errorf("failed to execute rpc before subscribing: %v", err)
+	}
+
+	subscribe(t, session)
+	if _, err := session.SyncRPC(message.NewGetConfig(message.DatastoreRunning, "", ""), 5); !errors.Is(err, netconf.ErrNoInterleave) {
+		t.Errorf("got error %v, wanted %v", err, netconf.ErrNoInterleave)
+	}
+	if err := session.CloseGracefully(5); err != nil {
+		t.Errorf("expected close-session to be sent during the subscription: %v", err)
+	}
+}
+
+func TestInterleaveQueue(t *testing.T) {
+	transport := mock.NewTransport()
+	session := newMockSession(t, transport, netconf.WithInterleavePolicy(netconf.InterleaveQueue))
+	defer session.Close()
+	subscribe(t, session)
+
+	replies := make(chan error, 1)
+	go func() {
+		_, err := session.SyncRPC(message.NewGetConfig(message.DatastoreRunning, "", ""), 5)
+		replies <- err
+	}()
+	select {
+	case err := <-replies:
+		t.Fatalf("expected the rpc to wait for the subscription to complete, got %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	transport.Notify("<notification xmlns=\"urn:ietf:params:xml:ns:netconf:notification:1.0\">" +
+		"<eventTime>2021-11-01T10:00:00Z</eventTime>" +
+		"<ncn:notificationComplete xmlns:ncn=\"urn:ietf:params:xml:ns:netmod:notification\"/></notification>")
+	select {
+	case err := <-replies:
+		if err != nil {
+			t.Errorf("failed to execute rpc once the subscription completed: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("queued rpc not sent once the subscription completed")
+	}
+}
+
+func TestInterleaveSupported(t *testing.T) {
+	session := newMockSession(t, mock.NewTransport(mock.WithHello(interleaveHello)),
+		netconf.WithInterleavePolicy(netconf.InterleaveReject))
+	defer session.Close()
+	if !session.SupportsInterleave() {
+		t.Errorf("expected the server to support interleave")
+	}
+
+	subscribe(t, session)
+	if _, err := session.SyncRPC(message.NewGetConfig(message.DatastoreRunning, "", ""), 5); err != nil {
+		t.Errorf("failed to execute rpc during the subscription: %v", err)
+	}
+}
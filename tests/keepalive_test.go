@@ -0,0 +1,65 @@
+package tests
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openshift-telco/go-netconf-client/netconf"
+	"github.com/openshift-telco/go-netconf-client/netconf/message"
+)
+
+func TestKeepaliveClosesUnresponsiveSession(t *testing.T) {
+	address := startSSHServer(t, "127.0.0.1:0", helloHandler)
+
+	session, err := netconf.NewSessionFromSSHConfig(address, sshClientConfig(), netconf.WithKeepalive(50*time.Millisecond, 2))
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+	defer session.Close()
+
+	failed := make(chan error, 1)
+	session.Listener.Register(netconf.ErrorEventHandler, func(event netconf.Event) {
+		failed <- netconf.EventErr(event)
+	})
+	if err := session.SendHello(&message.Hello{}); err != nil {
+		t.Fatalf("failed to send hello: %v", err)
+	}
+
+	select {
+	case err := <-failed:
+		var keepaliveErr *netconf.KeepaliveError
+		if !errors.As(err, &keepaliveErr) || keepaliveErr.Missed != 2 {
+			t.Errorf("got %v, wanted a KeepaliveError after 2 missed replies", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("session not failed by the keepalive")
+	}
+}
+
+func TestKeepaliveResponsiveSession(t *testing.T) {
+	transport := newEchoTransport()
+	session, err := netconf.NewSession(transport, netconf.WithKeepalive(20*time.Millisecond, 1))
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+	defer session.Close()
+	if err := session.SendHello(&message.Hello{}); err != nil {
+		t.Fatalf("failed to send hello: %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		select {
+		case request := <-transport.sent:
+			if !strings.Contains(request, "<get><filter type=\"subtree\"></filter></get>") {
+				t.Errorf("got request %s, wanted a keepalive get", request)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no keepalive sent")
+		}
+	}
+	if session.Closed() {
+		t.Errorf("expected the responsive session to stay open")
+	}
+}
@@ -0,0 +1,79 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/openshift-telco/go-netconf-client/netconf"
+)
+
+func TestAdaptiveLimiter(t *testing.T) {
+	limiter := netconf.NewAdaptiveLimiter(1, 8)
+	if limiter.Limit() != 8 {
+		t.Fatalf("got initial limit %d, wanted 8", limiter.Limit())
+	}
+
+	// failures halve the limit down to the minimum
+	for _, expected := range []int{4, 2, 1, 1} {
+		_ = limiter.Do(context.Background(), func() error { return errors.New("timeout") })
+		if limiter.Limit() != expected {
+			t.Errorf("got limit %d, wanted %d", limiter.Limit(), expected)
+		}
+	}
+
+	// successes grow it back additively
+	for i := 0; i < 3; i++ {
+		_ = limiter.Do(context.Background(), func() error { return nil })
+	}
+	if limiter.Limit() != 2 {
+		t.Errorf("got limit %d, wanted 2", limiter.Limit())
+	}
+}
+
+func TestAdaptiveLimiterBlocksAtLimit(t *testing.T) {
+	limiter := netconf.NewAdaptiveLimiter(1, 1)
+	if err := limiter.Acquire(context.Background()); err != nil {
+		t.Fatalf("failed to acquire: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := limiter.Acquire(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v, wanted the acquisition to time out", err)
+	}
+
+	acquired := make(chan error)
+	go func() { acquired <- limiter.Acquire(context.Background()) }()
+	limiter.Release(nil)
+	if err := <-acquired; err != nil {
+		t.Errorf("failed to acquire after release: %v", err)
+	}
+	if limiter.InFlight() != 1 {
+		t.Errorf("got %d operations in flight, wanted 1", limiter.InFlight())
+	}
+}
+
+func TestAdaptiveLimiterHalvesOncePerWindow(t *testing.T) {
+	limiter := netconf.NewAdaptiveLimiter(1, 8)
+	for i := 0; i < 8; i++ {
+		if err := limiter.Acquire(context.Background()); err != nil {
+			t.Fatalf("failed to acquire: %v", err)
+		}
+	}
+
+	// the operations started under the same limit all time out
+	for i := 0; i < 8; i++ {
+		limiter.Release(errors.New("timeout"))
+	}
+	if limiter.Limit() != 4 {
+		t.Errorf("got limit %d, wanted 4", limiter.Limit())
+	}
+
+	// an operation started under the new limit halves it again
+	_ = limiter.Do(context.Background(), func() error { return errors.New("timeout") })
+	if limiter.Limit() != 2 {
+		t.Errorf("got limit %d, wanted 2", limiter.Limit())
+	}
+}
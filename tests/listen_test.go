@@ -0,0 +1,86 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/openshift-telco/go-netconf-client/netconf"
+	"github.com/openshift-telco/go-netconf-client/netconf/message"
+	"github.com/openshift-telco/go-netconf-client/netconf/mock"
+)
+
+func TestManualListen(t *testing.T) {
+	session, err := netconf.NewSession(mock.NewTransport(), netconf.WithManualListen())
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+	defer session.Close()
+	if err := session.Start(context.Background()); !errors.Is(err, netconf.ErrHelloNotExchanged) {
+		t.Errorf("got error %v, wanted %v", err, netconf.ErrHelloNotExchanged)
+	}
+	if err := session.SendHello(&message.Hello{Capabilities: netconf.DefaultCapabilities}); err != nil {
+		t.Fatalf("failed to send hello: %v", err)
+	}
+
+	replies := make(chan error, 1)
+	go func() {
+		_, err := session.SyncRPC(message.NewGetConfig(message.DatastoreRunning, "", ""), 5)
+		replies <- err
+	}()
+	select {
+	case <-replies:
+		t.Fatalf("expected no reply before the session is started")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := session.Start(ctx); err != nil {
+		t.Fatalf("failed to start session: %v", err)
+	}
+	if err := session.Start(ctx); !errors.Is(err, netconf.ErrListening) {
+		t.Errorf("got error %v, wanted %v", err, netconf.ErrListening)
+	}
+	select {
+	case err := <-replies:
+		if err != nil {
+			t.Errorf("failed to execute rpc: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("no reply once the session is started")
+	}
+
+	// the session is closed along with its context
+	cancel()
+	select {
+	case <-session.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatalf("receive goroutine not stopped once the context is done")
+	}
+	if !session.Closed() || session.State() != netconf.StateClosed {
+		t.Errorf("expected the session to be closed, got state %s", session.State())
+	}
+}
+
+func TestStop(t *testing.T) {
+	session := newMockSession(t, mock.NewTransport())
+
+	closed := make(chan struct{})
+	session.OnClose(func(*netconf.Session, error) {
+		close(closed)
+	})
+	if err := session.Stop(context.Background()); err != nil {
+		t.Fatalf("failed to stop session: %v", err)
+	}
+	select {
+	case <-session.Done():
+	default:
+		t.Errorf("expected the receive goroutine to be done once stopped")
+	}
+	select {
+	case <-closed:
+	default:
+		t.Errorf("expected the close hooks to have returned once stopped")
+	}
+}
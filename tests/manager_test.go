@@ -0,0 +1,313 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/openshift-telco/go-netconf-client/netconf"
+	"github.com/openshift-telco/go-netconf-client/netconf/message"
+	"github.com/openshift-telco/go-netconf-client/netconf/mock"
+	"golang.org/x/crypto/ssh"
+)
+
+// mockConnector connects the devices to mock servers, failing the devices named `unreachable`.
+type mockConnector struct {
+	connects atomic.Int32
+	lock     sync.Mutex
+	servers  map[string]*mock.Transport
+	redial   bool
+}
+
+func (c *mockConnector) connect(ctx context.Context, device netconf.Device) (*netconf.Session, error) {
+	if device.Name == "unreachable" {
+		return nil, errors.New("connection refused")
+	}
+	c.connects.Add(1)
+	dial := func() (netconf.Transport, error) {
+		transport := mock.NewTransport()
+		c.lock.Lock()
+		c.servers[device.Name] = transport
+		c.lock.Unlock()
+		return transport, nil
+	}
+	transport, _ := dial()
+	var options []netconf.SessionOption
+	if c.redial {
+		options = append(options, netconf.WithRedial(dial))
+	}
+	session, err := netconf.NewSession(transport, options...)
+	if err != nil {
+		return nil, err
+	}
+	return session, session.SendHello(&message.Hello{Capabilities: netconf.DefaultCapabilities})
+}
+
+func (c *mockConnector) server(name string) *mock.Transport {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.servers[name]
+}
+
+func TestSessionManager(t *testing.T) {
+	connector := &mockConnector{servers: map[string]*mock.Transport{}}
+	manager := netconf.NewSessionManager(connector.connect)
+	defer manager.Close()
+
+	for i := 0; i < 3; i++ {
+		if err := manager.Add(netconf.Device{Name: fmt.Sprintf("device%d", i)}); err != nil {
+			t.Fatalf("failed to add device: %v", err)
+		}
+	}
+	if err := manager.Add(netconf.Device{Name: "device0"}); !errors.Is(err, netconf.ErrDeviceExists) {
+		t.Errorf("got error %v, wanted %v", err, netconf.ErrDeviceExists)
+	}
+	if connector.connects.Load() != 0 {
+		t.Errorf("expected the devices to be connected on first use")
+	}
+
+	// concurrent uses share the same session
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := manager.Run(context.Background(), "device0", func(s *netconf.Session) error {
+				_, err := s.SyncRPC(message.NewGetConfig(message.DatastoreRunning, "", ""), 5)
+				return err
+			})
+			if err != nil {
+				t.Errorf("failed to run operation: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := connector.connects.Load(); n != 1 {
+		t.Errorf("got %d connections, wanted 1", n)
+	}
+
+	// a closed session is connected anew
+	session, _ := manager.Session(context.Background(), "device0")
+	_ = session.Close()
+	reconnected, err := manager.Session(context.Background(), "device0")
+	if err != nil {
+		t.Fatalf("failed to get session: %v", err)
+	}
+	if reconnected == session || reconnected.Closed() {
+		t.Errorf("expected a new session once the previous one closed")
+	}
+
+	if err := manager.Run(context.Background(), "unknown", func(*netconf.Session) error { return nil }); !errors.Is(err, netconf.ErrUnknownDevice) {
+		t.Errorf("got error %v, wanted %v", err, netconf.ErrUnknownDevice)
+	}
+
+	if err := manager.Remove("device0"); err != nil {
+		t.Errorf("failed to remove device: %v", err)
+	}
+	if !reconnected.Closed() {
+		t.Errorf("expected the session of a removed device to be closed")
+	}
+	if names := manager.Devices(); len(names) != 2 || names[0] != "device1" || names[1] != "device2" {
+		t.Errorf("got devices %v, wanted [device1 device2]", names)
+	}
+
+	if err := manager.Close(); err != nil {
+		t.Errorf("failed to close manager: %v", err)
+	}
+	if _, err := manager.Session(context.Background(), "device1"); !errors.Is(err, netconf.ErrManagerClosed) {
+		t.Errorf("got error %v, wanted %v", err, netconf.ErrManagerClosed)
+	}
+}
+
+func TestSessionManagerReconnect(t *testing.T) {
+	connector := &mockConnector{servers: map[string]*mock.Transport{}, redial: true}
+	reinits := make(chan string, 1)
+	manager := netconf.NewSessionManager(connector.connect, netconf.WithManagerReinit(func(name string, s *netconf.Session) error {
+		reinits <- name
+		return nil
+	}))
+	defer manager.Close()
+	_ = manager.Add(netconf.Device{Name: "device"})
+
+	session, err := manager.Session(context.Background(), "device")
+	if err != nil {
+		t.Fatalf("failed to get session: %v", err)
+	}
+	// the server hangs up
+	_ = connector.server("device").Close()
+	for deadline := time.Now().Add(5 * time.Second); !session.Closed(); time.Sleep(10 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("session not closed once the server hung up")
+		}
+	}
+
+	reconnected, err := manager.Session(context.Background(), "device")
+	if err != nil {
+		t.Fatalf("failed to get session: %v", err)
+	}
+	if reconnected != session || reconnected.State() != netconf.StateEstablished {
+		t.Errorf("expected the session to be reconnected")
+	}
+	select {
+	case name := <-reinits:
+		if name != "device" {
+			t.Errorf("got reinit of %s, wanted device", name)
+		}
+	default:
+		t.Errorf("expected the reconnected session to be reinitialized")
+	}
+	if n := connector.connects.Load(); n != 1 {
+		t.Errorf("got %d connections, wanted 1", n)
+	}
+}
+
+func TestSessionManagerReconnectCancelled(t *testing.T) {
+	// the server is unreachable once the session dropped, the redial hanging
+	release := make(chan struct{})
+	defer close(release)
+	transport := mock.NewTransport()
+	connect := func(ctx context.Context, device netconf.Device) (*netconf.Session, error) {
+		session, err := netconf.NewSession(transport, netconf.WithRedial(func() (netconf.Transport, error) {
+			<-release
+			return nil, errors.New("connection refused")
+		}))
+		if err != nil {
+			return nil, err
+		}
+		return session, session.SendHello(&message.Hello{Capabilities: netconf.DefaultCapabilities})
+	}
+	manager := netconf.NewSessionManager(connect)
+	defer manager.Close()
+	_ = manager.Add(netconf.Device{Name: "device"})
+
+	session, err := manager.Session(context.Background(), "device")
+	if err != nil {
+		t.Fatalf("failed to get session: %v", err)
+	}
+	_ = session.Close()
+
+	// the waiters are not held by the reconnection past their context
+	for i := 0; i < 2; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		start := time.Now()
+		_, err := manager.Session(ctx, "device")
+		cancel()
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("got error %v, wanted the context deadline", err)
+		}
+		if elapsed := time.Since(start); elapsed > 5*time.Second {
+			t.Errorf("got session after %v, wanted the reconnection cancelled with the context", elapsed)
+		}
+	}
+}
+
+func TestSessionManagerRunAll(t *testing.T) {
+	connector := &mockConnector{servers: map[string]*mock.Transport{}}
+	manager := netconf.NewSessionManager(connector.connect, netconf.WithManagerLimiter(netconf.NewAdaptiveLimiter(1, 2)))
+	defer manager.Close()
+	for _, name := range []string{"device0", "device1", "unreachable"} {
+		_ = manager.Add(netconf.Device{Name: name})
+	}
+
+	var ran atomic.Int32
+	errs := manager.RunAll(context.Background(), func(name string, s *netconf.Session) error {
+		ran.Add(1)
+		_, err := s.SyncRPC(message.NewGetConfig(message.DatastoreRunning, "", ""), 5)
+		return err
+	})
+	if ran.Load() != 2 {
+		t.Errorf("got operation run on %d devices, wanted 2", ran.Load())
+	}
+	if len(errs) != 1 || errs["unreachable"] == nil {
+		t.Errorf("got errors %v, wanted one for the unreachable device", errs)
+	}
+}
+
+func TestSessionManagerFailover(t *testing.T) {
+	var primaryDown atomic.Bool
+	var servers sync.Map
+	connect := func(ctx context.Context, device netconf.Device) (*netconf.Session, error) {
+		if device.Address == "primary:830" && primaryDown.Load() {
+			return nil, errors.New("connection refused")
+		}
+		transport := mock.NewTransport()
+		servers.Store(device.Address, transport)
+		session, err := netconf.NewSession(transport)
+		if err != nil {
+			return nil, err
+		}
+		return session, session.SendHello(&message.Hello{Capabilities: netconf.DefaultCapabilities})
+	}
+	failovers := make(chan netconf.FailoverEvent, 2)
+	manager := netconf.NewSessionManager(connect, netconf.WithManagerFailover(func(event netconf.FailoverEvent) {
+		failovers <- event
+	}))
+	defer manager.Close()
+	_ = manager.Add(netconf.Device{Name: "device", Address: "primary:830", SecondaryAddress: "secondary:830"})
+
+	session, err := manager.Session(context.Background(), "device")
+	if err != nil {
+		t.Fatalf("failed to get session: %v", err)
+	}
+	if len(failovers) != 0 {
+		t.Errorf("unexpected failover while the primary address is reachable")
+	}
+
+	// the primary path dies
+	primaryDown.Store(true)
+	transport, _ := servers.Load("primary:830")
+	_ = transport.(*mock.Transport).Close()
+	for deadline := time.Now().Add(5 * time.Second); !session.Closed(); time.Sleep(10 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("session not closed once the primary path died")
+		}
+	}
+	if _, err := manager.Session(context.Background(), "device"); err != nil {
+		t.Fatalf("failed to fail over: %v", err)
+	}
+	select {
+	case event := <-failovers:
+		if event.Device != "device" || event.From != "primary:830" || event.To != "secondary:830" || event.Err == nil {
+			t.Errorf("got failover %+v, wanted from primary:830 to secondary:830", event)
+		}
+	default:
+		t.Fatalf("no failover event")
+	}
+
+	// the session stays on the secondary address while it is available
+	primaryDown.Store(false)
+	transport, _ = servers.Load("secondary:830")
+	_ = transport.(*mock.Transport).Close()
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		if s, _ := manager.Session(context.Background(), "device"); s != nil && !s.Closed() {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("session not established again")
+		}
+	}
+	if len(failovers) != 0 {
+		t.Errorf("got failover %+v, wanted the session to stay on the secondary address", <-failovers)
+	}
+}
+
+func TestSessionManagerCheckStandby(t *testing.T) {
+	address := startSSHServer(t, "127.0.0.1:0", helloHandler)
+	manager := netconf.NewSessionManager(netconf.SSHConnector(func(netconf.Device) (*ssh.ClientConfig, error) {
+		return sshClientConfig(), nil
+	}))
+	defer manager.Close()
+	_ = manager.Add(netconf.Device{Name: "standby", Address: "127.0.0.1:1", SecondaryAddress: address})
+	_ = manager.Add(netconf.Device{Name: "none", Address: address})
+
+	if err := manager.CheckStandby(context.Background(), "standby"); err != nil {
+		t.Errorf("got unreachable standby: %v", err)
+	}
+	if err := manager.CheckStandby(context.Background(), "none"); err == nil {
+		t.Errorf("expected a device without secondary address to fail the check")
+	}
+}
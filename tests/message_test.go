@@ -17,9 +17,12 @@ limitations under the License.
 package tests
 
 import (
+	"bytes"
 	"encoding/xml"
+	"reflect"
 	"regexp"
 	"testing"
+	"time"
 
 	"github.com/openshift-telco/go-netconf-client/netconf/message"
 )
@@ -92,7 +95,7 @@ func TestGetWithoutFilter(t *testing.T) {
 }
 
 func TestGetWithFilter(t *testing.T) {
-	expected := "<rpc xmlns=\"urn:ietf:params:xml:ns:netconf:base:1.0\" message-id=\"\"><filter type=\"subtree\"><top xmlns=\"http://example.com/schema/1.2/config\"><users/></top></filter></rpc>"
+	expected := "<rpc xmlns=\"urn:ietf:params:xml:ns:netconf:base:1.0\" message-id=\"\"><get><filter type=\"subtree\"><top xmlns=\"http://example.com/schema/1.2/config\"><users/></top></filter></get></rpc>"
 
 	rpc := message.NewGet(message.FilterTypeSubtree, data)
 	output, err := xml.Marshal(rpc)
@@ -105,6 +108,181 @@ func TestGetWithFilter(t *testing.T) {
 	}
 }
 
+func TestGetWithXPathFilter(t *testing.T) {
+	expected := "<rpc xmlns=\"urn:ietf:params:xml:ns:netconf:base:1.0\" message-id=\"\"><get><filter type=\"xpath\" select=\"/t:top/t:users/t:user[t:name=&#39;fred&#39;]\" xmlns:t=\"http://example.com/schema/1.2/config\"></filter></get></rpc>"
+
+	rpc := message.NewGetXPath("/t:top/t:users/t:user[t:name='fred']", map[string]string{"t": "http://example.com/schema/1.2/config"})
+	output, err := xml.Marshal(rpc)
+	if err != nil {
+		t.Errorf(err.Error())
+	}
+
+	if got, want := StripUUID(string(output)), StripUUID(expected); got != want {
+		t.Errorf("TestGetWithXPathFilter:\nGot:%s\nWant:\n%s", got, want)
+	}
+}
+
+func TestGetConfigWithXPathFilter(t *testing.T) {
+	expected := "<rpc xmlns=\"urn:ietf:params:xml:ns:netconf:base:1.0\" message-id=\"\"><get-config><source><running></running></source><filter type=\"xpath\" select=\"/top/users\"></filter></get-config></rpc>"
+
+	rpc := message.NewGetConfig(message.DatastoreRunning, message.FilterTypeXPath, "/top/users")
+	output, err := xml.Marshal(rpc)
+	if err != nil {
+		t.Errorf(err.Error())
+	}
+
+	if got, want := StripUUID(string(output)), StripUUID(expected); got != want {
+		t.Errorf("TestGetConfigWithXPathFilter:\nGot:%s\nWant:\n%s", got, want)
+	}
+
+	didPanic := panics(func() { message.NewGetXPath("", nil) })
+	if !didPanic {
+		t.Errorf("TestGetConfigWithXPathFilter: expected an empty XPath expression to panic")
+	}
+}
+
+func TestWithDefaults(t *testing.T) {
+	expected := "<rpc xmlns=\"urn:ietf:params:xml:ns:netconf:base:1.0\" message-id=\"\"><get-config><source><running></running></source><filter type=\"subtree\"><top xmlns=\"http://example.com/schema/1.2/config\"><users/></top></filter><with-defaults xmlns=\"urn:ietf:params:xml:ns:yang:ietf-netconf-with-defaults\">report-all</with-defaults></get-config></rpc>"
+
+	rpc := message.NewGetConfigWithDefaults(message.DatastoreRunning, message.FilterTypeSubtree, data, message.WithDefaultsReportAll)
+	output, err := xml.Marshal(rpc)
+	if err != nil {
+		t.Errorf(err.Error())
+	}
+	if got, want := StripUUID(string(output)), StripUUID(expected); got != want {
+		t.Errorf("TestWithDefaults:\nGot:%s\nWant:\n%s", got, want)
+	}
+
+	expected = "<rpc xmlns=\"urn:ietf:params:xml:ns:netconf:base:1.0\" message-id=\"\"><get><with-defaults xmlns=\"urn:ietf:params:xml:ns:yang:ietf-netconf-with-defaults\">trim</with-defaults></get></rpc>"
+	output, err = xml.Marshal(message.NewGetWithDefaults(message.FilterTypeSubtree, "", message.WithDefaultsTrim))
+	if err != nil {
+		t.Errorf(err.Error())
+	}
+	if got, want := StripUUID(string(output)), StripUUID(expected); got != want {
+		t.Errorf("TestWithDefaults:\nGot:%s\nWant:\n%s", got, want)
+	}
+
+	expected = "<rpc xmlns=\"urn:ietf:params:xml:ns:netconf:base:1.0\" message-id=\"\"><copy-config><target><startup></startup></target><source><running></running></source><with-defaults xmlns=\"urn:ietf:params:xml:ns:yang:ietf-netconf-with-defaults\">explicit</with-defaults></copy-config></rpc>"
+	output, err = xml.Marshal(message.NewCopyConfigWithDefaults(message.DatastoreStartup, message.DatastoreRunning, message.WithDefaultsExplicit))
+	if err != nil {
+		t.Errorf(err.Error())
+	}
+	if got, want := StripUUID(string(output)), StripUUID(expected); got != want {
+		t.Errorf("TestWithDefaults:\nGot:%s\nWant:\n%s", got, want)
+	}
+
+	didPanic := panics(func() { message.NewWithDefaults("all") })
+	if !didPanic {
+		t.Errorf("TestWithDefaults: expected an invalid mode to panic")
+	}
+}
+
+func TestDatastores(t *testing.T) {
+	expected := "<rpc xmlns=\"urn:ietf:params:xml:ns:netconf:base:1.0\" message-id=\"\"><copy-config><target><url>file:///backup.xml</url></target><source><candidate></candidate></source></copy-config></rpc>"
+
+	rpc, err := message.NewCopyConfigDatastores(message.NewURLDatastore("file:///backup.xml"), message.NewDatastore(message.DatastoreCandidate))
+	if err != nil {
+		t.Fatalf("TestDatastores: failed to build copy-config: %v", err)
+	}
+	output, err := xml.Marshal(rpc)
+	if err != nil {
+		t.Errorf(err.Error())
+	}
+	if got, want := StripUUID(string(output)), StripUUID(expected); got != want {
+		t.Errorf("TestDatastores:\nGot:%s\nWant:\n%s", got, want)
+	}
+
+	expected = "<rpc xmlns=\"urn:ietf:params:xml:ns:netconf:base:1.0\" message-id=\"\"><validate><source><url>file:///backup.xml</url></source></validate></rpc>"
+	validate, err := message.NewValidateDatastore(message.NewURLDatastore("file:///backup.xml"))
+	if err != nil {
+		t.Fatalf("TestDatastores: failed to build validate: %v", err)
+	}
+	output, err = xml.Marshal(validate)
+	if err != nil {
+		t.Errorf(err.Error())
+	}
+	if got, want := StripUUID(string(output)), StripUUID(expected); got != want {
+		t.Errorf("TestDatastores:\nGot:%s\nWant:\n%s", got, want)
+	}
+
+	if name := message.NewInlineDatastore(data).Name(); name != "config" {
+		t.Errorf("TestDatastores: got name %s, wanted config", name)
+	}
+	invalid := map[string]func() error{
+		"lock url": func() error {
+			_, err := message.NewLockDatastore(message.NewURLDatastore("file:///backup.xml"))
+			return err
+		},
+		"copy to inline": func() error {
+			_, err := message.NewCopyConfigDatastores(message.NewInlineDatastore(data), message.NewDatastore(message.DatastoreRunning))
+			return err
+		},
+		"edit url": func() error {
+			_, err := message.NewEditConfigDatastore(message.NewURLDatastore("file:///backup.xml"), message.DefaultOperationTypeMerge, data)
+			return err
+		},
+		"delete running": func() error {
+			_, err := message.NewDeleteConfigDatastore(message.NewDatastore(message.DatastoreRunning))
+			return err
+		},
+		"validate nothing": func() error {
+			_, err := message.NewValidateDatastore(nil)
+			return err
+		},
+	}
+	for name, build := range invalid {
+		if err := build(); err == nil {
+			t.Errorf("TestDatastores: expected %s to fail", name)
+		}
+	}
+	if !panics(func() { message.NewDatastore("intended") }) {
+		t.Errorf("TestDatastores: expected an unknown datastore to panic")
+	}
+}
+
+func TestRPCErrorFields(t *testing.T) {
+	raw := `<rpc-reply xmlns="urn:ietf:params:xml:ns:netconf:base:1.0" xmlns:t="http://example.com/schema/1.2/config" message-id="101">` +
+		`<rpc-error><error-type>application</error-type><error-tag>invalid-value</error-tag><error-severity>error</error-severity>` +
+		`<error-app-tag>too-many-users</error-app-tag>` +
+		`<error-path xmlns:u="http://example.com/users">/t:top/u:users/u:user[u:name="fred"]</error-path>` +
+		`<error-message xml:lang="en">MTU value 25000 is not within range 256..9192</error-message>` +
+		`<error-info><bad-element>mtu</bad-element><session-id>4</session-id><err-element>user</err-element></error-info></rpc-error>` +
+		`<rpc-error><error-type>protocol</error-type><error-tag>lock-denied</error-tag><error-severity>warning</error-severity></rpc-error>` +
+		`</rpc-reply>`
+
+	reply, err := message.NewRPCReply([]byte(raw))
+	if err != nil {
+		t.Fatalf("TestRPCErrorFields: failed to parse the reply: %v", err)
+	}
+	if len(reply.Errors) != 2 {
+		t.Fatalf("TestRPCErrorFields: got %d errors, wanted 2", len(reply.Errors))
+	}
+
+	rpcErr := reply.Errors[0]
+	if rpcErr.Type != message.ErrorTypeApplication || rpcErr.Tag != message.ErrorTagInvalidValue ||
+		rpcErr.Severity != message.ErrorSeverityError || rpcErr.AppTag != "too-many-users" {
+		t.Errorf("TestRPCErrorFields: got error %+v, wanted an application invalid-value error", rpcErr)
+	}
+	if rpcErr.Path != `/t:top/u:users/u:user[u:name="fred"]` {
+		t.Errorf("TestRPCErrorFields: got path %s", rpcErr.Path)
+	}
+	wantNamespaces := map[string]string{
+		"":  "urn:ietf:params:xml:ns:netconf:base:1.0",
+		"t": "http://example.com/schema/1.2/config",
+		"u": "http://example.com/users",
+	}
+	if !reflect.DeepEqual(rpcErr.PathNamespaces, wantNamespaces) {
+		t.Errorf("TestRPCErrorFields: got path namespaces %v, wanted %v", rpcErr.PathNamespaces, wantNamespaces)
+	}
+	if info := rpcErr.ErrorInfo; info == nil || info.BadElement != "mtu" || info.SessionID != 4 ||
+		!reflect.DeepEqual(info.ErrElements, []string{"user"}) {
+		t.Errorf("TestRPCErrorFields: got error-info %+v", info)
+	}
+	if warning := reply.Errors[1]; !warning.IsWarning() || warning.ErrorInfo != nil || warning.PathNamespaces != nil {
+		t.Errorf("TestRPCErrorFields: got error %+v, wanted a warning without error-info", warning)
+	}
+}
+
 func TestGetWithInvalidFilter(t *testing.T) {
 	didPanic := panics(
 		func() {
@@ -228,6 +406,75 @@ func TestNewValidate(t *testing.T) {
 	}
 }
 
+func TestNewValidateConfig(t *testing.T) {
+	expected := "<rpc xmlns=\"urn:ietf:params:xml:ns:netconf:base:1.0\" message-id=\"\"><validate><source><config>" + data + "</config></source></validate></rpc>"
+
+	rpc := message.NewValidateConfig(data)
+	output, err := xml.Marshal(rpc)
+	if err != nil {
+		t.Errorf(err.Error())
+	}
+
+	if got, want := StripUUID(string(output)), StripUUID(expected); got != want {
+		t.Errorf("TestNewValidateConfig:\nGot:%s\nWant:\n%s", got, want)
+	}
+}
+
+func TestNewCopyConfig(t *testing.T) {
+	expected := "<rpc xmlns=\"urn:ietf:params:xml:ns:netconf:base:1.0\" message-id=\"\"><copy-config><target><startup></startup></target><source><running></running></source></copy-config></rpc>"
+
+	rpc := message.NewCopyConfig(message.DatastoreStartup, message.DatastoreRunning)
+	output, err := xml.Marshal(rpc)
+	if err != nil {
+		t.Errorf(err.Error())
+	}
+
+	if got, want := StripUUID(string(output)), StripUUID(expected); got != want {
+		t.Errorf("TestNewCopyConfig:\nGot:%s\nWant:\n%s", got, want)
+	}
+}
+
+func TestNewCopyConfigInline(t *testing.T) {
+	expected := "<rpc xmlns=\"urn:ietf:params:xml:ns:netconf:base:1.0\" message-id=\"\"><copy-config><target><candidate></candidate></target><source><config>" + data + "</config></source></copy-config></rpc>"
+
+	rpc := message.NewCopyConfigInline(message.DatastoreCandidate, data)
+	output, err := xml.Marshal(rpc)
+	if err != nil {
+		t.Errorf(err.Error())
+	}
+
+	if got, want := StripUUID(string(output)), StripUUID(expected); got != want {
+		t.Errorf("TestNewCopyConfigInline:\nGot:%s\nWant:\n%s", got, want)
+	}
+}
+
+func TestNewDeleteConfig(t *testing.T) {
+	expected := "<rpc xmlns=\"urn:ietf:params:xml:ns:netconf:base:1.0\" message-id=\"\"><delete-config><target><startup></startup></target></delete-config></rpc>"
+
+	rpc := message.NewDeleteConfig(message.DatastoreStartup)
+	output, err := xml.Marshal(rpc)
+	if err != nil {
+		t.Errorf(err.Error())
+	}
+
+	if got, want := StripUUID(string(output)), StripUUID(expected); got != want {
+		t.Errorf("TestNewDeleteConfig:\nGot:%s\nWant:\n%s", got, want)
+	}
+}
+
+func TestNewDeleteConfigRunning(t *testing.T) {
+	didPanic := panics(
+		func() {
+			message.NewDeleteConfig(message.DatastoreRunning)
+		},
+	)
+
+	// expect to panic
+	if !didPanic {
+		t.Errorf("TestNewDeleteConfigRunning: expected the running datastore deletion to panic")
+	}
+}
+
 func TestNewCloseSession(t *testing.T) {
 	expected := "<rpc xmlns=\"urn:ietf:params:xml:ns:netconf:base:1.0\" message-id=\"\"><close-session></close-session></rpc>"
 
@@ -297,6 +544,216 @@ func TestNewCommit(t *testing.T) {
 	}
 }
 
+func TestNewDiscardChanges(t *testing.T) {
+	expected := "<rpc xmlns=\"urn:ietf:params:xml:ns:netconf:base:1.0\" message-id=\"\"><discard-changes></discard-changes></rpc>"
+
+	rpc := message.NewDiscardChanges()
+	output, err := xml.Marshal(rpc)
+	if err != nil {
+		t.Errorf(err.Error())
+	}
+
+	if got, want := StripUUID(string(output)), StripUUID(expected); got != want {
+		t.Errorf("TestNewDiscardChanges:\nGot:%s\nWant:\n%s", got, want)
+	}
+}
+
+func TestNewCancelCommit(t *testing.T) {
+	expected := "<rpc xmlns=\"urn:ietf:params:xml:ns:netconf:base:1.0\" message-id=\"\"><cancel-commit><persist-id>change-42</persist-id></cancel-commit></rpc>"
+
+	rpc := message.NewCancelCommit("change-42")
+	output, err := xml.Marshal(rpc)
+	if err != nil {
+		t.Errorf(err.Error())
+	}
+
+	if got, want := StripUUID(string(output)), StripUUID(expected); got != want {
+		t.Errorf("TestNewCancelCommit:\nGot:%s\nWant:\n%s", got, want)
+	}
+
+	expected = "<rpc xmlns=\"urn:ietf:params:xml:ns:netconf:base:1.0\" message-id=\"\"><cancel-commit></cancel-commit></rpc>"
+	output, err = xml.Marshal(message.NewCancelCommit(""))
+	if err != nil {
+		t.Errorf(err.Error())
+	}
+
+	if got, want := StripUUID(string(output)), StripUUID(expected); got != want {
+		t.Errorf("TestNewCancelCommit:\nGot:%s\nWant:\n%s", got, want)
+	}
+}
+
+func TestNewConfirmedCommit(t *testing.T) {
+	expected := "<rpc xmlns=\"urn:ietf:params:xml:ns:netconf:base:1.0\" message-id=\"\"><commit><confirmed></confirmed><confirm-timeout>120</confirm-timeout><persist>change-42</persist></commit></rpc>"
+
+	rpc := message.NewConfirmedCommit(2*time.Minute, "change-42")
+	output, err := xml.Marshal(rpc)
+	if err != nil {
+		t.Errorf(err.Error())
+	}
+
+	if got, want := StripUUID(string(output)), StripUUID(expected); got != want {
+		t.Errorf("TestNewConfirmedCommit:\nGot:%s\nWant:\n%s", got, want)
+	}
+
+	expected = "<rpc xmlns=\"urn:ietf:params:xml:ns:netconf:base:1.0\" message-id=\"\"><commit><confirmed></confirmed></commit></rpc>"
+	output, err = xml.Marshal(message.NewConfirmedCommit(0, ""))
+	if err != nil {
+		t.Errorf(err.Error())
+	}
+
+	if got, want := StripUUID(string(output)), StripUUID(expected); got != want {
+		t.Errorf("TestNewConfirmedCommit:\nGot:%s\nWant:\n%s", got, want)
+	}
+}
+
+func TestNewConfirmingCommit(t *testing.T) {
+	expected := "<rpc xmlns=\"urn:ietf:params:xml:ns:netconf:base:1.0\" message-id=\"\"><commit><persist-id>change-42</persist-id></commit></rpc>"
+
+	rpc := message.NewConfirmingCommit("change-42")
+	output, err := xml.Marshal(rpc)
+	if err != nil {
+		t.Errorf(err.Error())
+	}
+
+	if got, want := StripUUID(string(output)), StripUUID(expected); got != want {
+		t.Errorf("TestNewConfirmingCommit:\nGot:%s\nWant:\n%s", got, want)
+	}
+}
+
+func TestNewGetSchema(t *testing.T) {
+	expected := "<rpc xmlns=\"urn:ietf:params:xml:ns:netconf:base:1.0\" message-id=\"\"><get-schema xmlns=\"urn:ietf:params:xml:ns:yang:ietf-netconf-monitoring\"><identifier>ietf-interfaces</identifier><version>2018-02-20</version><format>yang</format></get-schema></rpc>"
+
+	rpc := message.NewGetSchema("ietf-interfaces", "2018-02-20", message.SchemaFormatYang)
+	output, err := xml.Marshal(rpc)
+	if err != nil {
+		t.Errorf(err.Error())
+	}
+
+	if got, want := StripUUID(string(output)), StripUUID(expected); got != want {
+		t.Errorf("TestNewGetSchema:\nGot:%s\nWant:\n%s", got, want)
+	}
+}
+
+func TestNewGetData(t *testing.T) {
+	expected := "<rpc xmlns=\"urn:ietf:params:xml:ns:netconf:base:1.0\" message-id=\"\"><get-data xmlns=\"urn:ietf:params:xml:ns:yang:ietf-netconf-nmda\" xmlns:ds=\"urn:ietf:params:xml:ns:yang:ietf-datastores\" xmlns:or=\"urn:ietf:params:xml:ns:yang:ietf-origin\">" +
+		"<datastore>ds:operational</datastore><subtree-filter>" + data + "</subtree-filter><config-filter>false</config-filter>" +
+		"<origin-filter>or:intended</origin-filter><origin-filter>or:learned</origin-filter><max-depth>3</max-depth><with-origin></with-origin>" +
+		"<with-defaults xmlns=\"urn:ietf:params:xml:ns:yang:ietf-netconf-with-defaults\">report-all</with-defaults></get-data></rpc>"
+
+	configFilter := false
+	rpc := message.NewGetData(message.DatastoreOperational, data, &configFilter, []string{"intended", "learned"}, true, 3, message.WithDefaultsReportAll)
+	output, err := xml.Marshal(rpc)
+	if err != nil {
+		t.Errorf(err.Error())
+	}
+
+	if got, want := StripUUID(string(output)), StripUUID(expected); got != want {
+		t.Errorf("TestNewGetData:\nGot:%s\nWant:\n%s", got, want)
+	}
+
+	expected = "<rpc xmlns=\"urn:ietf:params:xml:ns:netconf:base:1.0\" message-id=\"\"><get-data xmlns=\"urn:ietf:params:xml:ns:yang:ietf-netconf-nmda\" xmlns:ds=\"urn:ietf:params:xml:ns:yang:ietf-datastores\">" +
+		"<datastore>ds:intended</datastore></get-data></rpc>"
+	output, err = xml.Marshal(message.NewGetData(message.DatastoreIntended, "", nil, nil, false, 0, ""))
+	if err != nil {
+		t.Errorf(err.Error())
+	}
+
+	if got, want := StripUUID(string(output)), StripUUID(expected); got != want {
+		t.Errorf("TestNewGetData:\nGot:%s\nWant:\n%s", got, want)
+	}
+}
+
+func TestNewGetDataInvalid(t *testing.T) {
+	if !panics(func() { message.NewGetData("unknown", "", nil, nil, false, 0, "") }) {
+		t.Errorf("TestNewGetDataInvalid: expected an unknown datastore to panic")
+	}
+	if !panics(func() { message.NewGetData(message.DatastoreRunning, "", nil, []string{"intended"}, false, 0, "") }) {
+		t.Errorf("TestNewGetDataInvalid: expected an origin filter on running to panic")
+	}
+	if !panics(func() { message.NewGetData(message.DatastoreOperational, "", nil, nil, false, 0, "all") }) {
+		t.Errorf("TestNewGetDataInvalid: expected an unknown with-defaults mode to panic")
+	}
+}
+
+func TestNewEditData(t *testing.T) {
+	expected := "<rpc xmlns=\"urn:ietf:params:xml:ns:netconf:base:1.0\" message-id=\"\"><edit-data xmlns=\"urn:ietf:params:xml:ns:yang:ietf-netconf-nmda\" xmlns:ds=\"urn:ietf:params:xml:ns:yang:ietf-datastores\">" +
+		"<datastore>ds:running</datastore><default-operation>merge</default-operation><config>" + data + "</config></edit-data></rpc>"
+
+	rpc := message.NewEditData(message.DatastoreRunning, message.DefaultOperationTypeMerge, data)
+	output, err := xml.Marshal(rpc)
+	if err != nil {
+		t.Errorf(err.Error())
+	}
+
+	if got, want := StripUUID(string(output)), StripUUID(expected); got != want {
+		t.Errorf("TestNewEditData:\nGot:%s\nWant:\n%s", got, want)
+	}
+
+	expected = "<rpc xmlns=\"urn:ietf:params:xml:ns:netconf:base:1.0\" message-id=\"\"><edit-data xmlns=\"urn:ietf:params:xml:ns:yang:ietf-netconf-nmda\" xmlns:ds=\"urn:ietf:params:xml:ns:yang:ietf-datastores\">" +
+		"<datastore xmlns:dsx=\"urn:example:ephemeral\">dsx:ephemeral</datastore><config>" + data + "</config></edit-data></rpc>"
+	output, err = xml.Marshal(message.NewEditDataDynamic("urn:example:ephemeral", "ephemeral", "", data))
+	if err != nil {
+		t.Errorf(err.Error())
+	}
+
+	if got, want := StripUUID(string(output)), StripUUID(expected); got != want {
+		t.Errorf("TestNewEditData:\nGot:%s\nWant:\n%s", got, want)
+	}
+}
+
+func TestNewEditDataReadOnly(t *testing.T) {
+	if !panics(func() { message.NewEditData(message.DatastoreOperational, message.DefaultOperationTypeMerge, data) }) {
+		t.Errorf("TestNewEditDataReadOnly: expected editing the operational datastore to panic")
+	}
+}
+
+func TestNewPartialLock(t *testing.T) {
+	expected := "<rpc xmlns=\"urn:ietf:params:xml:ns:netconf:base:1.0\" message-id=\"\"><partial-lock xmlns=\"urn:ietf:params:xml:ns:netconf:partial-lock:1.0\" xmlns:if=\"urn:ietf:params:xml:ns:yang:ietf-interfaces\" xmlns:sys=\"urn:ietf:params:xml:ns:yang:ietf-system\">" +
+		"<select>/if:interfaces/if:interface[if:name=&#39;eth0&#39;]</select><select>/sys:system/sys:hostname</select></partial-lock></rpc>"
+
+	rpc := message.NewPartialLock(
+		[]string{"/if:interfaces/if:interface[if:name='eth0']", "/sys:system/sys:hostname"},
+		map[string]string{"sys": "urn:ietf:params:xml:ns:yang:ietf-system", "if": "urn:ietf:params:xml:ns:yang:ietf-interfaces"},
+	)
+	output, err := xml.Marshal(rpc)
+	if err != nil {
+		t.Errorf(err.Error())
+	}
+
+	if got, want := StripUUID(string(output)), StripUUID(expected); got != want {
+		t.Errorf("TestNewPartialLock:\nGot:%s\nWant:\n%s", got, want)
+	}
+}
+
+func TestNewPartialUnlock(t *testing.T) {
+	expected := "<rpc xmlns=\"urn:ietf:params:xml:ns:netconf:base:1.0\" message-id=\"\"><partial-unlock xmlns=\"urn:ietf:params:xml:ns:netconf:partial-lock:1.0\"><lock-id>127</lock-id></partial-unlock></rpc>"
+
+	rpc := message.NewPartialUnlock(127)
+	output, err := xml.Marshal(rpc)
+	if err != nil {
+		t.Errorf(err.Error())
+	}
+
+	if got, want := StripUUID(string(output)), StripUUID(expected); got != want {
+		t.Errorf("TestNewPartialUnlock:\nGot:%s\nWant:\n%s", got, want)
+	}
+}
+
+func TestNewAction(t *testing.T) {
+	action := "<interfaces xmlns=\"urn:example:interfaces\"><interface><name>eth0</name><reset><delay>10</delay></reset></interface></interfaces>"
+	expected := "<rpc xmlns=\"urn:ietf:params:xml:ns:netconf:base:1.0\" message-id=\"\"><action xmlns=\"urn:ietf:params:xml:ns:yang:1\">" + action + "</action></rpc>"
+
+	rpc := message.NewAction(action)
+	output, err := xml.Marshal(rpc)
+	if err != nil {
+		t.Errorf(err.Error())
+	}
+
+	if got, want := StripUUID(string(output)), StripUUID(expected); got != want {
+		t.Errorf("TestNewAction:\nGot:%s\nWant:\n%s", got, want)
+	}
+}
+
 func TestNewRPC(t *testing.T) {
 	expected := "<rpc xmlns=\"urn:ietf:params:xml:ns:netconf:base:1.0\" message-id=\"\"><commit></commit></rpc>"
 	data := "<commit></commit>"
@@ -311,3 +768,240 @@ func TestNewRPC(t *testing.T) {
 		t.Errorf("TestNewRPC:\nGot:%s\nWant:\n%s", got, want)
 	}
 }
+
+type interfaceConfig struct {
+	XMLName     xml.Name `xml:"urn:ietf:params:xml:ns:yang:ietf-interfaces interface"`
+	Name        string   `xml:"name"`
+	Description string   `xml:"description,omitempty" nc:"operation=remove"`
+	MTU         int      `xml:"mtu,omitempty" nc:"operation=replace"`
+	Enabled     *bool    `xml:"config>enabled"`
+}
+
+type interfacesConfig struct {
+	XMLName    xml.Name          `xml:"urn:ietf:params:xml:ns:yang:ietf-interfaces interfaces"`
+	Interfaces []interfaceConfig `xml:"interface" nc:"operation=merge"`
+}
+
+func TestNewEditConfigFromStruct(t *testing.T) {
+	expected := "<rpc xmlns=\"urn:ietf:params:xml:ns:netconf:base:1.0\" message-id=\"\"><edit-config><target><candidate></candidate></target><default-operation>none</default-operation><config>" +
+		"<interfaces xmlns=\"urn:ietf:params:xml:ns:yang:ietf-interfaces\">" +
+		"<interface xmlns=\"urn:ietf:params:xml:ns:yang:ietf-interfaces\" xmlns:nc=\"urn:ietf:params:xml:ns:netconf:base:1.0\" nc:operation=\"merge\"><name>eth0</name><description xmlns:nc=\"urn:ietf:params:xml:ns:netconf:base:1.0\" nc:operation=\"remove\">uplink</description><mtu xmlns:nc=\"urn:ietf:params:xml:ns:netconf:base:1.0\" nc:operation=\"replace\">9000</mtu></interface>" +
+		"<interface xmlns=\"urn:ietf:params:xml:ns:yang:ietf-interfaces\" xmlns:nc=\"urn:ietf:params:xml:ns:netconf:base:1.0\" nc:operation=\"merge\"><name>eth1</name></interface>" +
+		"</interfaces></config></edit-config></rpc>"
+
+	data := interfacesConfig{Interfaces: []interfaceConfig{{Name: "eth0", Description: "uplink", MTU: 9000}, {Name: "eth1"}}}
+	rpc := message.NewEditConfigFromStruct(message.DatastoreCandidate, message.DefaultOperationTypeNone, data)
+	output, err := xml.Marshal(rpc)
+	if err != nil {
+		t.Errorf(err.Error())
+	}
+
+	if got, want := StripUUID(string(output)), StripUUID(expected); got != want {
+		t.Errorf("TestNewEditConfigFromStruct:\nGot:%s\nWant:\n%s", got, want)
+	}
+}
+
+func TestNewEditConfigFromStructInvalidOperation(t *testing.T) {
+	type invalid struct {
+		Name string `xml:"name" nc:"operation=upsert"`
+	}
+	didPanic := panics(
+		func() {
+			_ = message.NewEditConfigFromStruct(message.DatastoreRunning, message.DefaultOperationTypeMerge, invalid{})
+		},
+	)
+
+	// expect to panic
+	if didPanic != true {
+		t.FailNow()
+	}
+}
+
+func TestParseEventTime(t *testing.T) {
+	want := time.Date(2021, 11, 1, 10, 0, 0, 0, time.UTC)
+	valid := map[string]time.Time{
+		"2021-11-01T10:00:00Z":             want,
+		"2021-11-01T10:00:00.123456Z":      want.Add(123456 * time.Microsecond),
+		"2021-11-01T11:00:00+01:00":        want,
+		"2021-11-01T11:00:00.5+0100":       want.Add(500 * time.Millisecond),
+		"2021-11-01T05:00:00-05":           want,
+		" 2021-11-01t10:00:00z ":           want,
+		"2021-11-01 10:00:00Z":             want,
+		"2021-11-01T10:00:00":              want,
+		"2021-11-01T15:30:00.000000+05:30": want,
+	}
+	for value, expected := range valid {
+		got, err := message.ParseEventTime(value)
+		if err != nil {
+			t.Errorf("TestParseEventTime: failed to parse %q: %v", value, err)
+			continue
+		}
+		if !got.Equal(expected) {
+			t.Errorf("TestParseEventTime: got %s for %q, wanted %s", got, value, expected)
+		}
+	}
+
+	for _, value := range []string{"", "yesterday", "2021-11-01", "2021-11-01T10:00:00+1"} {
+		if _, err := message.ParseEventTime(value); err == nil {
+			t.Errorf("TestParseEventTime: expected %q to be invalid", value)
+		}
+	}
+
+	notification, err := message.NewNotification([]byte(`<notification xmlns="urn:ietf:params:xml:ns:netconf:notification:1.0"><eventTime>2021-11-01T10:00:00Z</eventTime></notification>`))
+	if err != nil {
+		t.Fatalf("TestParseEventTime: failed to parse notification: %v", err)
+	}
+	if got, err := notification.Time(); err != nil || !got.Equal(want) || notification.EventTime != "2021-11-01T10:00:00Z" {
+		t.Errorf("TestParseEventTime: got %s, %v for %s, wanted %s", got, err, notification.EventTime, want)
+	}
+}
+
+func TestSetMessageIDGenerator(t *testing.T) {
+	message.SetMessageIDGenerator(message.NewCounterMessageID(10))
+	defer message.SetMessageIDGenerator(nil)
+
+	if first, second := message.NewGet("", "").GetMessageID(), message.NewCommit().GetMessageID(); first != "10" || second != "11" {
+		t.Errorf("got message-ids %s and %s, wanted 10 and 11", first, second)
+	}
+
+	message.SetMessageIDGenerator(nil)
+	if id := message.NewGet("", "").GetMessageID(); len(id) != 36 {
+		t.Errorf("got message-id %s, wanted a UUID", id)
+	}
+}
+
+type makeToast struct {
+	Doneness int
+}
+
+func (toast makeToast) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	start.Name = xml.Name{Space: "http://netconfcentral.org/ns/toaster", Local: "make-toast"}
+	return e.EncodeElement(struct {
+		Doneness int `xml:"toasterDoneness"`
+	}{toast.Doneness}, start)
+}
+
+func TestNewRPCWithOptions(t *testing.T) {
+	expected := `<rpc xmlns="urn:ietf:params:xml:ns:netconf:base:1.0" message-id="" xmlns:junos="http://xml.juniper.net/junos/22.2I0/junos" junos:format="json">` +
+		`<make-toast xmlns="http://netconfcentral.org/ns/toaster"><toasterDoneness>9</toasterDoneness></make-toast></rpc>`
+
+	rpc := message.NewRPC(makeToast{Doneness: 9},
+		message.WithRPCNamespace("junos", "http://xml.juniper.net/junos/22.2I0/junos"), message.WithRPCAttr("junos:format", "json"))
+	output, err := xml.Marshal(rpc)
+	if err != nil {
+		t.Fatalf("TestNewRPCWithOptions: failed to marshal rpc: %v", err)
+	}
+	if got, want := StripUUID(string(output)), StripUUID(expected); got != want {
+		t.Errorf("TestNewRPCWithOptions:\nGot:%s\nWant:\n%s", got, want)
+	}
+}
+
+func TestSubtreeFilter(t *testing.T) {
+	filter := message.NewSubtree("urn:ietf:params:xml:ns:yang:ietf-interfaces", "interfaces")
+	filter.Container("interface").Match("name", "eth<0>").Select("mtu", "enabled").
+		Add(message.NewSubtree("urn:ietf:params:xml:ns:yang:ietf-ip", "ipv4").Select("address"))
+	users := message.NewSubtree("http://example.com/schema/1.2/config", "users").MatchAttr("type", "admin")
+
+	expected := `<interfaces xmlns="urn:ietf:params:xml:ns:yang:ietf-interfaces"><interface><name>eth&lt;0&gt;</name><mtu></mtu><enabled></enabled>` +
+		`<ipv4 xmlns="urn:ietf:params:xml:ns:yang:ietf-ip"><address></address></ipv4></interface></interfaces>` +
+		`<users xmlns="http://example.com/schema/1.2/config" type="admin"></users>`
+	if got := message.SubtreeFilter(filter, users); got != expected {
+		t.Errorf("TestSubtreeFilter:\nGot:%s\nWant:\n%s", got, expected)
+	}
+
+	rpc := message.NewGetConfig(message.DatastoreRunning, message.FilterTypeSubtree, users.String())
+	output, err := xml.Marshal(rpc)
+	if err != nil {
+		t.Fatalf("TestSubtreeFilter: failed to marshal get-config: %v", err)
+	}
+	if !bytes.Contains(output, []byte(`<filter type="subtree">`+users.String()+`</filter>`)) {
+		t.Errorf("TestSubtreeFilter: got get-config %s", output)
+	}
+}
+
+func TestNmdaDatastores(t *testing.T) {
+	output, err := xml.Marshal(message.NewGetData(message.DatastoreSystem, "", nil, nil, false, 0, ""))
+	if err != nil || !bytes.Contains(output, []byte("<datastore>ds:system</datastore>")) {
+		t.Errorf("TestNmdaDatastores: got get-data %s and error %v", output, err)
+	}
+
+	for _, datastore := range []string{message.DatastoreIntended, message.DatastoreOperational, message.DatastoreSystem} {
+		if !message.IsNmdaDatastore(datastore) {
+			t.Errorf("TestNmdaDatastores: expected %s to be an NMDA datastore", datastore)
+		}
+		if !panics(func() { message.NewLock(datastore) }) || !panics(func() { message.NewGetConfig(datastore, "", "") }) {
+			t.Errorf("TestNmdaDatastores: expected %s to be refused by lock and get-config", datastore)
+		}
+		if !panics(func() { message.NewEditData(datastore, message.DefaultOperationTypeMerge, "<top/>") }) {
+			t.Errorf("TestNmdaDatastores: expected %s to be refused by edit-data", datastore)
+		}
+	}
+	if message.IsNmdaDatastore(message.DatastoreRunning) {
+		t.Errorf("TestNmdaDatastores: expected running not to be an NMDA datastore")
+	}
+
+	ephemeral := message.NewDynamicDatastore("urn:example:ephemeral", "ephemeral")
+	lock, err := message.NewLockDatastore(ephemeral)
+	if err != nil {
+		t.Fatalf("TestNmdaDatastores: failed to build lock: %v", err)
+	}
+	output, err = xml.Marshal(lock)
+	if err != nil || !bytes.Contains(output, []byte(`<lock><target><ephemeral xmlns="urn:example:ephemeral"></ephemeral></target></lock>`)) {
+		t.Errorf("TestNmdaDatastores: got lock %s and error %v", output, err)
+	}
+	if ephemeral.Name() != "dynamic" {
+		t.Errorf("TestNmdaDatastores: got datastore name %s, wanted dynamic", ephemeral.Name())
+	}
+	if _, err := message.NewEditConfigDatastore(ephemeral, message.DefaultOperationTypeMerge, "<top/>"); err == nil {
+		t.Errorf("TestNmdaDatastores: expected edit-config to refuse the dynamic datastore")
+	}
+}
+
+func TestEditConfigBuilder(t *testing.T) {
+	expected := `<rpc xmlns="urn:ietf:params:xml:ns:netconf:base:1.0" message-id=""><edit-config><target><candidate></candidate></target>` +
+		`<default-operation>none</default-operation><error-option>rollback-on-error</error-option><config>` +
+		`<system xmlns:nc="urn:ietf:params:xml:ns:netconf:base:1.0" nc:operation="merge" xmlns="urn:example:system"><hostname>r1</hostname></system>` +
+		"\n  <sys:users xmlns:nc=\"urn:ietf:params:xml:ns:netconf:base:1.0\" nc:operation=\"delete\" xmlns:sys=\"urn:example:system\"><sys:user><sys:name>bob</sys:name></sys:user></sys:users>" +
+		`<ntp xmlns="urn:example:system"/>` +
+		`</config></edit-config></rpc>`
+
+	rpc, err := message.NewEditConfigBuilder(message.NewDatastore(message.DatastoreCandidate)).
+		DefaultOperation(message.DefaultOperationTypeNone).
+		ErrorOption(message.ErrorOptionRollbackOnError).
+		Merge(`<system xmlns="urn:example:system"><hostname>r1</hostname></system>`).
+		Delete("\n  <sys:users xmlns:sys=\"urn:example:system\"><sys:user><sys:name>bob</sys:name></sys:user></sys:users>").
+		Add("", `<ntp xmlns="urn:example:system"/>`).
+		Build()
+	if err != nil {
+		t.Fatalf("TestEditConfigBuilder: failed to build edit-config: %v", err)
+	}
+	output, err := xml.Marshal(rpc)
+	if err != nil {
+		t.Fatalf("TestEditConfigBuilder: failed to marshal edit-config: %v", err)
+	}
+	if got, want := StripUUID(string(output)), StripUUID(expected); got != want {
+		t.Errorf("TestEditConfigBuilder:\nGot:%s\nWant:\n%s", got, want)
+	}
+
+	builder := message.NewEditConfigBuilder(message.NewDatastore(message.DatastoreRunning))
+	invalid := map[string]func(){
+		"unknown operation": func() { builder.Add("move", `<system/>`) },
+		"two top elements":  func() { builder.Merge(`<system/><users/>`) },
+		"no element":        func() { builder.Merge(`text`) },
+		"malformed":         func() { builder.Merge(`<system>`) },
+		"operation set": func() {
+			builder.Replace(`<system xmlns:nc="urn:ietf:params:xml:ns:netconf:base:1.0" nc:operation="merge"/>`)
+		},
+	}
+	for name, build := range invalid {
+		if !panics(build) {
+			t.Errorf("TestEditConfigBuilder: expected %s to panic", name)
+		}
+	}
+	if _, err := builder.Build(); err == nil {
+		t.Errorf("TestEditConfigBuilder: expected an empty edit-config to fail")
+	}
+	if _, err := message.NewEditConfigBuilder(message.NewURLDatastore("file:///config.xml")).Merge(`<system/>`).Build(); err == nil {
+		t.Errorf("TestEditConfigBuilder: expected a url target to fail")
+	}
+}
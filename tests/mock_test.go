@@ -0,0 +1,48 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/openshift-telco/go-netconf-client/netconf/message"
+	"github.com/openshift-telco/go-netconf-client/netconf/mock"
+)
+
+func TestMockProfileLatency(t *testing.T) {
+	profile := mock.Profile{Latency: 100 * time.Millisecond, Jitter: 50 * time.Millisecond, Seed: 1}
+	session := newMockSession(t, mock.NewTransport(mock.WithProfile(profile)))
+	defer session.Close()
+
+	start := time.Now()
+	if _, err := session.SyncRPC(message.NewGetConfig(message.DatastoreRunning, "", ""), 5); err != nil {
+		t.Fatalf("failed to execute rpc: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < profile.Latency {
+		t.Errorf("got reply after %v, wanted at least %v", elapsed, profile.Latency)
+	}
+}
+
+func TestMockProfileDrop(t *testing.T) {
+	session := newMockSession(t, mock.NewTransport(mock.WithProfile(mock.Profile{DropRate: 1})))
+	defer session.Close()
+
+	if _, err := session.SyncRPC(message.NewGetConfig(message.DatastoreRunning, "", ""), 1); err == nil {
+		t.Errorf("expected the rpc to time out when its reply is dropped")
+	}
+}
+
+func TestMockProfileMalformed(t *testing.T) {
+	transport := mock.NewTransport(mock.WithProfile(mock.Profile{MalformedRate: 1}))
+	session := newMockSession(t, transport)
+	defer session.Close()
+
+	if _, err := session.SyncRPC(message.NewGetConfig(message.DatastoreRunning, "", ""), 1); err == nil {
+		t.Errorf("expected the rpc to time out when its reply is malformed")
+	}
+	if session.Closed() {
+		t.Errorf("expected the session to survive a recovered framing error")
+	}
+	if stats := session.Listener.CorrelationStats(); stats.Pending != 0 {
+		t.Errorf("got %d pending callbacks, wanted none", stats.Pending)
+	}
+}
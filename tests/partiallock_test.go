@@ -0,0 +1,83 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/openshift-telco/go-netconf-client/netconf"
+	"github.com/openshift-telco/go-netconf-client/netconf/mock"
+)
+
+const partialLockHello = `<hello xmlns="urn:ietf:params:xml:ns:netconf:base:1.0"><capabilities>` +
+	`<capability>urn:ietf:params:netconf:base:1.1</capability>` +
+	`<capability>urn:ietf:params:netconf:capability:partial-lock:1.0</capability>` +
+	`</capabilities><session-id>1</session-id></hello>`
+
+// partialLockServer grants increasing lock-ids, and counts the released locks.
+type partialLockServer struct {
+	next     atomic.Uint32
+	released atomic.Int32
+}
+
+func (s *partialLockServer) handle(messageID string, request []byte) []byte {
+	switch {
+	case strings.Contains(string(request), "<partial-lock"):
+		id := s.next.Add(1)
+		return mock.Reply(messageID, `<lock-id xmlns="urn:ietf:params:xml:ns:netconf:partial-lock:1.0">`+
+			strconv.Itoa(int(id))+`</lock-id><locked-node xmlns:if="urn:ietf:params:xml:ns:yang:ietf-interfaces">`+
+			`/if:interfaces/if:interface[if:name='eth0']</locked-node>`)
+	case strings.Contains(string(request), "<partial-unlock"):
+		s.released.Add(1)
+	}
+	return mock.ReplyOK(messageID, request)
+}
+
+func TestPartialLock(t *testing.T) {
+	server := &partialLockServer{}
+	session := newMockSession(t, mock.NewTransport(mock.WithHello(partialLockHello), mock.WithHandler(server.handle)))
+
+	namespaces := map[string]string{"if": "urn:ietf:params:xml:ns:yang:ietf-interfaces"}
+	lock, err := session.PartialLock(context.Background(), []string{"/if:interfaces/if:interface[if:name='eth0']"}, namespaces)
+	if err != nil {
+		t.Fatalf("failed to lock: %v", err)
+	}
+	want := &netconf.PartialLock{ID: 1, LockedNodes: []string{"/if:interfaces/if:interface[if:name='eth0']"}}
+	if !reflect.DeepEqual(lock, want) {
+		t.Errorf("got lock %+v, wanted %+v", lock, want)
+	}
+	if _, err := session.PartialLock(context.Background(), []string{"/if:interfaces"}, namespaces); err != nil {
+		t.Fatalf("failed to lock: %v", err)
+	}
+	if locks := session.PartialLocks(); len(locks) != 2 || locks[0].ID != 1 || locks[1].ID != 2 {
+		t.Errorf("got held locks %+v, wanted 1 and 2", locks)
+	}
+
+	if err := session.PartialUnlock(context.Background(), 1); err != nil {
+		t.Errorf("failed to unlock: %v", err)
+	}
+	if locks := session.PartialLocks(); len(locks) != 1 {
+		t.Errorf("got held locks %+v, wanted only 2", locks)
+	}
+
+	// the remaining lock is released on close
+	if err := session.CloseGracefully(5); err != nil {
+		t.Errorf("failed to close session: %v", err)
+	}
+	if released := server.released.Load(); released != 2 {
+		t.Errorf("got %d locks released, wanted 2", released)
+	}
+}
+
+func TestPartialLockNotSupported(t *testing.T) {
+	session := newMockSession(t, mock.NewTransport())
+	defer session.Close()
+
+	if _, err := session.PartialLock(context.Background(), []string{"/interfaces"}, nil); !errors.Is(err, netconf.ErrPartialLockNotSupported) {
+		t.Errorf("got error %v, wanted %v", err, netconf.ErrPartialLockNotSupported)
+	}
+}
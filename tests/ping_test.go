@@ -0,0 +1,153 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/openshift-telco/go-netconf-client/netconf"
+	"github.com/openshift-telco/go-netconf-client/netconf/message"
+	"github.com/openshift-telco/go-netconf-client/netconf/mock"
+	"golang.org/x/crypto/ssh"
+)
+
+// echoTransport replies <ok/> to every RPC it is sent, after providing the server hello.
+type echoTransport struct {
+	lock     sync.Mutex
+	closed   bool
+	sent     chan string
+	received chan []byte
+}
+
+func newEchoTransport() *echoTransport {
+	t := &echoTransport{sent: make(chan string, 100), received: make(chan []byte, 100)}
+	t.received <- []byte(strings.TrimSuffix(serverHello, "]]>]]>"))
+	return t
+}
+
+func (t *echoTransport) Send(data []byte) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.closed {
+		return io.ErrClosedPipe
+	}
+	if match := messageIDRegex.FindSubmatch(data); match != nil {
+		select {
+		case t.sent <- string(data):
+		default:
+		}
+		t.received <- []byte(fmt.Sprintf("<rpc-reply xmlns=\"urn:ietf:params:xml:ns:netconf:base:1.0\" message-id=\"%s\"><ok/></rpc-reply>", match[1]))
+	}
+	return nil
+}
+
+func (t *echoTransport) Receive() ([]byte, error) {
+	data, ok := <-t.received
+	if !ok {
+		return nil, io.EOF
+	}
+	return data, nil
+}
+
+func (t *echoTransport) Close() error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if !t.closed {
+		t.closed = true
+		close(t.received)
+	}
+	return nil
+}
+
+func (t *echoTransport) SetVersion(version string) {}
+
+func TestPingSSH(t *testing.T) {
+	address := startSSHServer(t, "127.0.0.1:0", helloHandler)
+
+	session, err := netconf.NewSessionFromSSHConfig(address, sshClientConfig())
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+	defer session.Close()
+
+	if _, err := session.Ping(5); err != nil {
+		t.Errorf("failed to ping: %v", err)
+	}
+}
+
+func TestPingFallbackToGet(t *testing.T) {
+	transport := newEchoTransport()
+	session, err := netconf.NewSession(transport)
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+	defer session.Close()
+	if err := session.SendHello(&message.Hello{Capabilities: netconf.DefaultCapabilities}); err != nil {
+		t.Fatalf("failed to send hello: %v", err)
+	}
+
+	if _, err := session.Ping(5); err != nil {
+		t.Fatalf("failed to ping: %v", err)
+	}
+	request := <-transport.sent
+	if !strings.Contains(request, "<get><filter type=\"subtree\"></filter></get>") {
+		t.Errorf("got request %s, wanted a get with an empty subtree filter", request)
+	}
+}
+
+func TestPingContext(t *testing.T) {
+	session := newMockSession(t, mock.NewTransport(mock.WithHandler(mock.NoReply)))
+	defer session.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, err := session.PingContext(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got error %v, wanted the context deadline", err)
+	}
+}
+
+func TestPingSSHTimeout(t *testing.T) {
+	// the server never replies to the global requests
+	address := startSSHServerWithOptions(t, "127.0.0.1:0", helloHandler, sshServerOptions{
+		globalRequests: func(requests <-chan *ssh.Request) {
+			for range requests {
+			}
+		},
+	})
+
+	session, err := netconf.NewSessionFromSSHConfig(address, sshClientConfig())
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+	defer session.Close()
+
+	start := time.Now()
+	if _, err := session.Ping(1); err == nil {
+		t.Errorf("expected the ping of an unresponsive server to fail")
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("got ping returning after %v, wanted the timeout applied", elapsed)
+	}
+	// the dead connection is closed, so the next ping fails at once
+	start = time.Now()
+	if _, err := session.Ping(1); err == nil {
+		t.Errorf("expected the ping of a closed connection to fail")
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("got ping returning after %v, wanted the connection closed by the previous ping", elapsed)
+	}
+}
+
+func TestPingClosedSession(t *testing.T) {
+	session := newMockSession(t, mock.NewTransport())
+	_ = session.Close()
+
+	if _, err := session.Ping(1); !errors.Is(err, netconf.ErrSessionClosed) {
+		t.Errorf("got error %v, wanted ErrSessionClosed", err)
+	}
+}
@@ -0,0 +1,218 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/openshift-telco/go-netconf-client/netconf"
+	"github.com/openshift-telco/go-netconf-client/netconf/message"
+	"github.com/openshift-telco/go-netconf-client/netconf/mock"
+)
+
+func mockPoolFactory(options ...mock.Option) netconf.PoolFactory {
+	return func(ctx context.Context) (*netconf.Session, error) {
+		session, err := netconf.NewSession(mock.NewTransport(options...), netconf.WithRPCTimeout(100*time.Millisecond))
+		if err != nil {
+			return nil, err
+		}
+		return session, session.SendHello(&message.Hello{Capabilities: netconf.DefaultCapabilities})
+	}
+}
+
+func TestPool(t *testing.T) {
+	pool := netconf.NewPool(mockPoolFactory(mock.WithProfile(mock.Profile{Latency: 5 * time.Millisecond})), netconf.WithPoolSize(2))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := pool.Do(context.Background(), func(s *netconf.Session) error {
+				_, err := s.SyncRPC(message.NewGetConfig(message.DatastoreRunning, "", ""), 5)
+				return err
+			})
+			if err != nil {
+				t.Errorf("failed to run operation: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if stats := pool.Stats(); stats.Created != 2 || stats.Idle != 2 || stats.InUse != 0 {
+		t.Errorf("got stats %+v, wanted 2 sessions created and idle", stats)
+	}
+
+	// a closed session is dropped once checked in
+	session, err := pool.Get(context.Background())
+	if err != nil {
+		t.Fatalf("failed to get session: %v", err)
+	}
+	_ = session.Close()
+	pool.Put(session)
+	if stats := pool.Stats(); stats.Idle != 1 {
+		t.Errorf("got %d idle sessions, wanted 1", stats.Idle)
+	}
+
+	// the pool size is reached
+	first, _ := pool.Get(context.Background())
+	second, _ := pool.Get(context.Background())
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := pool.Get(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got error %v, wanted %v", err, context.DeadlineExceeded)
+	}
+	pool.Put(first)
+	pool.Put(second)
+
+	if err := pool.Close(); err != nil {
+		t.Errorf("failed to close pool: %v", err)
+	}
+	if !first.Closed() || !second.Closed() {
+		t.Errorf("expected the idle sessions to be closed with the pool")
+	}
+	if _, err := pool.Get(context.Background()); !errors.Is(err, netconf.ErrPoolClosed) {
+		t.Errorf("got error %v, wanted %v", err, netconf.ErrPoolClosed)
+	}
+}
+
+func TestPoolMaxIdle(t *testing.T) {
+	pool := netconf.NewPool(mockPoolFactory(), netconf.WithPoolMaxIdle(50*time.Millisecond))
+	defer pool.Close()
+
+	session, err := pool.Get(context.Background())
+	if err != nil {
+		t.Fatalf("failed to get session: %v", err)
+	}
+	pool.Put(session)
+	for deadline := time.Now().Add(5 * time.Second); !session.Closed(); time.Sleep(10 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("idle session not evicted")
+		}
+	}
+	if stats := pool.Stats(); stats.Evicted != 1 || stats.Idle != 0 {
+		t.Errorf("got stats %+v, wanted the session evicted", stats)
+	}
+}
+
+func TestPoolHealthCheck(t *testing.T) {
+	pool := netconf.NewPool(mockPoolFactory(mock.WithHandler(mock.NoReply)), netconf.WithPoolHealthCheck(20*time.Millisecond))
+	defer pool.Close()
+
+	session, err := pool.Get(context.Background())
+	if err != nil {
+		t.Fatalf("failed to get session: %v", err)
+	}
+	pool.Put(session)
+	for deadline := time.Now().Add(5 * time.Second); !session.Closed(); time.Sleep(10 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("unresponsive session not evicted")
+		}
+	}
+
+	next, err := pool.Get(context.Background())
+	if err != nil {
+		t.Fatalf("failed to get session: %v", err)
+	}
+	if next == session {
+		t.Errorf("expected a new session once the unresponsive one was evicted")
+	}
+	pool.Put(next)
+}
+
+func TestPoolHealthCheckBounded(t *testing.T) {
+	// the sessions keep the default RPC timeout of 30 seconds
+	factory := func(ctx context.Context) (*netconf.Session, error) {
+		session, err := netconf.NewSession(mock.NewTransport(mock.WithHandler(mock.NoReply)))
+		if err != nil {
+			return nil, err
+		}
+		return session, session.SendHello(&message.Hello{Capabilities: netconf.DefaultCapabilities})
+	}
+	pool := netconf.NewPool(factory, netconf.WithPoolHealthCheck(50*time.Millisecond))
+
+	var sessions []*netconf.Session
+	for i := 0; i < 3; i++ {
+		session, err := pool.Get(context.Background())
+		if err != nil {
+			t.Fatalf("failed to get session: %v", err)
+		}
+		sessions = append(sessions, session)
+	}
+	for _, session := range sessions {
+		pool.Put(session)
+	}
+
+	// the unresponsive sessions are all evicted within the health check interval
+	for deadline := time.Now().Add(2 * time.Second); pool.Stats().Evicted != 3; time.Sleep(10 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("got %d sessions evicted, wanted 3", pool.Stats().Evicted)
+		}
+	}
+	start := time.Now()
+	_ = pool.Close()
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("got pool closed after %v, wanted the health checks bounded", elapsed)
+	}
+}
+
+func TestPoolProbes(t *testing.T) {
+	pool := netconf.NewPool(mockPoolFactory(), netconf.WithPoolMinHealthy(1))
+
+	if err := pool.Healthy(); err != nil {
+		t.Errorf("got unhealthy pool: %v", err)
+	}
+	if err := pool.Ready(); err == nil {
+		t.Errorf("expected a pool without session not to be ready")
+	}
+	recorder := httptest.NewRecorder()
+	pool.ReadyHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if recorder.Code != http.StatusServiceUnavailable {
+		t.Errorf("got status %d, wanted %d", recorder.Code, http.StatusServiceUnavailable)
+	}
+
+	session, err := pool.Get(context.Background())
+	if err != nil {
+		t.Fatalf("failed to get session: %v", err)
+	}
+	pool.Put(session)
+	if err := pool.Ready(); err != nil {
+		t.Errorf("got pool not ready: %v", err)
+	}
+	recorder = httptest.NewRecorder()
+	pool.ReadyHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	var body struct {
+		Status string            `json:"status"`
+		Stats  netconf.PoolStats `json:"stats"`
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+		t.Fatalf("failed to decode probe body %q: %v", recorder.Body, err)
+	}
+	if recorder.Code != http.StatusOK || body.Status != "ok" || body.Stats.Idle != 1 || body.Stats.LastProbe.IsZero() {
+		t.Errorf("got status %d and body %+v, wanted ok with one idle session", recorder.Code, body)
+	}
+
+	_ = pool.Close()
+	recorder = httptest.NewRecorder()
+	pool.HealthHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if !errors.Is(pool.Healthy(), netconf.ErrPoolClosed) || recorder.Code != http.StatusServiceUnavailable {
+		t.Errorf("got status %d, wanted a closed pool to be unhealthy", recorder.Code)
+	}
+}
+
+func TestPoolProbesUnreachable(t *testing.T) {
+	failure := errors.New("connection refused")
+	pool := netconf.NewPool(func(ctx context.Context) (*netconf.Session, error) { return nil, failure })
+	defer pool.Close()
+
+	if _, err := pool.Get(context.Background()); !errors.Is(err, failure) {
+		t.Fatalf("got error %v, wanted %v", err, failure)
+	}
+	if err := pool.Healthy(); !errors.Is(err, failure) {
+		t.Errorf("got %v, wanted the pool unhealthy with %v", err, failure)
+	}
+}
@@ -0,0 +1,94 @@
+package tests
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/openshift-telco/go-netconf-client/netconf"
+	"github.com/openshift-telco/go-netconf-client/netconf/message"
+	"github.com/openshift-telco/go-netconf-client/netconf/mock"
+)
+
+func TestReconnect(t *testing.T) {
+	transports := make(chan *mock.Transport, 2)
+	var current atomic.Pointer[mock.Transport]
+	redial := func() (netconf.Transport, error) {
+		transport := mock.NewTransport()
+		current.Store(transport)
+		transports <- transport
+		return transport, nil
+	}
+	first, _ := redial()
+	session := newMockSession(t, first.(*mock.Transport), netconf.WithRedial(redial))
+	defer session.Close()
+
+	notifications := make(chan *message.Notification, 1)
+	session.Listener.Register(message.NetconfNotificationStreamHandler, func(event netconf.Event) {
+		notifications <- event.Notification()
+	})
+
+	// the server hangs up
+	_ = current.Load().Close()
+	for deadline := time.Now().Add(5 * time.Second); session.State() != netconf.StateClosed; time.Sleep(10 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("session not closed once the server hung up")
+		}
+	}
+
+	reinitialized := false
+	err := session.Reconnect(func(s *netconf.Session) error {
+		reinitialized = true
+		_, err := s.SyncRPC(message.NewCreateSubscription("", "", ""), 5)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("failed to reconnect: %v", err)
+	}
+	if !reinitialized {
+		t.Errorf("expected the reinit function to be called")
+	}
+	if state := session.State(); state != netconf.StateEstablished {
+		t.Errorf("got state %s, wanted %s", state, netconf.StateEstablished)
+	}
+
+	current.Load().Notify("<notification xmlns=\"urn:ietf:params:xml:ns:netconf:notification:1.0\"><eventTime>2021-11-01T10:00:00Z</eventTime></notification>")
+	select {
+	case <-notifications:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("registered notification handler not restored")
+	}
+	if _, err := session.SyncRPC(message.NewGetConfig(message.DatastoreRunning, "", ""), 5); err != nil {
+		t.Errorf("failed to execute rpc once reconnected: %v", err)
+	}
+	if len(transports) != 2 {
+		t.Errorf("got %d dialed transports, wanted 2", len(transports))
+	}
+}
+
+func TestReconnectSSH(t *testing.T) {
+	address := startSSHServer(t, "127.0.0.1:0", helloHandler)
+
+	session, err := netconf.Connect(address, sshClientConfig())
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer session.Close()
+
+	if err := session.Reconnect(nil); err != nil {
+		t.Fatalf("failed to reconnect: %v", err)
+	}
+	if session.SessionID() != 7 || session.State() != netconf.StateEstablished {
+		t.Errorf("got session-id %d in state %s, wanted 7 established", session.SessionID(), session.State())
+	}
+}
+
+func TestReconnectNotSupported(t *testing.T) {
+	session := newMockSession(t, mock.NewTransport())
+	defer session.Close()
+
+	if err := session.Reconnect(nil); !errors.Is(err, netconf.ErrReconnectNotSupported) {
+		t.Errorf("got error %v, wanted %v", err, netconf.ErrReconnectNotSupported)
+	}
+}
@@ -0,0 +1,84 @@
+package tests
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/openshift-telco/go-netconf-client/netconf"
+	"github.com/openshift-telco/go-netconf-client/netconf/mock"
+)
+
+var identifierRegex = regexp.MustCompile(`<identifier>([^<]*)</identifier>`)
+
+// schemaServer lists two schemas, and returns their content.
+func schemaServer(messageID string, request []byte) []byte {
+	if strings.Contains(string(request), "<get-schema") {
+		switch identifierRegex.FindStringSubmatch(string(request))[1] {
+		case "example":
+			return mock.Reply(messageID, `<data xmlns="urn:ietf:params:xml:ns:yang:ietf-netconf-monitoring">module example { prefix ex; }</data>`)
+		case "example-yin":
+			return mock.Reply(messageID, `<data xmlns="urn:ietf:params:xml:ns:yang:ietf-netconf-monitoring"><module name="example-yin"/></data>`)
+		}
+		return mock.Reply(messageID, `<rpc-error><error-type>application</error-type><error-tag>invalid-value</error-tag>`+
+			`<error-severity>error</error-severity><error-message>unknown schema</error-message></rpc-error>`)
+	}
+	return mock.Reply(messageID, `<data><netconf-state xmlns="urn:ietf:params:xml:ns:yang:ietf-netconf-monitoring"><schemas>`+
+		`<schema><identifier>example</identifier><version>2021-11-01</version><format xmlns:ncm="urn:ietf:params:xml:ns:yang:ietf-netconf-monitoring">ncm:yang</format>`+
+		`<namespace>http://example.com/example</namespace><location>NETCONF</location></schema>`+
+		`<schema><identifier>example-yin</identifier><version></version><format>yin</format>`+
+		`<namespace>http://example.com/example-yin</namespace><location>NETCONF</location></schema>`+
+		`</schemas></netconf-state></data>`)
+}
+
+func TestListSchemas(t *testing.T) {
+	session := newMockSession(t, mock.NewTransport(mock.WithHandler(schemaServer)))
+	defer session.Close()
+
+	schemas, err := session.ListSchemas(context.Background())
+	if err != nil {
+		t.Fatalf("failed to list schemas: %v", err)
+	}
+	want := []netconf.Schema{
+		{Identifier: "example", Version: "2021-11-01", Format: "yang", Namespace: "http://example.com/example", Location: []string{"NETCONF"}},
+		{Identifier: "example-yin", Format: "yin", Namespace: "http://example.com/example-yin", Location: []string{"NETCONF"}},
+	}
+	if !reflect.DeepEqual(schemas, want) {
+		t.Errorf("got schemas %+v, wanted %+v", schemas, want)
+	}
+
+	if _, err := session.GetSchema(context.Background(), "unknown", "", ""); err == nil {
+		t.Errorf("expected an unknown schema to fail")
+	}
+}
+
+func TestDownloadSchemas(t *testing.T) {
+	session := newMockSession(t, mock.NewTransport(mock.WithHandler(schemaServer)))
+	defer session.Close()
+
+	dir := filepath.Join(t.TempDir(), "schemas")
+	paths, err := session.DownloadSchemas(context.Background(), dir)
+	if err != nil {
+		t.Fatalf("failed to download schemas: %v", err)
+	}
+	want := map[string]string{
+		filepath.Join(dir, "example@2021-11-01.yang"): "module example { prefix ex; }",
+		filepath.Join(dir, "example-yin.yin"):         `<module name="example-yin"/>`,
+	}
+	if len(paths) != len(want) {
+		t.Fatalf("got files %v, wanted %d", paths, len(want))
+	}
+	for _, path := range paths {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("failed to read schema: %v", err)
+		}
+		if string(content) != want[path] {
+			t.Errorf("got %s content %q, wanted %q", path, content, want[path])
+		}
+	}
+}
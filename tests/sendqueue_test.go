@@ -0,0 +1,125 @@
+package tests
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/openshift-telco/go-netconf-client/netconf"
+	"github.com/openshift-telco/go-netconf-client/netconf/message"
+	"github.com/openshift-telco/go-netconf-client/netconf/mock"
+)
+
+// gatedTransport holds the RPCs being sent until the gate is opened.
+type gatedTransport struct {
+	*mock.Transport
+	gate chan struct{}
+}
+
+func (t *gatedTransport) Send(data []byte) error {
+	if !bytes.Contains(data, []byte("<hello")) {
+		<-t.gate
+	}
+	return t.Transport.Send(data)
+}
+
+func newGatedSession(t *testing.T, options ...netconf.SessionOption) (*netconf.Session, *gatedTransport) {
+	t.Helper()
+	transport := &gatedTransport{Transport: mock.NewTransport(), gate: make(chan struct{})}
+	session, err := netconf.NewSession(transport, options...)
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+	if err := session.SendHello(&message.Hello{Capabilities: netconf.DefaultCapabilities}); err != nil {
+		t.Fatalf("failed to send hello: %v", err)
+	}
+	return session, transport
+}
+
+func waitForQueueDepth(t *testing.T, session *netconf.Session, depth int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for session.SendQueueDepth() != depth {
+		if time.Now().After(deadline) {
+			t.Fatalf("got queue depth %d, wanted %d", session.SendQueueDepth(), depth)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestSendQueueOrder(t *testing.T) {
+	session, transport := newGatedSession(t)
+	defer session.Close()
+
+	var replied sync.WaitGroup
+	var ids []string
+	for i := 0; i < 10; i++ {
+		rpc := message.NewGetConfig(message.DatastoreRunning, "", "")
+		ids = append(ids, rpc.GetMessageID())
+		replied.Add(1)
+		go func() {
+			err := session.AsyncRPC(rpc, func(event netconf.Event) {
+				replied.Done()
+			})
+			if err != nil {
+				t.Errorf("failed to execute rpc: %v", err)
+				replied.Done()
+			}
+		}()
+		// queue the next RPC once this one is
+		waitForQueueDepth(t, session, i+1)
+	}
+
+	close(transport.gate)
+	replied.Wait()
+	waitForQueueDepth(t, session, 0)
+
+	// the client hello comes first
+	requests := transport.Requests()[1:]
+	if len(requests) != len(ids) {
+		t.Fatalf("got %d requests, wanted %d", len(requests), len(ids))
+	}
+	for i, request := range requests {
+		if !bytes.Contains(request, []byte(`message-id="`+ids[i]+`"`)) {
+			t.Errorf("got request %s at position %d, wanted the RPC %s", request, i, ids[i])
+		}
+	}
+}
+
+func TestWithSendQueueLimit(t *testing.T) {
+	session, transport := newGatedSession(t, netconf.WithSendQueueLimit(2))
+	defer session.Close()
+
+	sent := make(chan error, 3)
+	for i := 0; i < 3; i++ {
+		go func() {
+			sent <- session.AsyncRPC(message.NewGetConfig(message.DatastoreRunning, "", ""), func(event netconf.Event) {})
+		}()
+	}
+	waitForQueueDepth(t, session, 2)
+	select {
+	case err := <-sent:
+		t.Fatalf("expected the RPCs to wait for the queue to have room, got %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	if depth := session.SendQueueDepth(); depth != 2 {
+		t.Errorf("got queue depth %d, wanted the limit 2", depth)
+	}
+
+	close(transport.gate)
+	for i := 0; i < 3; i++ {
+		if err := <-sent; err != nil {
+			t.Errorf("failed to execute rpc: %v", err)
+		}
+	}
+}
+
+func TestWithSendQueueLimitInvalid(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected a negative limit to panic")
+		}
+	}()
+	netconf.WithSendQueueLimit(-1)
+}
@@ -0,0 +1,406 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/openshift-telco/go-netconf-client/netconf"
+	"github.com/openshift-telco/go-netconf-client/netconf/message"
+	"github.com/openshift-telco/go-netconf-client/netconf/mock"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestWithHelloTimeout(t *testing.T) {
+	address := startSSHServer(t, "127.0.0.1:0", func(channel ssh.Channel) {
+		// never send the hello
+		buf := make([]byte, 1024)
+		for {
+			if _, err := channel.Read(buf); err != nil {
+				return
+			}
+		}
+	})
+
+	start := time.Now()
+	_, err := netconf.NewSessionFromSSHConfig(address, sshClientConfig(), netconf.WithHelloTimeout(100*time.Millisecond))
+	if err == nil {
+		t.Errorf("expected the session creation to fail on hello timeout")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("hello timeout not applied, waited %s", elapsed)
+	}
+}
+
+// stuckTransport never completes sending a message, until closed.
+type stuckTransport struct {
+	*mock.Transport
+	closed chan struct{}
+}
+
+func (t *stuckTransport) Send(data []byte) error {
+	<-t.closed
+	return errors.New("transport closed")
+}
+
+func (t *stuckTransport) Close() error {
+	select {
+	case <-t.closed:
+	default:
+		close(t.closed)
+	}
+	return t.Transport.Close()
+}
+
+func TestWithHelloTimeoutSend(t *testing.T) {
+	transport := &stuckTransport{Transport: mock.NewTransport(), closed: make(chan struct{})}
+	session, err := netconf.NewSession(transport, netconf.WithHelloTimeout(100*time.Millisecond))
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+
+	start := time.Now()
+	if err := session.SendHello(&message.Hello{}); err == nil {
+		t.Errorf("expected the hello to fail on timeout")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("hello timeout not applied, waited %s", elapsed)
+	}
+	if state := session.State(); state != netconf.StateFailed {
+		t.Errorf("got state %s, wanted %s", state, netconf.StateFailed)
+	}
+
+	if _, err := netconf.NewSession(mock.NewTransport(), netconf.WithBaseVersion("urn:ietf:params:netconf:base:2.0")); err == nil {
+		t.Errorf("expected an unsupported base capability to fail")
+	}
+}
+
+// silentTransport never receives a message, until closed.
+type silentTransport struct {
+	*mock.Transport
+	closed chan struct{}
+}
+
+func (t *silentTransport) Receive() ([]byte, error) {
+	<-t.closed
+	return nil, io.EOF
+}
+
+func (t *silentTransport) Close() error {
+	select {
+	case <-t.closed:
+	default:
+		close(t.closed)
+	}
+	return nil
+}
+
+func TestHelloTimeoutSides(t *testing.T) {
+	receiving := &silentTransport{Transport: mock.NewTransport(), closed: make(chan struct{})}
+	_, err := netconf.NewSession(receiving,
+		netconf.WithSendHelloTimeout(time.Hour), netconf.WithReceiveHelloTimeout(100*time.Millisecond))
+	var timeoutErr *netconf.HelloTimeoutError
+	if !errors.As(err, &timeoutErr) {
+		t.Fatalf("got error %v, wanted a hello timeout", err)
+	}
+	if timeoutErr.Side != netconf.HelloReceive || timeoutErr.Timeout != 100*time.Millisecond {
+		t.Errorf("got timeout on %s after %s, wanted on receive after 100ms", timeoutErr.Side, timeoutErr.Timeout)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the error %v to match %v", err, context.DeadlineExceeded)
+	}
+
+	sending := &stuckTransport{Transport: mock.NewTransport(), closed: make(chan struct{})}
+	session, err := netconf.NewSession(sending,
+		netconf.WithSendHelloTimeout(100*time.Millisecond), netconf.WithReceiveHelloTimeout(time.Hour))
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+	err = session.SendHello(&message.Hello{})
+	if !errors.As(err, &timeoutErr) {
+		t.Fatalf("got error %v, wanted a hello timeout", err)
+	}
+	if timeoutErr.Side != netconf.HelloSend || timeoutErr.Timeout != 100*time.Millisecond {
+		t.Errorf("got timeout on %s after %s, wanted on send after 100ms", timeoutErr.Side, timeoutErr.Timeout)
+	}
+}
+
+func TestWithCapabilitiesAndDispatcher(t *testing.T) {
+	clientHello := make(chan string, 1)
+	address := startSSHServer(t, "127.0.0.1:0", func(channel ssh.Channel) {
+		_, _ = channel.Write([]byte(serverHello))
+		var received []byte
+		buf := make([]byte, 1024)
+		for {
+			n, err := channel.Read(buf)
+			if err != nil {
+				return
+			}
+			received = append(received, buf[:n]...)
+			if end := bytes.Index(received, []byte("]]>]]>")); end >= 0 {
+				clientHello <- string(received[:end])
+				received = received[end+6:]
+			}
+		}
+	})
+
+	dispatcher := netconf.NewDispatcher()
+	session, err := netconf.NewSessionFromSSHConfig(address, sshClientConfig(),
+		netconf.WithCapabilities(message.NetconfVersion10),
+		netconf.WithDispatcher(dispatcher),
+	)
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+	defer session.Close()
+	if err := session.SendHello(&message.Hello{}); err != nil {
+		t.Fatalf("failed to send hello: %v", err)
+	}
+
+	hello := <-clientHello
+	if !strings.Contains(hello, message.NetconfVersion10) || strings.Contains(hello, message.NetconfVersion11) {
+		t.Errorf("got hello %s, wanted only base:1.0", hello)
+	}
+	if session.Listener != dispatcher {
+		t.Errorf("session does not use the provided dispatcher")
+	}
+}
+
+func TestWithDispatcherShared(t *testing.T) {
+	dispatcher := netconf.NewDispatcher()
+	var submittedA, submittedB atomic.Int32
+	sessionA := newMockSession(t, mock.NewTransport(),
+		netconf.WithDispatcher(dispatcher),
+		netconf.WithCallbackExecutor(netconf.ExecutorFunc(func(task func()) {
+			submittedA.Add(1)
+			go task()
+		})),
+	)
+	sessionB := newMockSession(t, mock.NewTransport(),
+		netconf.WithDispatcher(dispatcher),
+		netconf.WithCallbackExecutor(netconf.ExecutorFunc(func(task func()) {
+			submittedB.Add(1)
+			go task()
+		})),
+	)
+
+	// a callback of the first session is still running while the second one closes
+	started := make(chan struct{})
+	release := make(chan struct{})
+	err := sessionA.AsyncRPC(message.NewGetConfig(message.DatastoreRunning, "", ""), func(event netconf.Event) {
+		close(started)
+		<-release
+	})
+	if err != nil {
+		t.Fatalf("failed to send rpc: %v", err)
+	}
+	<-started
+	defer func() {
+		close(release)
+		_ = sessionA.Close()
+	}()
+
+	if _, err := sessionB.SyncRPC(message.NewGetConfig(message.DatastoreRunning, "", ""), 5); err != nil {
+		t.Fatalf("failed to execute rpc: %v", err)
+	}
+	if a, b := submittedA.Load(), submittedB.Load(); a != 1 || b != 1 {
+		t.Errorf("got %d and %d submitted callbacks, wanted each session to use its own executor", a, b)
+	}
+
+	_ = sessionB.Close()
+	select {
+	case <-sessionB.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatalf("session waited for the callbacks of another session to stop")
+	}
+}
+
+func TestNewSessionInvalidHello(t *testing.T) {
+	address := startSSHServer(t, "127.0.0.1:0", func(channel ssh.Channel) {
+		_, _ = channel.Write([]byte("SSH-2.0-not-netconf\r\n]]>]]>"))
+	})
+
+	_, err := netconf.NewSessionFromSSHConfig(address, sshClientConfig())
+	if err == nil || !strings.Contains(err.Error(), "server hello") {
+		t.Errorf("got %v, wanted a server hello error", err)
+	}
+}
+
+func TestNewSessionNonCompliantHello(t *testing.T) {
+	for name, hello := range map[string]string{
+		"missing session-id": `<hello xmlns="urn:ietf:params:xml:ns:netconf:base:1.0"><capabilities>` +
+			`<capability>urn:ietf:params:netconf:base:1.1</capability></capabilities></hello>`,
+		"no supported base capability": `<hello xmlns="urn:ietf:params:xml:ns:netconf:base:1.0"><capabilities>` +
+			`<capability>urn:ietf:params:netconf:capability:candidate:1.0</capability></capabilities>` +
+			`<session-id>3</session-id></hello>`,
+	} {
+		transport := mock.NewTransport(mock.WithHello(hello))
+		_, err := netconf.NewSession(transport)
+		var helloErr *netconf.InvalidHelloError
+		if !errors.As(err, &helloErr) || !strings.Contains(helloErr.Reason, name) {
+			t.Errorf("%s: got error %v, wanted an InvalidHelloError", name, err)
+			continue
+		}
+		if err := transport.Send([]byte("<rpc/>")); err == nil {
+			t.Errorf("%s: expected the transport to be closed", name)
+		}
+	}
+}
+
+func TestNewSessionSessionID(t *testing.T) {
+	hello := func(sessionID string) string {
+		return `<hello xmlns="urn:ietf:params:xml:ns:netconf:base:1.0"><capabilities>` +
+			`<capability>urn:ietf:params:netconf:base:1.1</capability></capabilities>` +
+			`<session-id>` + sessionID + `</session-id></hello>`
+	}
+	for _, sessionID := range []string{"0", "-1", "4294967296", "abc"} {
+		if _, err := netconf.NewSession(mock.NewTransport(mock.WithHello(hello(sessionID)))); err == nil {
+			t.Errorf("expected session-id %s to be rejected", sessionID)
+		}
+	}
+
+	session, err := netconf.NewSession(mock.NewTransport(mock.WithHello(hello("4294967295"))))
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+	defer session.Close()
+	if got := session.SessionID(); got != 4294967295 {
+		t.Errorf("got session-id %d, wanted 4294967295", got)
+	}
+}
+
+func TestHelloGetters(t *testing.T) {
+	transport := mock.NewTransport()
+	session, err := netconf.NewSession(transport)
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+	defer session.Close()
+	if got := string(session.ServerHello()); got != mock.DefaultHello {
+		t.Errorf("got server hello %s, wanted %s", got, mock.DefaultHello)
+	}
+	if session.ClientHello() != nil || session.FramingVersion() != "" {
+		t.Errorf("expected no client hello nor framing before SendHello")
+	}
+
+	if err := session.SendHello(&message.Hello{Capabilities: netconf.DefaultCapabilities}); err != nil {
+		t.Fatalf("failed to send hello: %v", err)
+	}
+	if got := session.ClientHello(); !bytes.Equal(got, transport.Requests()[0]) {
+		t.Errorf("got client hello %s, wanted the sent one %s", got, transport.Requests()[0])
+	}
+	if got := session.FramingVersion(); got != netconf.FramingVersion11 {
+		t.Errorf("got framing version %q, wanted %q", got, netconf.FramingVersion11)
+	}
+}
+
+func TestWithFramingVersion(t *testing.T) {
+	hello10 := `<hello xmlns="urn:ietf:params:xml:ns:netconf:base:1.0"><capabilities>` +
+		`<capability>urn:ietf:params:netconf:base:1.0</capability></capabilities><session-id>1</session-id></hello>`
+	for name, test := range map[string]struct {
+		hello   string
+		options []netconf.SessionOption
+		version string
+		base11  bool
+	}{
+		"negotiated": {hello: mock.DefaultHello, version: netconf.FramingVersion11, base11: true},
+		"forced 1.0": {hello: mock.DefaultHello, options: []netconf.SessionOption{netconf.WithFramingVersion(netconf.FramingVersion10)}, version: netconf.FramingVersion10},
+		"forced 1.1": {hello: hello10, options: []netconf.SessionOption{netconf.WithFramingVersion(netconf.FramingVersion11)}, version: netconf.FramingVersion11, base11: true},
+		"server 1.0": {hello: hello10, version: netconf.FramingVersion10, base11: true},
+	} {
+		transport := mock.NewTransport(mock.WithHello(test.hello))
+		session := newMockSession(t, transport, test.options...)
+		if got := session.FramingVersion(); got != test.version {
+			t.Errorf("%s: got framing version %q, wanted %q", name, got, test.version)
+		}
+		clientHello := string(transport.Requests()[0])
+		if got := strings.Contains(clientHello, message.NetconfVersion11); got != test.base11 {
+			t.Errorf("%s: got client hello %s, wanted base:1.1 advertised: %v", name, clientHello, test.base11)
+		}
+		_ = session.Close()
+	}
+
+	if _, err := netconf.NewSession(mock.NewTransport(), netconf.WithFramingVersion("v2")); err == nil {
+		t.Errorf("expected an unsupported framing version to fail")
+	}
+}
+
+func TestWithBaseVersion(t *testing.T) {
+	for name, test := range map[string]struct {
+		base    string
+		version string
+	}{
+		"base 1.0": {base: message.NetconfVersion10, version: netconf.FramingVersion10},
+		"base 1.1": {base: message.NetconfVersion11, version: netconf.FramingVersion11},
+	} {
+		transport := mock.NewTransport()
+		session := newMockSession(t, transport, netconf.WithBaseVersion(test.base))
+		if got := session.FramingVersion(); got != test.version {
+			t.Errorf("%s: got framing version %q, wanted %q", name, got, test.version)
+		}
+		clientHello := string(transport.Requests()[0])
+		if strings.Count(clientHello, "urn:ietf:params:netconf:base:") != 1 || !strings.Contains(clientHello, test.base) {
+			t.Errorf("%s: got client hello %s, wanted %s advertised only", name, clientHello, test.base)
+		}
+		_ = session.Close()
+	}
+
+	hello10 := `<hello xmlns="urn:ietf:params:xml:ns:netconf:base:1.0"><capabilities>` +
+		`<capability>urn:ietf:params:netconf:base:1.0</capability></capabilities><session-id>1</session-id></hello>`
+	session, err := netconf.NewSession(mock.NewTransport(mock.WithHello(hello10)), netconf.WithBaseVersion(message.NetconfVersion11))
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+	if err := session.SendHello(&message.Hello{}); err == nil {
+		t.Errorf("expected the hello to fail without a common base version")
+	}
+	if state := session.State(); state != netconf.StateFailed {
+		t.Errorf("got state %s, wanted %s", state, netconf.StateFailed)
+	}
+
+	if _, err := netconf.NewSession(mock.NewTransport(), netconf.WithBaseVersion("urn:ietf:params:netconf:base:2.0")); err == nil {
+		t.Errorf("expected an unsupported base capability to fail")
+	}
+}
+
+func TestWithMessageIDGenerator(t *testing.T) {
+	transport := mock.NewTransport()
+	session := newMockSession(t, transport, netconf.WithMessageIDGenerator(message.NewPrefixMessageID("s1-", message.NewCounterMessageID(1))))
+
+	get := message.NewGet("", "")
+	reply, err := session.SyncRPC(get, 1)
+	if err != nil {
+		t.Fatalf("failed to execute get: %v", err)
+	}
+	if get.GetMessageID() != "s1-1" || reply.MessageID != "s1-1" {
+		t.Errorf("got message-id %s and reply message-id %s, wanted s1-1", get.GetMessageID(), reply.MessageID)
+	}
+
+	done := make(chan string, 1)
+	if err := session.AsyncRPC(message.NewLock(message.DatastoreRunning), func(event netconf.Event) {
+		done <- event.RPCReply().MessageID
+	}); err != nil {
+		t.Fatalf("failed to send lock: %v", err)
+	}
+	select {
+	case id := <-done:
+		if id != "s1-2" {
+			t.Errorf("got reply message-id %s, wanted s1-2", id)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("no reply received")
+	}
+
+	if err := session.CloseGracefully(1); err != nil {
+		t.Fatalf("failed to close session: %v", err)
+	}
+	requests := transport.Requests()
+	if last := string(requests[len(requests)-1]); !strings.Contains(last, `message-id="s1-3"`) {
+		t.Errorf("got close-session %s, wanted message-id s1-3", last)
+	}
+}
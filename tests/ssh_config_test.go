@@ -0,0 +1,92 @@
+package tests
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"net"
+	"testing"
+
+	"github.com/openshift-telco/go-netconf-client/netconf"
+	"golang.org/x/crypto/ssh"
+)
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+func TestSSHConfigPasswordWithLegacyAlgorithms(t *testing.T) {
+	config := netconf.SSHConfigPassword("admin", "admin", netconf.SSHLegacyAlgorithms())
+
+	if config.User != "admin" || len(config.Auth) != 1 {
+		t.Errorf("unexpected credentials in config: %+v", config)
+	}
+	for _, cipher := range []string{"aes128-ctr", "aes128-cbc", "3des-cbc"} {
+		if !contains(config.Ciphers, cipher) {
+			t.Errorf("cipher %s missing from %v", cipher, config.Ciphers)
+		}
+	}
+	for _, kex := range []string{"curve25519-sha256@libssh.org", "diffie-hellman-group1-sha1"} {
+		if !contains(config.KeyExchanges, kex) {
+			t.Errorf("key exchange %s missing from %v", kex, config.KeyExchanges)
+		}
+	}
+	if !contains(config.HostKeyAlgorithms, "ssh-rsa") {
+		t.Errorf("host key algorithm ssh-rsa missing from %v", config.HostKeyAlgorithms)
+	}
+	// the legacy host key algorithms come after the default ones
+	for _, algorithm := range []string{"rsa-sha2-256", "rsa-sha2-512", "rsa-sha2-512-cert-v01@openssh.com", ssh.CertAlgoED25519v01, ssh.KeyAlgoED25519} {
+		if !contains(config.HostKeyAlgorithms[:len(config.HostKeyAlgorithms)-4], algorithm) {
+			t.Errorf("host key algorithm %s missing before the legacy ones in %v", algorithm, config.HostKeyAlgorithms)
+		}
+	}
+}
+
+func TestSSHLegacyAlgorithmsRSAHostKey(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate host key: %v", err)
+	}
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		t.Fatalf("failed to create signer: %v", err)
+	}
+	address := startSSHServerWithOptions(t, "127.0.0.1:0", helloHandler, sshServerOptions{hostKey: signer})
+
+	var hostKeyType string
+	config := netconf.SSHConfigPassword("admin", "admin",
+		netconf.SSHLegacyAlgorithms(),
+		netconf.SSHHostKeyCallback(func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+			hostKeyType = key.Type()
+			return nil
+		}),
+	)
+	session, err := netconf.NewSessionFromSSHConfig(address, config)
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+	defer session.Close()
+
+	if hostKeyType != ssh.KeyAlgoRSA {
+		t.Errorf("got host key type %s, wanted %s", hostKeyType, ssh.KeyAlgoRSA)
+	}
+}
+
+func TestSSHConfigOptionsOverrideAlgorithms(t *testing.T) {
+	config := netconf.SSHConfigPassword("admin", "admin",
+		netconf.SSHCiphers("aes256-ctr"),
+		netconf.SSHKeyExchanges("ecdh-sha2-nistp256"),
+		netconf.SSHLegacyAlgorithms(),
+	)
+
+	if len(config.Ciphers) != 3 || config.Ciphers[0] != "aes256-ctr" {
+		t.Errorf("got ciphers %v, wanted aes256-ctr followed by legacy ciphers", config.Ciphers)
+	}
+	if config.KeyExchanges[0] != "ecdh-sha2-nistp256" {
+		t.Errorf("got key exchanges %v, wanted ecdh-sha2-nistp256 first", config.KeyExchanges)
+	}
+}
@@ -0,0 +1,120 @@
+package tests
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"net"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+const serverHello = "<hello xmlns=\"urn:ietf:params:xml:ns:netconf:base:1.0\"><capabilities><capability>urn:ietf:params:netconf:base:1.0</capability></capabilities><session-id>7</session-id></hello>]]>]]>"
+
+// sshServerOptions customizes the test SSH server, the zero value using an ed25519 host key and discarding the
+// global requests.
+type sshServerOptions struct {
+	hostKey        ssh.Signer
+	globalRequests func(requests <-chan *ssh.Request)
+}
+
+// startSSHServer starts an SSH server accepting the admin/admin credentials on the provided address, and
+// calling handler for every netconf subsystem channel. It returns the address the server listens on.
+func startSSHServer(t *testing.T, address string, handler func(channel ssh.Channel)) string {
+	t.Helper()
+	return startSSHServerWithOptions(t, address, handler, sshServerOptions{})
+}
+
+// startSSHServerWithOptions is startSSHServer with a customized server.
+func startSSHServerWithOptions(t *testing.T, address string, handler func(channel ssh.Channel), options sshServerOptions) string {
+	t.Helper()
+
+	signer := options.hostKey
+	if signer == nil {
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatalf("failed to generate host key: %v", err)
+		}
+		if signer, err = ssh.NewSignerFromKey(key); err != nil {
+			t.Fatalf("failed to create signer: %v", err)
+		}
+	}
+	if options.globalRequests == nil {
+		options.globalRequests = ssh.DiscardRequests
+	}
+	config := &ssh.ServerConfig{
+		PasswordCallback: func(conn ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+			if conn.User() == "admin" && string(password) == "admin" {
+				return nil, nil
+			}
+			return nil, ssh.ErrNoAuth
+		},
+	}
+	config.AddHostKey(signer)
+
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveSSH(conn, config, handler, options.globalRequests)
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func serveSSH(conn net.Conn, config *ssh.ServerConfig, handler func(channel ssh.Channel), globalRequests func(<-chan *ssh.Request)) {
+	_, channels, requests, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		_ = conn.Close()
+		return
+	}
+	go globalRequests(requests)
+
+	for newChannel := range channels {
+		if newChannel.ChannelType() != "session" {
+			_ = newChannel.Reject(ssh.UnknownChannelType, "unsupported channel type")
+			continue
+		}
+		channel, channelRequests, err := newChannel.Accept()
+		if err != nil {
+			continue
+		}
+		go func() {
+			for request := range channelRequests {
+				isNetconf := request.Type == "subsystem" && string(request.Payload[4:]) == "netconf"
+				_ = request.Reply(isNetconf, nil)
+				if isNetconf {
+					go handler(channel)
+				}
+			}
+		}()
+	}
+}
+
+// sshClientConfig returns a client configuration matching the test server.
+func sshClientConfig() *ssh.ClientConfig {
+	return &ssh.ClientConfig{
+		User:            "admin",
+		Auth:            []ssh.AuthMethod{ssh.Password("admin")},
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+	}
+}
+
+// helloHandler sends the server hello, then discards everything it receives.
+func helloHandler(channel ssh.Channel) {
+	_, _ = channel.Write([]byte(serverHello))
+	buf := make([]byte, 1024)
+	for {
+		if _, err := channel.Read(buf); err != nil {
+			return
+		}
+	}
+}
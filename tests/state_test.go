@@ -0,0 +1,78 @@
+package tests
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/openshift-telco/go-netconf-client/netconf"
+	"github.com/openshift-telco/go-netconf-client/netconf/mock"
+)
+
+// stateRecorder records the state transitions of a session.
+type stateRecorder struct {
+	lock        sync.Mutex
+	transitions []string
+}
+
+func (r *stateRecorder) hook(session *netconf.Session, from netconf.SessionState, to netconf.SessionState) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.transitions = append(r.transitions, from.String()+">"+to.String())
+}
+
+// wait returns the recorded transitions once there are as many as wanted, or after a timeout.
+func (r *stateRecorder) wait(count int) []string {
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		r.lock.Lock()
+		if len(r.transitions) >= count {
+			r.lock.Unlock()
+			break
+		}
+		r.lock.Unlock()
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return append([]string(nil), r.transitions...)
+}
+
+func TestSessionStateClose(t *testing.T) {
+	recorder := &stateRecorder{}
+	session := newMockSession(t, mock.NewTransport(), netconf.WithStateChangeHook(recorder.hook))
+	if state := session.State(); state != netconf.StateEstablished {
+		t.Errorf("got state %s once established, wanted %s", state, netconf.StateEstablished)
+	}
+
+	_ = session.Close()
+	want := []string{"connecting>established", "established>closed"}
+	if got := recorder.wait(len(want)); !reflect.DeepEqual(got, want) {
+		t.Errorf("got transitions %v, wanted %v", got, want)
+	}
+}
+
+func TestSessionStateCloseGracefully(t *testing.T) {
+	recorder := &stateRecorder{}
+	session := newMockSession(t, mock.NewTransport())
+	session.OnStateChange(recorder.hook)
+
+	if err := session.CloseGracefully(5); err != nil {
+		t.Fatalf("failed to close session: %v", err)
+	}
+	want := []string{"established>draining", "draining>closed"}
+	if got := recorder.wait(len(want)); !reflect.DeepEqual(got, want) {
+		t.Errorf("got transitions %v, wanted %v", got, want)
+	}
+}
+
+func TestSessionStateFailed(t *testing.T) {
+	recorder := &stateRecorder{}
+	_, err := netconf.NewSession(mock.NewTransport(mock.WithHello("<hello")), netconf.WithStateChangeHook(recorder.hook))
+	if err == nil {
+		t.Fatalf("expected an invalid hello to fail the session")
+	}
+	want := []string{"connecting>failed"}
+	if got := recorder.wait(len(want)); !reflect.DeepEqual(got, want) {
+		t.Errorf("got transitions %v, wanted %v", got, want)
+	}
+}
@@ -0,0 +1,86 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openshift-telco/go-netconf-client/netconf"
+	"github.com/openshift-telco/go-netconf-client/netconf/message"
+	"github.com/openshift-telco/go-netconf-client/netconf/mock"
+)
+
+func TestSessionStats(t *testing.T) {
+	transport := mock.NewTransport(mock.WithHandler(func(messageID string, request []byte) []byte {
+		switch {
+		case strings.Contains(string(request), "<lock>"):
+			return mock.Reply(messageID, "<rpc-error><error-type>protocol</error-type><error-tag>lock-denied</error-tag>"+
+				"<error-severity>error</error-severity></rpc-error>")
+		case strings.Contains(string(request), "<commit>"):
+			return nil
+		}
+		return mock.Reply(messageID, "<ok/>")
+	}))
+	session := newMockSession(t, transport, netconf.WithRPCTimeout(200*time.Millisecond))
+	defer session.Close()
+
+	for i := 0; i < 3; i++ {
+		if _, err := session.SyncRPC(message.NewGetConfig(message.DatastoreRunning, "", ""), 5); err != nil {
+			t.Fatalf("failed to execute rpc: %v", err)
+		}
+	}
+	if _, err := session.SyncRPC(message.NewLock(message.DatastoreCandidate), 5); err != nil {
+		t.Fatalf("failed to execute rpc: %v", err)
+	}
+	if _, err := session.SyncRPC(message.NewCommit(), 0); err == nil {
+		t.Fatalf("expected the commit to time out")
+	}
+	notified := make(chan struct{})
+	session.Listener.Register(message.NetconfNotificationStreamHandler, func(event netconf.Event) {
+		close(notified)
+	})
+	transport.Notify("<notification xmlns=\"urn:ietf:params:xml:ns:netconf:notification:1.0\">" +
+		"<eventTime>2021-11-01T10:00:00Z</eventTime><event/></notification>")
+	<-notified
+
+	stats := session.Stats()
+	if stats.RPCsSent != 5 || stats.RepliesReceived != 4 || stats.RPCErrors != 1 || stats.RPCFailures != 1 {
+		t.Errorf("got sent %d, replied %d, errors %d, failures %d, wanted 5, 4, 1, 1",
+			stats.RPCsSent, stats.RepliesReceived, stats.RPCErrors, stats.RPCFailures)
+	}
+	if stats.NotificationsReceived != 1 {
+		t.Errorf("got %d notifications, wanted 1", stats.NotificationsReceived)
+	}
+	getConfig := stats.Operations["get-config"]
+	if getConfig.Sent != 3 || getConfig.Replied != 3 || getConfig.Errors != 0 {
+		t.Errorf("got get-config stats %+v", getConfig)
+	}
+	if getConfig.MaxLatency < getConfig.AverageLatency() || getConfig.TotalLatency < getConfig.MaxLatency {
+		t.Errorf("inconsistent get-config latencies %+v", getConfig)
+	}
+	if lock := stats.Operations["lock"]; lock.Replied != 1 || lock.Errors != 1 {
+		t.Errorf("got lock stats %+v", lock)
+	}
+	if commit := stats.Operations["commit"]; commit.Sent != 1 || commit.Failures != 1 || commit.Replied != 0 {
+		t.Errorf("got commit stats %+v", commit)
+	}
+}
+
+func TestSessionStatsAsyncRPC(t *testing.T) {
+	session := newMockSession(t, mock.NewTransport(mock.WithHandler(mock.NoReply)),
+		netconf.WithRPCTimeout(100*time.Millisecond))
+	defer session.Close()
+
+	done := make(chan struct{})
+	err := session.AsyncRPC(message.NewGetConfig(message.DatastoreRunning, "", ""), func(event netconf.Event) {
+		close(done)
+	})
+	if err != nil {
+		t.Fatalf("failed to execute rpc: %v", err)
+	}
+	<-done
+
+	if stats := session.Stats().Operations["get-config"]; stats.Sent != 1 || stats.Failures != 1 {
+		t.Errorf("got get-config stats %+v, wanted one sent RPC timing out", stats)
+	}
+}
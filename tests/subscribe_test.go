@@ -0,0 +1,104 @@
+package tests
+
+import (
+	"encoding/xml"
+	"testing"
+	"time"
+
+	"github.com/openshift-telco/go-netconf-client/netconf"
+	"github.com/openshift-telco/go-netconf-client/netconf/message"
+)
+
+type configChange struct {
+	XMLName   xml.Name `xml:"notification"`
+	EventTime string   `xml:"eventTime"`
+	User      string   `xml:"netconf-config-change>changed-by>username"`
+}
+
+func TestSubscribeChan(t *testing.T) {
+	transport := newEchoTransport()
+	session, err := netconf.NewSession(transport)
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+	defer session.Close()
+	if err := session.SendHello(&message.Hello{Capabilities: netconf.DefaultCapabilities}); err != nil {
+		t.Fatalf("failed to send hello: %v", err)
+	}
+
+	changes, err := netconf.SubscribeChan(session, "NETCONF", netconf.XMLNotificationDecoder[configChange]())
+	if err != nil {
+		t.Fatalf("failed to subscribe: %v", err)
+	}
+
+	for _, user := range []string{"alice", "bob"} {
+		transport.received <- []byte("<notification xmlns=\"urn:ietf:params:xml:ns:netconf:notification:1.0\">" +
+			"<eventTime>2021-11-01T10:00:00Z</eventTime><netconf-config-change xmlns=\"urn:ietf:params:xml:ns:yang:ietf-netconf-notifications\">" +
+			"<changed-by><username>" + user + "</username></changed-by></netconf-config-change></notification>")
+	}
+	transport.received <- []byte("<notification xmlns=\"urn:ietf:params:xml:ns:netconf:notification:1.0\">" +
+		"<eventTime>2021-11-01T10:00:01Z</eventTime><notificationComplete/></notification>")
+
+	var users []string
+	timeout := time.After(5 * time.Second)
+	for done := false; !done; {
+		select {
+		case change, ok := <-changes:
+			if !ok {
+				done = true
+				break
+			}
+			users = append(users, change.User)
+		case <-timeout:
+			t.Fatalf("channel not closed upon notificationComplete")
+		}
+	}
+	if len(users) != 2 || users[0] != "alice" || users[1] != "bob" {
+		t.Errorf("got users %v, wanted [alice bob]", users)
+	}
+}
+
+func TestSubscribeChanAgainAfterComplete(t *testing.T) {
+	transport := newEchoTransport()
+	session, err := netconf.NewSession(transport)
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+	defer session.Close()
+	if err := session.SendHello(&message.Hello{Capabilities: netconf.DefaultCapabilities}); err != nil {
+		t.Fatalf("failed to send hello: %v", err)
+	}
+
+	for _, user := range []string{"alice", "bob"} {
+		changes, err := netconf.SubscribeChan(session, "NETCONF", netconf.XMLNotificationDecoder[configChange]())
+		if err != nil {
+			t.Fatalf("failed to subscribe for %s: %v", user, err)
+		}
+		transport.received <- []byte("<notification xmlns=\"urn:ietf:params:xml:ns:netconf:notification:1.0\">" +
+			"<eventTime>2021-11-01T10:00:00Z</eventTime><netconf-config-change xmlns=\"urn:ietf:params:xml:ns:yang:ietf-netconf-notifications\">" +
+			"<changed-by><username>" + user + "</username></changed-by></netconf-config-change></notification>")
+		transport.received <- []byte("<notification xmlns=\"urn:ietf:params:xml:ns:netconf:notification:1.0\">" +
+			"<eventTime>2021-11-01T10:00:01Z</eventTime><notificationComplete/></notification>")
+
+		var users []string
+		timeout := time.After(5 * time.Second)
+		for done := false; !done; {
+			select {
+			case change, ok := <-changes:
+				if !ok {
+					done = true
+					break
+				}
+				users = append(users, change.User)
+			case <-timeout:
+				t.Fatalf("channel not closed upon notificationComplete")
+			}
+		}
+		if len(users) != 1 || users[0] != user {
+			t.Errorf("got users %v, wanted [%s]", users, user)
+		}
+		if session.NotificationStreamCreated() {
+			t.Errorf("notification stream still marked as created after notificationComplete")
+		}
+	}
+}
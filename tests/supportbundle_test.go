@@ -0,0 +1,85 @@
+package tests
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/openshift-telco/go-netconf-client/netconf"
+	"github.com/openshift-telco/go-netconf-client/netconf/message"
+	"golang.org/x/crypto/ssh"
+)
+
+var messageIDRegex = regexp.MustCompile(`message-id="([^"]+)"`)
+
+// errorHandler sends the server hello, then replies to every RPC with an rpc-error.
+func errorHandler(channel ssh.Channel) {
+	_, _ = channel.Write([]byte(serverHello))
+	var pending []byte
+	buf := make([]byte, 1024)
+	for {
+		n, err := channel.Read(buf)
+		if err != nil {
+			return
+		}
+		pending = append(pending, buf[:n]...)
+		for {
+			end := bytes.Index(pending, []byte("]]>]]>"))
+			if end < 0 {
+				break
+			}
+			msg := pending[:end]
+			pending = pending[end+6:]
+			if match := messageIDRegex.FindSubmatch(msg); match != nil {
+				_, _ = fmt.Fprintf(channel, "<rpc-reply xmlns=\"urn:ietf:params:xml:ns:netconf:base:1.0\" message-id=\"%s\">"+
+					"<rpc-error><error-type>application</error-type><error-tag>invalid-value</error-tag>"+
+					"<error-severity>error</error-severity><error-message>bad value</error-message></rpc-error>"+
+					"</rpc-reply>]]>]]>", match[1])
+			}
+		}
+	}
+}
+
+func TestSupportBundles(t *testing.T) {
+	address := startSSHServer(t, "127.0.0.1:0", errorHandler)
+
+	session, err := netconf.NewSessionFromSSHConfig(address, sshClientConfig(), netconf.WithSupportBundles(1))
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+	defer session.Close()
+	if err := session.SendHello(&message.Hello{Capabilities: netconf.DefaultCapabilities}); err != nil {
+		t.Fatalf("failed to send hello: %v", err)
+	}
+
+	first := message.NewGetConfig(message.DatastoreRunning, "", "")
+	if _, err := session.SyncRPC(first, 5); err != nil {
+		t.Fatalf("failed to execute rpc: %v", err)
+	}
+	second := message.NewGetConfig(message.DatastoreCandidate, "", "")
+	if _, err := session.SyncRPC(second, 5); err != nil {
+		t.Fatalf("failed to execute rpc: %v", err)
+	}
+
+	bundles := session.SupportBundles()
+	if len(bundles) != 1 {
+		t.Fatalf("got %d bundles, wanted 1", len(bundles))
+	}
+	if _, ok := session.SupportBundle(first.GetMessageID()); ok {
+		t.Errorf("expected the oldest bundle to be evicted")
+	}
+	bundle, ok := session.SupportBundle(second.GetMessageID())
+	if !ok {
+		t.Fatalf("missing bundle for %s", second.GetMessageID())
+	}
+	if bundle.Operation != "get-config" {
+		t.Errorf("got operation %q, wanted get-config", bundle.Operation)
+	}
+	if len(bundle.Errors) != 1 || bundle.Errors[0].Tag != "invalid-value" {
+		t.Errorf("got errors %v, wanted a single invalid-value", bundle.Errors)
+	}
+	if bundle.Session.SessionID != 7 || bundle.RawReply == "" || bundle.Request == "" {
+		t.Errorf("incomplete bundle: %+v", bundle)
+	}
+}
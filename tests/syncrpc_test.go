@@ -0,0 +1,77 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/openshift-telco/go-netconf-client/netconf"
+	"github.com/openshift-telco/go-netconf-client/netconf/message"
+	"github.com/openshift-telco/go-netconf-client/netconf/mock"
+)
+
+func TestSyncRPCContextDeadline(t *testing.T) {
+	address := startSSHServer(t, "127.0.0.1:0", helloHandler)
+
+	session, err := netconf.NewSessionFromSSHConfig(address, sshClientConfig(), netconf.WithRPCTimeout(50*time.Millisecond))
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+	defer session.Close()
+	if err := session.SendHello(&message.Hello{Capabilities: netconf.DefaultCapabilities}); err != nil {
+		t.Fatalf("failed to send hello: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = session.SyncRPCContext(ctx, message.NewGetConfig(message.DatastoreRunning, "", ""))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v, wanted context.DeadlineExceeded", err)
+	}
+
+	// the server never replies, so the default timeout applies
+	start := time.Now()
+	_, err = session.SyncRPC(message.NewGetConfig(message.DatastoreRunning, "", ""), 0)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v, wanted a timeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("default timeout not applied, waited %s", elapsed)
+	}
+}
+
+func TestAsyncRPCTimeout(t *testing.T) {
+	session := newMockSession(t, mock.NewTransport(mock.WithHandler(mock.NoReply)), netconf.WithRPCTimeout(200*time.Millisecond))
+	defer session.Close()
+
+	for name, send := range map[string]func(netconf.Callback) error{
+		"default": func(callback netconf.Callback) error {
+			return session.AsyncRPC(message.NewGetConfig(message.DatastoreRunning, "", ""), callback)
+		},
+		"override": func(callback netconf.Callback) error {
+			return session.AsyncRPCTimeout(message.NewGetConfig(message.DatastoreRunning, "", ""), callback, 1)
+		},
+	} {
+		events := make(chan netconf.Event, 1)
+		start := time.Now()
+		if err := send(func(event netconf.Event) { events <- event }); err != nil {
+			t.Fatalf("%s: failed to execute rpc: %v", name, err)
+		}
+		select {
+		case event := <-events:
+			var timeoutErr *netconf.RPCTimeoutError
+			if !errors.As(netconf.EventErr(event), &timeoutErr) || !errors.Is(netconf.EventErr(event), context.DeadlineExceeded) {
+				t.Errorf("%s: got event error %v, wanted an RPCTimeoutError", name, netconf.EventErr(event))
+			}
+			if name == "override" && time.Since(start) < time.Second {
+				t.Errorf("%s: callback invoked after %v, before the timeout", name, time.Since(start))
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%s: callback not invoked upon timeout", name)
+		}
+	}
+	if stats := session.Listener.CorrelationStats(); stats.Pending != 0 || stats.TimedOut != 2 {
+		t.Errorf("got %d pending and %d timed out callbacks, wanted 0 and 2", stats.Pending, stats.TimedOut)
+	}
+}
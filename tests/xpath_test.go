@@ -0,0 +1,51 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/openshift-telco/go-netconf-client/netconf"
+	"github.com/openshift-telco/go-netconf-client/netconf/message"
+	"github.com/openshift-telco/go-netconf-client/netconf/mock"
+)
+
+const xpathHello = `<hello xmlns="urn:ietf:params:xml:ns:netconf:base:1.0"><capabilities>` +
+	`<capability>urn:ietf:params:netconf:base:1.1</capability>` +
+	`<capability>urn:ietf:params:netconf:capability:xpath:1.0</capability>` +
+	`</capabilities><session-id>1</session-id></hello>`
+
+func TestGetXPath(t *testing.T) {
+	transport := mock.NewTransport(mock.WithHello(xpathHello), mock.WithHandler(mock.ReplyData("<top><users/></top>")))
+	session := newMockSession(t, transport)
+	defer session.Close()
+
+	reply, err := session.GetConfigXPath(context.Background(), message.DatastoreRunning, "/t:top/t:users", map[string]string{"t": "urn:example"})
+	if err != nil {
+		t.Fatalf("failed to get config: %v", err)
+	}
+	if !strings.Contains(reply.Data, "<users/>") {
+		t.Errorf("got data %s, wanted the users", reply.Data)
+	}
+	requests := transport.Requests()
+	if request := string(requests[len(requests)-1]); !strings.Contains(request, `<filter type="xpath" select="/t:top/t:users" xmlns:t="urn:example">`) {
+		t.Errorf("got request %s, wanted a xpath filter", request)
+	}
+
+	if _, err := session.GetXPath(context.Background(), "/t:top", map[string]string{"t": "urn:example"}); err != nil {
+		t.Errorf("failed to get: %v", err)
+	}
+}
+
+func TestGetXPathNotSupported(t *testing.T) {
+	session := newMockSession(t, mock.NewTransport())
+	defer session.Close()
+
+	if _, err := session.GetXPath(context.Background(), "/top", nil); !errors.Is(err, netconf.ErrXPathNotSupported) {
+		t.Errorf("got error %v, wanted %v", err, netconf.ErrXPathNotSupported)
+	}
+	if _, err := session.GetConfigXPath(context.Background(), message.DatastoreRunning, "/top", nil); !errors.Is(err, netconf.ErrXPathNotSupported) {
+		t.Errorf("got error %v, wanted %v", err, netconf.ErrXPathNotSupported)
+	}
+}
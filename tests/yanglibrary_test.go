@@ -0,0 +1,75 @@
+package tests
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/openshift-telco/go-netconf-client/netconf"
+	"github.com/openshift-telco/go-netconf-client/netconf/mock"
+)
+
+const yangLibraryData = `<yang-library xmlns="urn:ietf:params:xml:ns:yang:ietf-yang-library" xmlns:ds="urn:ietf:params:xml:ns:yang:ietf-datastores">` +
+	`<module-set><name>config-modules</name>` +
+	`<module><name>ietf-interfaces</name><revision>2018-02-20</revision><namespace>urn:ietf:params:xml:ns:yang:ietf-interfaces</namespace>` +
+	`<feature>arbitrary-names</feature><deviation>example-deviations</deviation></module>` +
+	`<import-only-module><name>ietf-yang-types</name><revision>2013-07-15</revision><namespace>urn:ietf:params:xml:ns:yang:ietf-yang-types</namespace></import-only-module>` +
+	`</module-set>` +
+	`<schema><name>config-schema</name><module-set>config-modules</module-set></schema>` +
+	`<datastore><name>ds:running</name><schema>config-schema</schema></datastore>` +
+	`<content-id>42</content-id>` +
+	`</yang-library>` +
+	`<modules-state xmlns="urn:ietf:params:xml:ns:yang:ietf-yang-library"><module-set-id>42</module-set-id>` +
+	`<module><name>ietf-ip</name><revision>2018-02-22</revision><namespace>urn:ietf:params:xml:ns:yang:ietf-ip</namespace>` +
+	`<deviation><name>example-deviations</name><revision>2021-11-01</revision></deviation><conformance-type>implement</conformance-type></module>` +
+	`</modules-state>`
+
+func TestYangLibrary(t *testing.T) {
+	transport := mock.NewTransport(mock.WithHandler(mock.ReplyData(yangLibraryData)))
+	session := newMockSession(t, transport)
+	defer session.Close()
+
+	library, err := session.YangLibrary(context.Background())
+	if err != nil {
+		t.Fatalf("failed to retrieve yang library: %v", err)
+	}
+	requests := transport.Requests()
+	if got := string(requests[len(requests)-1]); !strings.Contains(got, `<yang-library xmlns="urn:ietf:params:xml:ns:yang:ietf-yang-library"/>`) {
+		t.Errorf("got request %s, wanted the yang library to be selected", got)
+	}
+
+	want := &netconf.YangLibrary{
+		ContentID: "42",
+		ModuleSets: []netconf.YangModuleSet{{
+			Name: "config-modules",
+			Modules: []netconf.YangModule{{
+				Name: "ietf-interfaces", Revision: "2018-02-20", Namespace: "urn:ietf:params:xml:ns:yang:ietf-interfaces",
+				Features: []string{"arbitrary-names"}, Deviations: []netconf.YangDeviation{{Name: "example-deviations"}},
+			}},
+			ImportOnlyModules: []netconf.YangModule{{
+				Name: "ietf-yang-types", Revision: "2013-07-15", Namespace: "urn:ietf:params:xml:ns:yang:ietf-yang-types",
+			}},
+		}},
+		Schemas:     []netconf.YangSchema{{Name: "config-schema", ModuleSets: []string{"config-modules"}}},
+		Datastores:  []netconf.YangDatastore{{Name: "running", Schema: "config-schema"}},
+		ModuleSetID: "42",
+		Modules: []netconf.YangModule{{
+			Name: "ietf-ip", Revision: "2018-02-22", Namespace: "urn:ietf:params:xml:ns:yang:ietf-ip",
+			Deviations:      []netconf.YangDeviation{{Name: "example-deviations", Revision: "2021-11-01"}},
+			ConformanceType: "implement",
+		}},
+	}
+	if !reflect.DeepEqual(library, want) {
+		t.Errorf("got yang library %+v, wanted %+v", library, want)
+	}
+
+	for _, name := range []string{"ietf-interfaces", "ietf-ip"} {
+		if _, ok := library.Module(name); !ok {
+			t.Errorf("expected module %s to be implemented", name)
+		}
+	}
+	if _, ok := library.Module("ietf-yang-types"); ok {
+		t.Errorf("expected import-only module ietf-yang-types not to be implemented")
+	}
+}